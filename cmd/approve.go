@@ -0,0 +1,85 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"os/user"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.temporal.io/sdk/client"
+)
+
+var approvalOpts workflow.ApprovalInput
+
+// approveCmd represents the approve command
+var approveCmd = &cobra.Command{
+	Use:   "approve <workflow-id>",
+	Short: "Approve a provisioning workflow awaiting sign-off",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sendApprovalDecision(args[0], workflow.ApproveUpdate)
+	},
+}
+
+// Send the approve/reject update to the workflow and wait for it to be accepted
+func sendApprovalDecision(workflowID, updateName string) {
+	c, err := newClient()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create Temporal client")
+	}
+	defer c.Close()
+
+	handle, err := c.UpdateWorkflow(context.Background(), client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   updateName,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []any{approvalOpts},
+	})
+	if err != nil {
+		log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to send decision")
+	}
+
+	var decision providers.ApprovalDecision
+	if err := handle.Get(context.Background(), &decision); err != nil {
+		log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to get decision result")
+	}
+
+	log.Info().Str("WorkflowID", workflowID).Interface("decision", decision).Msg("Decision recorded")
+}
+
+func addApprovalFlags(cmd *cobra.Command) {
+	defaultApprover := ""
+	if u, err := user.Current(); err == nil {
+		defaultApprover = u.Username
+	}
+
+	bindEnv("approver", defaultApprover)
+	cmd.Flags().StringVar(&approvalOpts.Approver, "approver", viper.GetString("approver"), "Identity of the person making the decision")
+
+	cmd.Flags().StringVar(&approvalOpts.Comment, "comment", "", "Comment to record with the decision")
+}
+
+func init() {
+	rootCmd.AddCommand(approveCmd)
+
+	addApprovalFlags(approveCmd)
+}
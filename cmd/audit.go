@@ -0,0 +1,136 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	auditSinkNone      = "none"
+	auditSinkFile      = "file"
+	auditSinkWebhook   = "webhook"
+	auditSinkInventory = "inventory"
+)
+
+var auditOpts struct {
+	audit.Filter
+	Since time.Duration
+}
+
+// Create the sink from the audit flags. The events aren't recorded if the
+// sink is none, or is the inventory and the inventory is disabled.
+func newAuditSink() (audit.Sink, error) {
+	switch rootOpts.Audit.Sink {
+	case auditSinkNone:
+		return nil, nil
+	case auditSinkFile:
+		if rootOpts.Audit.File == "" {
+			return nil, fmt.Errorf("--audit-file is required for the %s sink", auditSinkFile)
+		}
+		return audit.NewFileSink(rootOpts.Audit.File), nil
+	case auditSinkWebhook:
+		if rootOpts.Audit.WebhookURL == "" {
+			return nil, fmt.Errorf("--audit-webhook-url is required for the %s sink", auditSinkWebhook)
+		}
+		return audit.NewWebhookSink(rootOpts.Audit.WebhookURL), nil
+	case auditSinkInventory:
+		if rootOpts.InventoryFile == "" {
+			log.Warn().Msg("The inventory is disabled so audit events will not be recorded")
+			return nil, nil
+		}
+		return inventory.NewBoltStore(rootOpts.InventoryFile), nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink: %s", rootOpts.Audit.Sink)
+	}
+}
+
+func printAuditTable(w io.Writer, events []*audit.Event) error {
+	fmt.Fprintln(w, "TIME\tACTOR\tOPERATION\tRESOURCE\tPROJECT ID\tRESULT\tWORKFLOW ID")
+	for _, e := range events {
+		resource := e.ResourceID
+		if resource == "" {
+			resource = e.ResourceName
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Format(time.RFC3339),
+			e.Actor,
+			e.Operation,
+			resource,
+			e.ProjectID,
+			e.Result,
+			e.WorkflowID,
+		)
+	}
+	return nil
+}
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Search the audit events of the changes made to the cloud providers",
+	Run: func(cmd *cobra.Command, args []string) {
+		sink, err := newAuditSink()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create audit sink")
+		}
+
+		searcher, ok := sink.(audit.Searcher)
+		if !ok {
+			log.Fatal().Str("sink", rootOpts.Audit.Sink).Msg("The audit sink cannot be searched")
+		}
+
+		filter := auditOpts.Filter
+		if auditOpts.Since > 0 {
+			filter.Since = time.Now().Add(-auditOpts.Since)
+		}
+
+		events, err := searcher.Search(context.Background(), filter)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to search audit events")
+		}
+
+		if err := printResult(events, printAuditTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print audit events")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().StringVar(&auditOpts.Actor, "actor", "", "Only show changes made for this actor, eg alice@laptop")
+	auditCmd.Flags().StringVar((*string)(&auditOpts.Operation), "operation", "", "Only show this operation, eg delete-node")
+	auditCmd.Flags().StringVar(&auditOpts.ResourceID, "resource-id", "", "Only show changes to this resource")
+	auditCmd.Flags().StringVar(&auditOpts.ProjectID, "project-id", "", "Only show changes in this project")
+	auditCmd.Flags().StringVar(&auditOpts.WorkflowID, "workflow-id", "", "Only show changes made by this workflow")
+	auditCmd.Flags().StringVar((*string)(&auditOpts.Result), "result", "", "Only show changes with this result - success or failure")
+	auditCmd.Flags().DurationVar(&auditOpts.Since, "since", 0, "Only show changes made within this duration, eg 24h")
+	auditCmd.Flags().IntVar(&auditOpts.Limit, "limit", 100, "Maximum number of events to show - 0 shows all")
+	addOutputFlags(auditCmd)
+}
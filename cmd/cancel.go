@@ -0,0 +1,48 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// cancelCmd represents the cancel command
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <workflow-id>",
+	Short: "Stop a provisioning workflow, deleting any resources it created",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		if err := c.CancelWorkflow(context.Background(), args[0], ""); err != nil {
+			log.Fatal().Err(err).Str("WorkflowID", args[0]).Msg("Unable to cancel workflow")
+		}
+
+		log.Info().Str("WorkflowID", args[0]).Msg("Cancellation requested - the workflow will clean up its resources")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cancelCmd)
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/codec"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var codecServerOpts struct {
+	Listen string
+	codec.ServerOptions
+}
+
+// codecServerCmd represents the codec-server command
+var codecServerCmd = &cobra.Command{
+	Use:   "codec-server",
+	Short: "Serve the payload codec so the Temporal UI can decrypt the payloads",
+	Run: func(cmd *cobra.Command, args []string) {
+		if rootOpts.EncryptionKeyFile == "" {
+			log.Fatal().Msg("The encryption key file is required")
+		}
+
+		c, err := loadCodec()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create codec")
+		}
+
+		if err := codec.CheckListenAddress(codecServerOpts.Listen, codecServerOpts.ServerOptions); err != nil {
+			log.Fatal().Err(err).Msg("Refusing to start codec server")
+		}
+
+		srv := &http.Server{
+			Addr:              codecServerOpts.Listen,
+			Handler:           codec.NewHTTPHandler(c, codecServerOpts.ServerOptions),
+			ReadHeaderTimeout: time.Second * 10,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		go func() {
+			<-ctx.Done()
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				log.Error().Err(err).Msg("Error stopping codec server")
+			}
+		}()
+
+		log.Info().Str("address", codecServerOpts.Listen).Msg("Starting codec server")
+
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("Error running codec server")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(codecServerCmd)
+
+	bindEnv("codec-listen", "127.0.0.1:8081")
+	codecServerCmd.Flags().StringVar(&codecServerOpts.Listen, "listen", viper.GetString("codec-listen"), "Address to serve the codec on")
+
+	bindEnv("codec-allowed-origins", []string{"http://localhost:8233"})
+	codecServerCmd.Flags().StringSliceVar(
+		&codecServerOpts.AllowedOrigins,
+		"allowed-origins",
+		viper.GetStringSlice("codec-allowed-origins"),
+		"Exact origins of the Temporal UI allowed to call the codec server",
+	)
+
+	bindEnv("codec-auth-token", "")
+	codecServerCmd.Flags().StringVar(
+		&codecServerOpts.AuthToken,
+		"auth-token",
+		viper.GetString("codec-auth-token"),
+		"Bearer token the Temporal UI must send - required unless listening on loopback",
+	)
+}
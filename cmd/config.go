@@ -0,0 +1,110 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mrsimonemms/temporal/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Loaded before every command
+var appConfig *config.Config
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the Temporal connection profiles",
+}
+
+// Load the config file and apply the selected profile. The profile's values
+// are only used where the flag or environment variable hasn't been set.
+func loadProfile(cmd *cobra.Command) error {
+	file := rootOpts.ConfigFile
+	if file == "" {
+		var err error
+		if file, err = config.DefaultFile(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if appConfig, err = config.Load(file); err != nil {
+		return err
+	}
+
+	// The config commands report on the profiles rather than use them
+	if cmd.Parent() == configCmd {
+		return nil
+	}
+
+	name, profile, err := appConfig.Active(rootOpts.Profile)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return nil
+	}
+
+	log.Debug().Str("profile", name).Str("file", file).Msg("Using profile")
+
+	set := func(flag string, target *string, val string) {
+		_, isEnv := os.LookupEnv(strings.ToUpper(strings.ReplaceAll(flag, "-", "_")))
+		if val != "" && !isEnv && !cmd.Flags().Changed(flag) {
+			*target = val
+		}
+	}
+
+	set("temporal-address", &rootOpts.Host, profile.Address)
+	set("temporal-namespace", &rootOpts.Namespace, profile.Namespace)
+	set("temporal-key", &rootOpts.APIKey, profile.APIKey)
+	set("tls-cert", &rootOpts.TLS.CertFile, profile.TLS.Cert)
+	set("tls-key", &rootOpts.TLS.KeyFile, profile.TLS.Key)
+	set("tls-ca", &rootOpts.TLS.CAFile, profile.TLS.CA)
+	set("tls-server-name", &rootOpts.TLS.ServerName, profile.TLS.ServerName)
+
+	if profile.TLS.Enabled || profile.Auth == config.AuthMTLS {
+		rootOpts.TLS.Enabled = true
+	}
+
+	return nil
+}
+
+// Get a profile by name, defaulting to the active one
+func getProfile(args []string) (string, *config.Profile, error) {
+	selected := rootOpts.Profile
+	if len(args) > 0 {
+		selected = args[0]
+	}
+
+	name, profile, err := appConfig.Active(selected)
+	if err != nil {
+		return "", nil, err
+	}
+	if profile == nil {
+		return "", nil, fmt.Errorf("no profile selected and no currentProfile set in %s", appConfig.File)
+	}
+	return name, profile, nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
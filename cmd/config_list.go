@@ -0,0 +1,85 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mrsimonemms/temporal/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// profileSummary is a row in the profile list
+type profileSummary struct {
+	Name      string
+	Active    bool
+	Address   string
+	Namespace string
+	Auth      config.AuthMethod
+}
+
+func printProfileListTable(w io.Writer, profiles []profileSummary) error {
+	fmt.Fprintln(w, "ACTIVE\tNAME\tADDRESS\tNAMESPACE\tAUTH")
+	for _, p := range profiles {
+		marker := ""
+		if p.Active {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", marker, p.Name, p.Address, p.Namespace, p.Auth)
+	}
+	return nil
+}
+
+// configListCmd represents the config list command
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles",
+	Run: func(cmd *cobra.Command, args []string) {
+		// An unknown profile is reported by the validate command
+		active, _, _ := appConfig.Active(rootOpts.Profile)
+
+		profiles := make([]profileSummary, 0, len(appConfig.Profiles))
+		for _, name := range appConfig.Names() {
+			profile := appConfig.Profiles[name]
+
+			auth := profile.Auth
+			if auth == "" {
+				auth = config.AuthNone
+			}
+
+			profiles = append(profiles, profileSummary{
+				Name:      name,
+				Active:    name == active,
+				Address:   profile.Address,
+				Namespace: profile.Namespace,
+				Auth:      auth,
+			})
+		}
+
+		if err := printResult(profiles, printProfileListTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print profiles")
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd)
+
+	addOutputFlags(configListCmd)
+}
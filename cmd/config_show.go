@@ -0,0 +1,57 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show [profile]",
+	Short: "Show a profile - defaults to the active profile",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, profile, err := getProfile(args)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to get profile")
+		}
+
+		// Don't print the secret
+		redacted := *profile
+		if redacted.APIKey != "" {
+			redacted.APIKey = "xxx"
+		}
+
+		fmt.Printf("# %s\n", name)
+
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(redacted); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print profile")
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+}
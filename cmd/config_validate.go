@@ -0,0 +1,53 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check every profile has the settings for its auth method",
+	Run: func(cmd *cobra.Command, args []string) {
+		errs := appConfig.Validate()
+
+		for _, name := range appConfig.Names() {
+			if err, ok := errs[name]; ok {
+				log.Error().Err(err).Str("profile", name).Msg("Invalid profile")
+				continue
+			}
+			fmt.Printf("%s: valid\n", name)
+		}
+
+		if err, ok := errs[appConfig.CurrentProfile]; ok && appConfig.Profiles[appConfig.CurrentProfile] == nil {
+			log.Error().Err(err).Str("profile", appConfig.CurrentProfile).Msg("Invalid profile")
+		}
+
+		if len(errs) > 0 {
+			log.Fatal().Int("invalid", len(errs)).Str("file", appConfig.File).Msg("Config is invalid")
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"go.temporal.io/api/serviceerror"
+)
+
+// costCmd represents the cost command
+var costCmd = &cobra.Command{
+	Use:   "cost <workflow-id>",
+	Short: "Show the cost accrued by a provisioned project",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		ctx := context.Background()
+		workflowID := args[0]
+
+		// Once the project has been torn down, the teardown workflow knows when
+		// each node was deleted so the cost stops accruing then rather than now
+		teardownID := workflow.TeardownWorkflowID(workflowID)
+		if _, err := c.DescribeWorkflowExecution(ctx, teardownID, ""); err == nil {
+			workflowID = teardownID
+		} else if notFound := new(serviceerror.NotFound); !errors.As(err, &notFound) {
+			log.Fatal().Err(err).Str("WorkflowID", teardownID).Msg("Unable to check for teardown")
+		}
+
+		val, err := c.QueryWorkflow(ctx, workflowID, "", workflow.CostQuery, time.Now().UTC())
+		if err != nil {
+			log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to query cost")
+		}
+
+		var report providers.CostReport
+		if err := val.Get(&report); err != nil {
+			log.Fatal().Err(err).Msg("Unable to decode cost report")
+		}
+
+		if err := printResult(&report, printCostTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print cost report")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(costCmd)
+
+	addOutputFlags(costCmd)
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mrsimonemms/temporal/pkg/dns"
+)
+
+// Create the DNS provider from the dns flags. Projects with a DNS zone fail
+// if no provider is configured.
+func newDNSProvider() (dns.Provider, error) {
+	if rootOpts.DNS.Server == "" {
+		return nil, nil
+	}
+
+	p, err := dns.NewRFC2136Provider(rootOpts.DNS.Server, rootOpts.DNS.TSIGKey, rootOpts.DNS.TSIGSecret, rootOpts.DNS.TSIGAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dns provider: %w", err)
+	}
+	return p, nil
+}
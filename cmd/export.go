@@ -0,0 +1,76 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mrsimonemms/temporal/pkg/export"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var exportOpts struct {
+	Format string
+	RunID  string
+}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export <workflow-id>",
+	Short: "Export a completed project's nodes for use by other tools",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		workflowID := args[0]
+
+		exporter, err := export.Get(exportOpts.Format)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid format")
+		}
+
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		project, err := completedProject(ctx, c, workflowID, exportOpts.RunID)
+		if err != nil {
+			log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to get project")
+		}
+
+		if err := exporter.Export(os.Stdout, project); err != nil {
+			log.Fatal().Err(err).Str("format", exportOpts.Format).Msg("Unable to export project")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(
+		&exportOpts.Format,
+		"format",
+		export.FormatAnsible,
+		fmt.Sprintf("Format to export - %s", strings.Join(export.Formats(), ", ")),
+	)
+	exportCmd.Flags().StringVar(&exportOpts.RunID, "run-id", "", "Run ID of the workflow - defaults to the latest run")
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// inventoryCmd represents the inventory command
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Report on the resources that have been created",
+}
+
+// The inventory is disabled if there's no file
+func newInventoryStore() inventory.Store {
+	if rootOpts.InventoryFile == "" {
+		return nil
+	}
+	return inventory.NewBoltStore(rootOpts.InventoryFile)
+}
+
+// Get the inventory for a command which reads it
+func mustInventoryStore() inventory.Store {
+	store := newInventoryStore()
+	if store == nil {
+		log.Fatal().Msg("The inventory is disabled - set --inventory-file")
+	}
+	return store
+}
+
+func init() {
+	rootCmd.AddCommand(inventoryCmd)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var inventoryListOpts inventory.Filter
+
+func printInventoryTable(w io.Writer, records []*inventory.Record) error {
+	fmt.Fprintln(w, "ID\tKIND\tNAME\tPROJECT ID\tPROVIDER\tREGION\tOWNER\tWORKFLOW ID\tCREATED\tDELETED")
+	for _, r := range records {
+		deleted := ""
+		if r.Deleted() {
+			deleted = r.DeletedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID,
+			r.Kind,
+			r.Name,
+			r.ProjectID,
+			r.Provider,
+			r.Region,
+			r.Owner,
+			r.WorkflowID,
+			r.CreatedAt.Format(time.RFC3339),
+			deleted,
+		)
+	}
+	return nil
+}
+
+// inventoryListCmd represents the inventory list command
+var inventoryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the resources in the inventory",
+	Run: func(cmd *cobra.Command, args []string) {
+		records, err := mustInventoryStore().List(context.Background(), inventoryListOpts)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to list inventory")
+		}
+
+		if err := printResult(records, printInventoryTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print inventory")
+		}
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryListCmd)
+
+	inventoryListCmd.Flags().StringVar(
+		(*string)(&inventoryListOpts.Kind),
+		"kind",
+		"",
+		fmt.Sprintf(
+			"Only show resources of this kind - %s, %s, %s, %s or %s",
+			inventory.KindProject, inventory.KindNetwork, inventory.KindSecurityGroup, inventory.KindNode, inventory.KindLoadBalancer,
+		),
+	)
+	inventoryListCmd.Flags().StringVar(&inventoryListOpts.ProjectID, "project-id", "", "Only show resources in this project")
+	inventoryListCmd.Flags().StringVar(&inventoryListOpts.WorkflowID, "workflow-id", "", "Only show resources created by this workflow")
+	inventoryListCmd.Flags().StringVar((*string)(&inventoryListOpts.Provider), "provider", "", "Only show resources for this cloud provider")
+	inventoryListCmd.Flags().StringVar(&inventoryListOpts.Region, "region", "", "Only show resources in this region")
+	inventoryListCmd.Flags().StringVar(&inventoryListOpts.Owner, "owner", "", "Only show resources for this owner")
+	inventoryListCmd.Flags().StringVar(&inventoryListOpts.Environment, "environment", "", "Only show resources for this environment")
+	inventoryListCmd.Flags().BoolVar(&inventoryListOpts.Deleted, "deleted", false, "Include resources which have been deleted")
+	addOutputFlags(inventoryListCmd)
+}
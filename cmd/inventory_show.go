@@ -0,0 +1,100 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// inventoryDetail is a resource and, for a project, the resources in it
+type inventoryDetail struct {
+	Resource  *inventory.Record
+	Resources []*inventory.Record `json:",omitempty" yaml:",omitempty"`
+}
+
+func printInventoryDetailTable(w io.Writer, detail *inventoryDetail) error {
+	r := detail.Resource
+
+	fmt.Fprintf(w, "ID\t%s\n", r.ID)
+	fmt.Fprintf(w, "KIND\t%s\n", r.Kind)
+	fmt.Fprintf(w, "NAME\t%s\n", r.Name)
+	fmt.Fprintf(w, "PROJECT ID\t%s\n", r.ProjectID)
+	fmt.Fprintf(w, "PROVIDER\t%s\n", r.Provider)
+	fmt.Fprintf(w, "REGION\t%s\n", r.Region)
+	fmt.Fprintf(w, "OWNER\t%s\n", r.Owner)
+	fmt.Fprintf(w, "ENVIRONMENT\t%s\n", r.Environment)
+	for _, key := range slices.Sorted(maps.Keys(r.Details)) {
+		fmt.Fprintf(w, "%s\t%s\n", key, r.Details[key])
+	}
+	fmt.Fprintf(w, "CREATED\t%s by %s\n", r.CreatedAt.Format(time.RFC3339), r.WorkflowID)
+	if r.Deleted() {
+		fmt.Fprintf(w, "DELETED\t%s by %s\n", r.DeletedAt.Format(time.RFC3339), r.DeletedBy)
+	}
+
+	if len(detail.Resources) > 0 {
+		fmt.Fprintln(w)
+		return printInventoryTable(w, detail.Resources)
+	}
+	return nil
+}
+
+// inventoryShowCmd represents the inventory show command
+var inventoryShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a resource in the inventory",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		store := mustInventoryStore()
+
+		record, err := store.Get(ctx, args[0])
+		if err != nil {
+			log.Fatal().Err(err).Str("id", args[0]).Msg("Unable to get resource")
+		}
+
+		detail := &inventoryDetail{Resource: record}
+		if record.Kind == inventory.KindProject {
+			resources, err := store.List(ctx, inventory.Filter{ProjectID: record.ID, Deleted: true})
+			if err != nil {
+				log.Fatal().Err(err).Str("id", args[0]).Msg("Unable to list project resources")
+			}
+
+			detail.Resources = slices.DeleteFunc(resources, func(r *inventory.Record) bool {
+				return r.ID == record.ID
+			})
+		}
+
+		if err := printResult(detail, printInventoryDetailTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print resource")
+		}
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryShowCmd)
+
+	addOutputFlags(inventoryShowCmd)
+}
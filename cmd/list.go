@@ -0,0 +1,190 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+)
+
+var listOpts struct {
+	workflow.ListFilter
+	Limit     int32
+	PageToken string
+}
+
+// projectSummary is a row in the list
+type projectSummary struct {
+	WorkflowID  string
+	RunID       string
+	Type        string
+	Status      string
+	Phase       string
+	StartTime   time.Time
+	CloseTime   *time.Time `json:",omitempty" yaml:",omitempty"`
+	Provider    string
+	Region      string
+	NodeCount   int64
+	Owner       string
+	Environment string
+	ProjectID   string
+}
+
+type projectList struct {
+	Projects []projectSummary
+	// Pass to --page-token to get the next page. Empty on the last page.
+	NextPageToken string
+}
+
+// Read a search attribute, leaving the value empty if it's not set
+func searchAttribute(execution *workflowpb.WorkflowExecutionInfo, key temporal.SearchAttributeKey, val any) {
+	payload, ok := execution.GetSearchAttributes().GetIndexedFields()[key.GetName()]
+	if !ok {
+		return
+	}
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, val); err != nil {
+		log.Debug().Err(err).Str("key", key.GetName()).Msg("Unable to decode search attribute")
+	}
+}
+
+// Convert the status to the format used in the queries, eg
+// WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW is ContinuedAsNew
+func executionStatus(execution *workflowpb.WorkflowExecutionInfo) string {
+	words := strings.Split(strings.TrimPrefix(execution.GetStatus().String(), "WORKFLOW_EXECUTION_STATUS_"), "_")
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+	return strings.Join(words, "")
+}
+
+func newProjectSummary(execution *workflowpb.WorkflowExecutionInfo) projectSummary {
+	summary := projectSummary{
+		WorkflowID: execution.GetExecution().GetWorkflowId(),
+		RunID:      execution.GetExecution().GetRunId(),
+		Type:       execution.GetType().GetName(),
+		Status:     executionStatus(execution),
+		StartTime:  execution.GetStartTime().AsTime(),
+	}
+	if execution.GetCloseTime() != nil {
+		closeTime := execution.GetCloseTime().AsTime()
+		summary.CloseTime = &closeTime
+	}
+
+	searchAttribute(execution, workflow.PhaseSearchAttribute, &summary.Phase)
+	searchAttribute(execution, workflow.ProviderSearchAttribute, &summary.Provider)
+	searchAttribute(execution, workflow.RegionSearchAttribute, &summary.Region)
+	searchAttribute(execution, workflow.NodeCountSearchAttribute, &summary.NodeCount)
+	searchAttribute(execution, workflow.OwnerSearchAttribute, &summary.Owner)
+	searchAttribute(execution, workflow.EnvironmentSearchAttribute, &summary.Environment)
+	searchAttribute(execution, workflow.ProjectIDSearchAttribute, &summary.ProjectID)
+
+	return summary
+}
+
+func printProjectListTable(w io.Writer, list *projectList) error {
+	fmt.Fprintln(w, "WORKFLOW ID\tSTATUS\tPHASE\tAGE\tPROVIDER\tREGION\tNODES\tOWNER\tENVIRONMENT")
+	for _, p := range list.Projects {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			p.WorkflowID,
+			p.Status,
+			p.Phase,
+			time.Since(p.StartTime).Truncate(time.Second),
+			p.Provider,
+			p.Region,
+			p.NodeCount,
+			p.Owner,
+			p.Environment,
+		)
+	}
+
+	if list.NextPageToken != "" {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "More results available with --page-token %s\n", list.NextPageToken)
+	}
+
+	return nil
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the provisioning workflows",
+	Run: func(cmd *cobra.Command, args []string) {
+		pageToken, err := base64.URLEncoding.DecodeString(listOpts.PageToken)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid page token")
+		}
+
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		query := listOpts.Query()
+		log.Debug().Str("query", query).Msg("Listing workflows")
+
+		res, err := c.ListWorkflow(context.Background(), &workflowservice.ListWorkflowExecutionsRequest{
+			Query:         query,
+			PageSize:      listOpts.Limit,
+			NextPageToken: pageToken,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to list workflows")
+		}
+
+		list := &projectList{
+			Projects:      make([]projectSummary, 0, len(res.GetExecutions())),
+			NextPageToken: base64.URLEncoding.EncodeToString(res.GetNextPageToken()),
+		}
+		for _, execution := range res.GetExecutions() {
+			list.Projects = append(list.Projects, newProjectSummary(execution))
+		}
+
+		if err := printResult(list, printProjectListTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print workflows")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().StringVar(&listOpts.Provider, "provider", "", "Only show workflows for this cloud provider")
+	listCmd.Flags().StringVar(&listOpts.Region, "region", "", "Only show workflows in this region")
+	listCmd.Flags().StringVar(&listOpts.Owner, "owner", "", "Only show workflows for this owner")
+	listCmd.Flags().StringVar(&listOpts.Environment, "environment", "", "Only show workflows for this environment")
+	listCmd.Flags().StringVar(&listOpts.Phase, "phase", "", "Only show workflows in this phase, eg provisioning")
+	listCmd.Flags().StringVar(&listOpts.Status, "status", "", "Only show workflows with this status, eg Running or Failed")
+	listCmd.Flags().Int32Var(&listOpts.Limit, "limit", 20, "Maximum number of workflows to show")
+	listCmd.Flags().StringVar(&listOpts.PageToken, "page-token", "", "Show the next page of a previous list")
+	addOutputFlags(listCmd)
+}
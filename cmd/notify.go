@@ -0,0 +1,49 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mrsimonemms/temporal/pkg/notify"
+)
+
+// Create the notifier from the notify flags. Nothing is sent if no
+// notifiers are configured.
+func newNotifier() (notify.Notifier, error) {
+	templates := notify.DefaultTemplates
+	if rootOpts.Notify.TemplatesFile != "" {
+		t, err := notify.LoadTemplates(rootOpts.Notify.TemplatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading notification templates: %w", err)
+		}
+		templates = t
+	}
+
+	notifiers := make(notify.Multi, 0)
+	if rootOpts.Notify.WebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(rootOpts.Notify.WebhookURL, []byte(rootOpts.Notify.WebhookSecret), templates))
+	}
+	if rootOpts.Notify.SlackURL != "" {
+		notifiers = append(notifiers, notify.NewSlackNotifier(rootOpts.Notify.SlackURL, templates))
+	}
+
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	return notifiers, nil
+}
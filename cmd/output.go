@@ -0,0 +1,261 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputGoTemplate = "go-template"
+	outputJSON       = "json"
+	outputTable      = "table"
+	outputYAML       = "yaml"
+)
+
+// How results are written to stdout. Logs always go to stderr so the results
+// can be piped into other tools.
+var outputOpts struct {
+	Format   string
+	Template string
+}
+
+// Add the output flags to a command which prints a result
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(
+		&outputOpts.Format,
+		"output",
+		"o",
+		outputTable,
+		fmt.Sprintf(
+			"Output format - %s, %s, %s or %s=<template>",
+			outputTable, outputJSON, outputYAML, outputGoTemplate,
+		),
+	)
+	cmd.Flags().StringVar(&outputOpts.Template, "template", "", "Go template used by --output go-template")
+}
+
+// Print a command's result to stdout in the format set by the output flags
+func printResult[T any](data T, table func(io.Writer, T) error) error {
+	format := outputOpts.Format
+	tmpl := outputOpts.Template
+	if f, t, ok := strings.Cut(format, "="); ok {
+		format = f
+		tmpl = t
+	}
+
+	if format == outputGoTemplate {
+		return printTemplate(os.Stdout, tmpl, data)
+	}
+
+	return printOutput(os.Stdout, format, data, table)
+}
+
+// Render the data with a Go template, eg "{{range .Nodes}}{{.Address}}{{end}}"
+func printTemplate(w io.Writer, tmpl string, data any) error {
+	if tmpl == "" {
+		return fmt.Errorf("a template is required for %s output", outputGoTemplate)
+	}
+
+	t, err := template.New("output").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("error parsing template: %w", err)
+	}
+
+	if err := t.Execute(w, data); err != nil {
+		return fmt.Errorf("error executing template: %w", err)
+	}
+
+	// Finish the line so the shell prompt isn't appended to the output
+	if !strings.HasSuffix(tmpl, "\n") {
+		_, err = fmt.Fprintln(w)
+	}
+	return err
+}
+
+// Print the data in the requested format. The table function is used to
+// render the human-readable version.
+func printOutput[T any](w io.Writer, format string, data T, table func(io.Writer, T) error) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if err := table(tw, data); err != nil {
+			return err
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+func printPlanTable(w io.Writer, cfg *providers.CloudConfig) error {
+	plan := cfg.Plan
+
+	fmt.Fprintf(w, "PROJECT\t%s\n", plan.Project.Name)
+	fmt.Fprintf(w, "PROVIDER\t%s\n", cfg.Provider)
+	fmt.Fprintf(w, "REGION\t%s\n", plan.Network.Region)
+	fmt.Fprintf(w, "SUBNET\t%s\n", plan.Network.Subnet)
+	fmt.Fprintln(w)
+
+	if len(plan.Network.Subnets) > 0 {
+		fmt.Fprintln(w, "SUBNET\tTIER\tZONE\tPOOL\tCIDR")
+		for _, s := range plan.Network.Subnets {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Tier, s.Zone, s.Pool, s.CIDR)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "NODE\tPOOL\tZONE\tSUBNET\tINSTANCE TYPE\tHOURLY\tMONTHLY")
+	for _, node := range plan.Nodes {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t$%.4f\t$%.2f\n",
+			node.Name, node.Pool, node.Zone, node.Subnet, node.InstanceType, node.HourlyCost, node.MonthlyCost,
+		)
+	}
+	fmt.Fprintf(w, "TOTAL\t\t\t\t\t$%.4f\t$%.2f\n", plan.HourlyCost, plan.MonthlyCost)
+
+	if len(plan.LoadBalancers) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "LOAD BALANCER\tPOOL\tTIER\tLISTENERS")
+		for _, lb := range plan.LoadBalancers {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", lb.Name, lb.Pool, lb.SubnetTier(), formatListeners(lb.WithDefaults().Listeners))
+		}
+	}
+
+	return nil
+}
+
+func printCostTable(w io.Writer, report *providers.CostReport) error {
+	fmt.Fprintf(w, "PROJECT\t%s\n", report.ProjectName)
+	fmt.Fprintf(w, "PROJECT ID\t%s\n", report.ProjectID)
+	fmt.Fprintf(w, "AS OF\t%s\n", report.AsOf.Format(time.RFC3339))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "NODE\tCREATED\tDELETED\tHOURLY\tACCRUED")
+	for _, node := range report.Nodes {
+		deleted := "-"
+		if node.DeletedAt != nil {
+			deleted = node.DeletedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t$%.4f\t$%.2f\n",
+			node.Name, node.CreatedAt.Format(time.RFC3339), deleted, node.HourlyCost, node.AccruedCost,
+		)
+	}
+	fmt.Fprintf(w, "TOTAL\t\t\t$%.4f\t$%.2f\n", report.HourlyCost, report.AccruedCost)
+	fmt.Fprintf(w, "MONTHLY ESTIMATE\t\t\t\t$%.2f\n", report.MonthlyCost)
+
+	return nil
+}
+
+func printProjectTable(w io.Writer, project *providers.ProjectResult) error {
+	fmt.Fprintf(w, "PROJECT\t%s\n", project.Name)
+	fmt.Fprintf(w, "PROJECT ID\t%s\n", project.ID)
+	fmt.Fprintf(w, "PROVIDER\t%s\n", project.Provider)
+	fmt.Fprintf(w, "REGION\t%s\n", project.Region)
+	if project.Network != nil {
+		fmt.Fprintf(w, "NETWORK ID\t%s\n", project.Network.ID)
+		fmt.Fprintf(w, "SUBNET\t%s\n", project.Network.Subnet)
+	}
+	if project.Approval != nil {
+		fmt.Fprintf(w, "APPROVED BY\t%s\n", project.Approval.Approver)
+	}
+	fmt.Fprintln(w)
+
+	if project.Network != nil && len(project.Network.Subnets) > 0 {
+		fmt.Fprintln(w, "SUBNET\tID\tTIER\tCIDR")
+		for _, s := range project.Network.Subnets {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.ID, s.Tier, s.CIDR)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(project.SecurityGroups) > 0 {
+		fmt.Fprintln(w, "SECURITY GROUP\tID\tRULES")
+		for _, group := range project.SecurityGroups {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", group.Name, group.ID, len(group.Rules))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "NODE\tID\tPOOL\tZONE\tINSTANCE TYPE\tADDRESS\tHOURLY")
+	for _, node := range project.Nodes {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s:%d\t$%.4f\n",
+			node.Name,
+			node.ID,
+			node.Pool,
+			node.Zone,
+			node.InstanceType,
+			node.Address,
+			node.Port,
+			node.HourlyCost,
+		)
+	}
+
+	if len(project.LoadBalancers) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "LOAD BALANCER\tID\tPOOL\tADDRESS\tLISTENERS\tTARGETS")
+		for _, lb := range project.LoadBalancers {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", lb.Name, lb.ID, lb.Pool, lb.Address, formatListeners(lb.Listeners), len(lb.Targets))
+		}
+	}
+
+	if len(project.DNSRecords) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "DNS RECORD\tTYPE\tADDRESS\tTTL")
+		for _, r := range project.DNSRecords {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.Type, r.Address, r.TTL)
+		}
+	}
+
+	return nil
+}
+
+// Listeners are shown as "http:80->8080,tcp:443->443"
+func formatListeners(listeners []providers.Listener) string {
+	parts := make([]string, 0, len(listeners))
+	for _, l := range listeners {
+		parts = append(parts, fmt.Sprintf("%s:%d->%d", l.Protocol, l.Port, l.TargetPort))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"go.temporal.io/sdk/client"
+)
+
+var planOpts struct {
+	Config   providers.CloudConfig
+	SaveFile string
+}
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Preview the resources that would be created without creating them",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		applySpec(&planOpts.Config)
+
+		workflowOptions := client.StartWorkflowOptions{
+			TaskQueue: rootOpts.TaskQueue,
+		}
+
+		we, err := c.ExecuteWorkflow(context.Background(), workflowOptions, workflow.PlanWorkflow, planOpts.Config)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to execute workflow")
+		}
+
+		log.Debug().Str("WorkflowID", we.GetID()).Str("RunID", we.GetRunID()).Msg("Started plan workflow")
+
+		var plan providers.Plan
+		if err := we.Get(context.Background(), &plan); err != nil {
+			log.Fatal().Err(err).Msg("Unable to get plan")
+		}
+
+		cfg := planOpts.Config
+		cfg.Plan = &plan
+
+		if planOpts.SaveFile != "" {
+			if err := savePlan(planOpts.SaveFile, &cfg); err != nil {
+				log.Fatal().Err(err).Str("file", planOpts.SaveFile).Msg("Unable to save plan")
+			}
+			log.Info().Str("file", planOpts.SaveFile).Msg("Plan saved")
+		}
+
+		if err := printResult(&cfg, printPlanTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print plan")
+		}
+	},
+}
+
+// Write the config and plan so it can be applied with "trigger --plan"
+func savePlan(file string, cfg *providers.CloudConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding plan: %w", err)
+	}
+
+	//nolint:gosec // plan file contains no secrets
+	return os.WriteFile(file, data, 0o644)
+}
+
+// Load a config and plan saved by the plan command
+func loadPlan(file string) (*providers.CloudConfig, error) {
+	//nolint:gosec // file is provided by the user
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan: %w", err)
+	}
+
+	var cfg providers.CloudConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error decoding plan: %w", err)
+	}
+
+	if cfg.Plan == nil {
+		return nil, fmt.Errorf("file contains no plan")
+	}
+
+	return &cfg, nil
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	addCloudConfigFlags(planCmd, &planOpts.Config)
+
+	addOutputFlags(planCmd)
+	planCmd.Flags().StringVar(&planOpts.SaveFile, "save", "", "Save the plan to a file so it can be applied with trigger --plan")
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+// rejectCmd represents the reject command
+var rejectCmd = &cobra.Command{
+	Use:   "reject <workflow-id>",
+	Short: "Reject a provisioning workflow awaiting sign-off",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sendApprovalDecision(args[0], workflow.RejectUpdate)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rejectCmd)
+
+	addApprovalFlags(rejectCmd)
+}
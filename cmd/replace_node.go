@@ -0,0 +1,78 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// replaceNodeCmd represents the replace-node command
+var replaceNodeCmd = &cobra.Command{
+	Use:   "replace-node <workflow-id> <node>",
+	Short: "Replace a node of a provisioned project, moving its traffic to the new node",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		workflowID := args[0]
+		node := args[1]
+		ctx := context.Background()
+
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		desc, err := c.DescribeWorkflowExecution(ctx, workflowID, "")
+		if err != nil {
+			log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to find workflow")
+		}
+
+		// Only the nodes of a project which has been provisioned are replaced
+		info := desc.GetWorkflowExecutionInfo()
+		if info.GetStatus() != enums.WORKFLOW_EXECUTION_STATUS_COMPLETED {
+			log.Fatal().Str("WorkflowID", workflowID).Str("status", info.GetStatus().String()).Msg("Workflow has not completed")
+		}
+		runID := info.GetExecution().GetRunId()
+
+		we, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+			ID:        workflow.ReplaceNodeWorkflowID(workflowID, node),
+			TaskQueue: rootOpts.TaskQueue,
+			Memo: map[string]any{
+				workflow.TriggeredByMemo: currentIdentity(),
+			},
+		}, workflow.ReplaceNodeWorkflow, workflow.ReplaceNodeRequest{
+			WorkflowID: workflowID,
+			RunID:      runID,
+			Node:       node,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to start node replacement workflow")
+		}
+
+		log.Info().Str("WorkflowID", we.GetID()).Str("RunID", we.GetRunID()).Str("node", node).Msg("Started node replacement workflow")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replaceNodeCmd)
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// How often the status is queried when following a workflow
+const followInterval = time.Second * 2
+
+var resultOpts struct {
+	RunID  string
+	Wait   bool
+	Follow bool
+}
+
+// Wait for the workflow to finish. If follow is set, the status is polled and
+// every change is logged until the result is ready.
+func waitForProject(ctx context.Context, c client.Client, workflowID, runID string, follow bool) (*providers.ProjectResult, error) {
+	var result providers.ProjectResult
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.GetWorkflow(ctx, workflowID, runID).Get(ctx, &result)
+	}()
+
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	var last workflow.ProvisionStatus
+	for {
+		if follow {
+			if status, err := queryStatus(ctx, c, workflowID, runID); err != nil {
+				log.Debug().Err(err).Str("WorkflowID", workflowID).Msg("Unable to query status")
+			} else if *status != last {
+				last = *status
+				log.Info().
+					Str("WorkflowID", workflowID).
+					Str("phase", string(status.Phase)).
+					Str("projectID", status.ProjectID).
+					Int("nodesCreated", status.NodesCreated).
+					Int("nodesPlanned", status.NodesPlanned).
+					Msg("Status changed")
+			}
+		}
+
+		select {
+		case err := <-done:
+			if err != nil {
+				return nil, err
+			}
+			return &result, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func queryStatus(ctx context.Context, c client.Client, workflowID, runID string) (*workflow.ProvisionStatus, error) {
+	val, err := c.QueryWorkflow(ctx, workflowID, runID, workflow.StatusQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var status workflow.ProvisionStatus
+	if err := val.Get(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Get the result of a workflow which has finished. If it's still running, the
+// error reports the current phase.
+func completedProject(ctx context.Context, c client.Client, workflowID, runID string) (*providers.ProjectResult, error) {
+	res, err := c.DescribeWorkflowExecution(ctx, workflowID, runID)
+	if err != nil {
+		return nil, fmt.Errorf("error describing workflow: %w", err)
+	}
+
+	info := res.GetWorkflowExecutionInfo()
+	if info.GetStatus() == enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		phase := "unknown"
+		if status, err := queryStatus(ctx, c, workflowID, info.GetExecution().GetRunId()); err == nil {
+			phase = string(status.Phase)
+		}
+		return nil, fmt.Errorf("workflow is still running in phase %s", phase)
+	}
+
+	return waitForProject(ctx, c, workflowID, info.GetExecution().GetRunId(), false)
+}
+
+// resultCmd represents the result command
+var resultCmd = &cobra.Command{
+	Use:   "result <workflow-id>",
+	Short: "Show the result of a provisioning workflow",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		workflowID := args[0]
+
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		var result *providers.ProjectResult
+		if resultOpts.Wait || resultOpts.Follow {
+			result, err = waitForProject(ctx, c, workflowID, resultOpts.RunID, resultOpts.Follow)
+		} else {
+			result, err = completedProject(ctx, c, workflowID, resultOpts.RunID)
+		}
+		if err != nil {
+			log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to get result - use --wait if the workflow is still running")
+		}
+
+		if err := printResult(result, printProjectTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print result")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resultCmd)
+
+	resultCmd.Flags().StringVar(&resultOpts.RunID, "run-id", "", "Run ID of the workflow - defaults to the latest run")
+	resultCmd.Flags().BoolVar(&resultOpts.Wait, "wait", false, "Wait for a running workflow to finish")
+	resultCmd.Flags().BoolVar(&resultOpts.Follow, "follow", false, "Log each status change while waiting - implies --wait")
+	addOutputFlags(resultCmd)
+}
@@ -17,54 +17,393 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/codec"
+	"github.com/mrsimonemms/temporal/pkg/dns"
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/server"
+	"github.com/mrsimonemms/temporal/pkg/telemetry"
 	"github.com/mrsimonemms/temporal/pkg/temporal"
 	"github.com/mrsimonemms/temporal/pkg/workflow"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
 	"go.temporal.io/sdk/worker"
 )
 
 var rootOpts struct {
-	APIKey    string
-	Host      string
-	Namespace string
+	ConfigFile  string
+	Profile     string
+	APIKey      string
+	Host        string
+	Namespace   string
+	PricingFile string
+	HTTPAddr    string
+	TaskQueue   string
+	Workflows   bool
+	Providers   []string
+	Quota       workflow.QuotaLimits
+	Policy      workflow.Policy
+	Worker      struct {
+		MaxConcurrentActivities    int
+		MaxConcurrentWorkflowTasks int
+		ActivityPollers            int
+		WorkflowPollers            int
+		StickyCacheSize            int
+		StopTimeout                time.Duration
+	}
+	EncryptionKeyFile string
+	InventoryFile     string
+	Audit             struct {
+		Sink       string
+		File       string
+		WebhookURL string
+	}
+	Notify struct {
+		WebhookURL    string
+		WebhookSecret string
+		SlackURL      string
+		TemplatesFile string
+	}
+	DNS struct {
+		Server        string
+		TSIGKey       string
+		TSIGSecret    string
+		TSIGAlgorithm string
+	}
+	TLS struct {
+		Enabled bool
+		temporal.TLSOptions
+	}
+	Tracing struct {
+		Exporter string
+		Endpoint string
+	}
+	RateLimit struct {
+		PerSecond                    float64
+		Burst                        int
+		Overrides                    map[string]string
+		TaskQueueActivitiesPerSecond float64
+		TaskQueueOverrides           map[string]string
+	}
 }
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "temporal",
 	Short: "Temporal demo application",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadProfile(cmd); err != nil {
+			return err
+		}
+
+		exporter := telemetry.TracingExporter(rootOpts.Tracing.Exporter)
+		if exporter == telemetry.TracingExporterNone {
+			return nil
+		}
+
+		var err error
+		tracing, err = telemetry.NewTracing(cmd.Context(), exporter, rootOpts.Tracing.Endpoint)
+		return err
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if tracing == nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		if err := tracing.Shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("Error flushing traces")
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		c, err := temporal.NewClient(rootOpts.Host, rootOpts.Namespace, rootOpts.APIKey)
+		if rootOpts.PricingFile != "" {
+			catalogue, err := providers.LoadPricingCatalogue(rootOpts.PricingFile)
+			if err != nil {
+				log.Fatal().Err(err).Str("file", rootOpts.PricingFile).Msg("Unable to load pricing catalogue")
+			}
+			providers.Pricing = catalogue
+		}
+
+		providers.Limiter = providers.NewRateLimiter(
+			rootOpts.RateLimit.PerSecond,
+			rootOpts.RateLimit.Burst,
+			parseRateLimitOverrides(rootOpts.RateLimit.Overrides),
+		)
+		taskQueueOverrides := parseRateLimitOverrides(rootOpts.RateLimit.TaskQueueOverrides)
+
+		clientOpts := make([]temporal.Option, 0)
+
+		var metrics *telemetry.Metrics
+		if rootOpts.HTTPAddr != "" {
+			metrics = telemetry.NewPrometheusMetrics()
+			defer func() {
+				_ = metrics.Close()
+			}()
+
+			clientOpts = append(clientOpts, temporal.WithMetricsHandler(metrics.Handler))
+		}
+
+		c, err := newClient(clientOpts...)
 		if err != nil {
 			log.Fatal().Err(err).Msg("Unable to create Temporal client")
 		}
 		defer c.Close()
 
-		w := worker.New(c, "cloud-provisioning", worker.Options{})
-
-		// Register the workflows
-		w.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
-		w.RegisterWorkflow(workflow.CloudProvisionWorkflow)
+		var httpServer *server.Server
+		if rootOpts.HTTPAddr != "" {
+			httpServer = server.New(rootOpts.HTTPAddr, c, metrics.HTTPHandler)
+			httpServer.Start()
+		}
 
-		// Register the activities
-		w.RegisterActivity(workflow.CreateProjectActivity)
-		w.RegisterActivity(workflow.SetupNetworkActivity)
-		w.RegisterActivity(workflow.ProvisionNodeActivity)
-		w.RegisterActivity(workflow.AwaitForNodeRunningActivity)
+		// This is shared by every worker in the process so must be set first
+		if rootOpts.Worker.StickyCacheSize > 0 {
+			worker.SetStickyWorkflowCacheSize(rootOpts.Worker.StickyCacheSize)
+		}
 
-		err = w.Run(worker.InterruptCh())
+		// The changes are audited by the activities that make them
+		sink, err := newAuditSink()
 		if err != nil {
-			log.Fatal().Err(err).Msg("Unable to start worker")
+			log.Fatal().Err(err).Msg("Unable to create audit sink")
+		}
+
+		workers := make([]worker.Worker, 0)
+
+		if rootOpts.Workflows {
+			// An unregistered search attribute would block every workflow
+			if err := workflow.CheckSearchAttributes(context.Background(), c.OperatorService(), rootOpts.Namespace); err != nil {
+				log.Fatal().Err(err).Msg("Search attributes are not registered")
+			}
+
+			w := worker.New(c, rootOpts.TaskQueue, workerOptions(sink))
+			registerWorkflows(w, c)
+			workers = append(workers, w)
+
+			log.Info().Str("taskQueue", rootOpts.TaskQueue).Msg("Serving workflows")
+		}
+
+		for _, p := range rootOpts.Providers {
+			provider, region, ok := strings.Cut(p, "/")
+			if !ok {
+				log.Fatal().Str("provider", p).Msg("Provider must be in the format <provider>/<region>")
+			}
+
+			// The task queue rate limit is enforced by the server across every
+			// worker serving this provider and region
+			taskQueue := workflow.ProviderTaskQueue(rootOpts.TaskQueue, providers.CloudProvider(provider), region)
+			opts := workerOptions(sink)
+			opts.TaskQueueActivitiesPerSecond = rootOpts.RateLimit.TaskQueueActivitiesPerSecond
+			if perSecond, ok := taskQueueOverrides[providers.RateLimitKey(providers.CloudProvider(provider), region)]; ok {
+				opts.TaskQueueActivitiesPerSecond = perSecond
+			}
+
+			w := worker.New(c, taskQueue, opts)
+			registerProviderActivities(w)
+			workers = append(workers, w)
+
+			log.Info().Str("taskQueue", taskQueue).Msg("Serving provider")
+		}
+
+		if len(workers) == 0 {
+			log.Fatal().Msg("Worker must serve workflows or at least one provider")
+		}
+
+		for _, w := range workers {
+			if err := w.Start(); err != nil {
+				log.Fatal().Err(err).Msg("Unable to start worker")
+			}
+		}
+
+		if httpServer != nil {
+			httpServer.SetRunning(true)
+		}
+
+		sig := <-worker.InterruptCh()
+
+		// Stop receiving traffic before draining
+		if httpServer != nil {
+			httpServer.SetRunning(false)
+		}
+
+		drain(workers, sig)
+
+		if httpServer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+
+			if err := httpServer.Stop(ctx); err != nil {
+				log.Error().Err(err).Msg("Error stopping HTTP server")
+			}
 		}
 	},
 }
 
+// Set if tracing is enabled
+var tracing *telemetry.Tracing
+
+// Create a Temporal client from the global flags. If tracing is enabled, the
+// spans are propagated to the workflows.
+func newClient(opts ...temporal.Option) (client.Client, error) {
+	if tracing != nil {
+		opts = append(opts, temporal.WithInterceptors(tracing.Interceptor))
+	}
+
+	if rootOpts.EncryptionKeyFile != "" {
+		c, err := loadCodec()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, temporal.WithDataConverter(codec.NewDataConverter(c)))
+	}
+
+	// Setting any of the certificates implies TLS
+	tlsOpts := rootOpts.TLS.TLSOptions
+	if rootOpts.TLS.Enabled || tlsOpts != (temporal.TLSOptions{}) {
+		tlsConfig, err := temporal.NewTLSConfig(tlsOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error creating tls config: %w", err)
+		}
+		opts = append(opts, temporal.WithTLS(tlsConfig))
+	}
+
+	return temporal.NewClient(rootOpts.Host, rootOpts.Namespace, rootOpts.APIKey, opts...)
+}
+
+func loadCodec() (*codec.Codec, error) {
+	keyring, err := codec.LoadKeyring(rootOpts.EncryptionKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading encryption keys: %w", err)
+	}
+	return codec.New(keyring)
+}
+
+// Parse the rate limits keyed by provider/region
+func parseRateLimitOverrides(overrides map[string]string) map[string]float64 {
+	parsed := map[string]float64{}
+	for key, val := range overrides {
+		perSecond, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			log.Fatal().Err(err).Str("key", key).Msg("Invalid rate limit override")
+		}
+		parsed[key] = perSecond
+	}
+	return parsed
+}
+
+func workerOptions(sink audit.Sink) worker.Options {
+	return worker.Options{
+		Interceptors: []interceptor.WorkerInterceptor{
+			&workflow.AuditInterceptor{Sink: sink},
+		},
+		MaxConcurrentActivityExecutionSize:     rootOpts.Worker.MaxConcurrentActivities,
+		MaxConcurrentWorkflowTaskExecutionSize: rootOpts.Worker.MaxConcurrentWorkflowTasks,
+		MaxConcurrentActivityTaskPollers:       rootOpts.Worker.ActivityPollers,
+		MaxConcurrentWorkflowTaskPollers:       rootOpts.Worker.WorkflowPollers,
+		WorkerStopTimeout:                      rootOpts.Worker.StopTimeout,
+	}
+}
+
+// Stop polling for new tasks and wait for the in-flight activities to finish,
+// up to the stop timeout. The workers are stopped in parallel so the timeout
+// applies to the whole process.
+func drain(workers []worker.Worker, sig any) {
+	log.Info().Interface("signal", sig).Dur("timeout", rootOpts.Worker.StopTimeout).Msg("Draining workers")
+
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Stop()
+		}()
+	}
+	wg.Wait()
+
+	log.Info().Msg("Workers drained")
+}
+
+// Register the workflows and any activities that don't talk to a provider
+func registerWorkflows(w worker.Worker, c client.Client) {
+	w.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
+	w.RegisterWorkflow(workflow.CloudProvisionWorkflow)
+	w.RegisterWorkflow(workflow.PlanWorkflow)
+	w.RegisterWorkflow(workflow.QuotaManagerWorkflow)
+	w.RegisterWorkflow(workflow.CIDRManagerWorkflow)
+	w.RegisterWorkflow(workflow.TeardownWorkflow)
+	w.RegisterWorkflow(workflow.ReplaceNodeWorkflow)
+	w.RegisterWorkflow(workflow.NotificationWorkflow)
+
+	w.RegisterActivity(&workflow.QuotaActivities{
+		Client:    c,
+		Limits:    rootOpts.Quota,
+		TaskQueue: rootOpts.TaskQueue,
+	})
+	w.RegisterActivity(&workflow.PolicyActivities{
+		Policy: rootOpts.Policy,
+	})
+	w.RegisterActivity(&workflow.ResourceActivities{
+		Client: c,
+	})
+
+	store := newInventoryStore()
+	w.RegisterActivity(&workflow.InventoryActivities{
+		Store: store,
+	})
+	w.RegisterActivity(&workflow.NetworkActivities{
+		Client:    c,
+		Store:     store,
+		TaskQueue: rootOpts.TaskQueue,
+	})
+
+	notifier, err := newNotifier()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create notifier")
+	}
+	w.RegisterActivity(&workflow.NotificationActivities{
+		Notifier: notifier,
+	})
+
+	dnsProvider, err := newDNSProvider()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create dns provider")
+	}
+	w.RegisterActivity(&workflow.DNSActivities{
+		Provider: dnsProvider,
+	})
+}
+
+// Register the activities which talk to the provider - the worker must have
+// the credentials for the provider
+func registerProviderActivities(w worker.Worker) {
+	w.RegisterActivity(workflow.CreateProjectActivity)
+	w.RegisterActivity(workflow.PlanProjectActivity)
+	w.RegisterActivity(workflow.SetupNetworkActivity)
+	w.RegisterActivity(workflow.CreateSecurityGroupActivity)
+	w.RegisterActivity(workflow.ProvisionNodeActivity)
+	w.RegisterActivity(workflow.AwaitForNodeRunningActivity)
+	w.RegisterActivity(workflow.CreateLoadBalancerActivity)
+	w.RegisterActivity(workflow.SetLoadBalancerTargetsActivity)
+	w.RegisterActivity(workflow.AwaitForTargetHealthyActivity)
+	w.RegisterActivity(workflow.DeleteLoadBalancerActivity)
+	w.RegisterActivity(workflow.DeleteNodeActivity)
+	w.RegisterActivity(workflow.DeleteSecurityGroupActivity)
+	w.RegisterActivity(workflow.DeleteNetworkActivity)
+	w.RegisterActivity(workflow.DeleteProjectActivity)
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -87,6 +426,22 @@ func bindEnv(key string, defaultValue ...any) {
 }
 
 func init() {
+	bindEnv("temporal-config", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.ConfigFile,
+		"config",
+		viper.GetString("temporal-config"),
+		"Config file of connection profiles - defaults to <user config dir>/temporal-provisioner/config.yaml",
+	)
+
+	bindEnv("temporal-profile", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Profile,
+		"profile",
+		viper.GetString("temporal-profile"),
+		"Connection profile to use - defaults to the config file's currentProfile",
+	)
+
 	bindEnv("temporal-address", client.DefaultHostPort)
 	rootCmd.PersistentFlags().StringVarP(&rootOpts.Host, "temporal-address", "a", viper.GetString("temporal-address"), "Address for Temporal server")
 
@@ -102,4 +457,342 @@ func init() {
 		viper.GetString("temporal-namespace"),
 		"Namespace for Temporal server",
 	)
+
+	bindEnv("task-queue", "cloud-provisioning")
+	rootCmd.PersistentFlags().StringVarP(
+		&rootOpts.TaskQueue,
+		"task-queue",
+		"q",
+		viper.GetString("task-queue"),
+		"Task queue for the workflows - provider activities use <task-queue>-<provider>-<region>",
+	)
+
+	bindEnv("encryption-key-file", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.EncryptionKeyFile,
+		"encryption-key-file",
+		viper.GetString("encryption-key-file"),
+		"YAML file of AES keys to encrypt the workflow payloads - disabled if empty",
+	)
+
+	bindEnv("inventory-file", inventory.DefaultFile())
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.InventoryFile,
+		"inventory-file",
+		viper.GetString("inventory-file"),
+		"BoltDB file recording the resources that have been created - disabled if empty",
+	)
+
+	bindEnv("audit-sink", auditSinkInventory)
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Audit.Sink,
+		"audit-sink",
+		viper.GetString("audit-sink"),
+		fmt.Sprintf(
+			"Where to send the audit events - %s, %s, %s or %s",
+			auditSinkNone, auditSinkFile, auditSinkWebhook, auditSinkInventory,
+		),
+	)
+
+	bindEnv("audit-file", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Audit.File,
+		"audit-file",
+		viper.GetString("audit-file"),
+		"File to append the audit events to as JSON lines",
+	)
+
+	bindEnv("audit-webhook-url", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Audit.WebhookURL,
+		"audit-webhook-url",
+		viper.GetString("audit-webhook-url"),
+		"URL to POST the audit events to",
+	)
+
+	bindEnv("notify-webhook-url", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Notify.WebhookURL,
+		"notify-webhook-url",
+		viper.GetString("notify-webhook-url"),
+		"URL to POST the notifications to as JSON",
+	)
+
+	bindEnv("notify-webhook-secret", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Notify.WebhookSecret,
+		"notify-webhook-secret",
+		viper.GetString("notify-webhook-secret"),
+		"Secret to sign the webhook notifications with HMAC-SHA256 - unsigned if empty",
+	)
+
+	bindEnv("notify-slack-url", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Notify.SlackURL,
+		"notify-slack-url",
+		viper.GetString("notify-slack-url"),
+		"Slack-compatible incoming webhook URL to send the notifications to",
+	)
+
+	bindEnv("notify-templates", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Notify.TemplatesFile,
+		"notify-templates",
+		viper.GetString("notify-templates"),
+		"YAML file of Go templates for the notification messages, keyed by event type",
+	)
+
+	bindEnv("dns-server", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.DNS.Server,
+		"dns-server",
+		viper.GetString("dns-server"),
+		"Address of the DNS server to send RFC 2136 dynamic updates to, eg ns1.example.com:53",
+	)
+
+	bindEnv("dns-tsig-key", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.DNS.TSIGKey,
+		"dns-tsig-key",
+		viper.GetString("dns-tsig-key"),
+		"Name of the TSIG key to sign the DNS updates with - unsigned if empty",
+	)
+
+	bindEnv("dns-tsig-secret", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.DNS.TSIGSecret,
+		"dns-tsig-secret",
+		viper.GetString("dns-tsig-secret"),
+		"Base64 encoded secret of the TSIG key",
+	)
+
+	bindEnv("dns-tsig-algorithm", "hmac-sha256")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.DNS.TSIGAlgorithm,
+		"dns-tsig-algorithm",
+		viper.GetString("dns-tsig-algorithm"),
+		fmt.Sprintf("Algorithm of the TSIG key - one of %s", strings.Join(dns.TSIGAlgorithms, ", ")),
+	)
+
+	bindEnv("tls", false)
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.TLS.Enabled,
+		"tls",
+		viper.GetBool("tls"),
+		"Connect to Temporal with TLS - implied by an API key or any other TLS flag",
+	)
+
+	bindEnv("tls-cert", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.TLS.CertFile,
+		"tls-cert",
+		viper.GetString("tls-cert"),
+		"Client certificate for mutual TLS - reloaded when changed",
+	)
+
+	bindEnv("tls-key", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.TLS.KeyFile,
+		"tls-key",
+		viper.GetString("tls-key"),
+		"Client private key for mutual TLS - reloaded when changed",
+	)
+
+	bindEnv("tls-ca", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.TLS.CAFile,
+		"tls-ca",
+		viper.GetString("tls-ca"),
+		"CA bundle to verify the Temporal server - uses the system roots if empty",
+	)
+
+	bindEnv("tls-server-name", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.TLS.ServerName,
+		"tls-server-name",
+		viper.GetString("tls-server-name"),
+		"Server name to verify the Temporal server certificate against",
+	)
+
+	bindEnv("tracing-exporter", string(telemetry.TracingExporterNone))
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Tracing.Exporter,
+		"tracing-exporter",
+		viper.GetString("tracing-exporter"),
+		fmt.Sprintf(
+			"Where to send the traces - %s, %s or %s",
+			telemetry.TracingExporterNone, telemetry.TracingExporterOTLP, telemetry.TracingExporterStdout,
+		),
+	)
+
+	bindEnv("otlp-endpoint", "")
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.Tracing.Endpoint,
+		"otlp-endpoint",
+		viper.GetString("otlp-endpoint"),
+		"Insecure OTLP gRPC endpoint for the traces, eg localhost:4317 - uses OTEL_EXPORTER_OTLP_* if empty",
+	)
+
+	bindEnv("workflows", true)
+	rootCmd.Flags().BoolVar(&rootOpts.Workflows, "workflows", viper.GetBool("workflows"), "Serve the workflows")
+
+	bindEnv("providers", []string{fmt.Sprintf("%s/eu-west-2", providers.CloudProviderAWS)})
+	rootCmd.Flags().StringSliceVar(
+		&rootOpts.Providers,
+		"providers",
+		viper.GetStringSlice("providers"),
+		"Providers and regions to serve activities for, eg aws/eu-west-2",
+	)
+
+	bindEnv("max-concurrent-activities", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Worker.MaxConcurrentActivities,
+		"max-concurrent-activities",
+		viper.GetInt("max-concurrent-activities"),
+		"Maximum activities executed at once by each worker - 0 uses the SDK default",
+	)
+
+	bindEnv("max-concurrent-workflow-tasks", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Worker.MaxConcurrentWorkflowTasks,
+		"max-concurrent-workflow-tasks",
+		viper.GetInt("max-concurrent-workflow-tasks"),
+		"Maximum workflow tasks executed at once by each worker - 0 uses the SDK default",
+	)
+
+	bindEnv("activity-pollers", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Worker.ActivityPollers,
+		"activity-pollers",
+		viper.GetInt("activity-pollers"),
+		"Number of activity task pollers for each worker - 0 uses the SDK default",
+	)
+
+	bindEnv("workflow-pollers", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Worker.WorkflowPollers,
+		"workflow-pollers",
+		viper.GetInt("workflow-pollers"),
+		"Number of workflow task pollers for each worker - 0 uses the SDK default",
+	)
+
+	bindEnv("sticky-cache-size", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Worker.StickyCacheSize,
+		"sticky-cache-size",
+		viper.GetInt("sticky-cache-size"),
+		"Number of workflows cached by the process - 0 uses the SDK default",
+	)
+
+	bindEnv("stop-timeout", time.Minute)
+	rootCmd.Flags().DurationVar(
+		&rootOpts.Worker.StopTimeout,
+		"stop-timeout",
+		viper.GetDuration("stop-timeout"),
+		"How long to wait for in-flight activities to finish when stopping",
+	)
+
+	bindEnv("http-addr", "")
+	rootCmd.Flags().StringVar(
+		&rootOpts.HTTPAddr,
+		"http-addr",
+		viper.GetString("http-addr"),
+		"Address to serve /healthz, /readyz and /metrics on, eg :8080 - disabled if empty",
+	)
+
+	bindEnv("pricing-file", "")
+	rootCmd.Flags().StringVar(
+		&rootOpts.PricingFile,
+		"pricing-file",
+		viper.GetString("pricing-file"),
+		"YAML pricing catalogue used for cost estimates - uses built-in prices if empty",
+	)
+
+	bindEnv("approval-max-nodes", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Policy.Approval.MaxNodes,
+		"approval-max-nodes",
+		viper.GetInt("approval-max-nodes"),
+		"Require approval when more than this number of VMs is requested - 0 disables",
+	)
+
+	bindEnv("approval-instance-types", []string{})
+	rootCmd.Flags().StringSliceVar(
+		&rootOpts.Policy.Approval.InstanceTypes,
+		"approval-instance-types",
+		viper.GetStringSlice("approval-instance-types"),
+		"Instance types which require approval",
+	)
+
+	bindEnv("approval-timeout", time.Hour*24)
+	rootCmd.Flags().DurationVar(
+		&rootOpts.Policy.Approval.Timeout,
+		"approval-timeout",
+		viper.GetDuration("approval-timeout"),
+		"How long to wait for approval - 0 waits forever",
+	)
+
+	bindEnv("max-monthly-cost", 0)
+	rootCmd.Flags().Float64Var(
+		&rootOpts.Policy.MaxMonthlyCost,
+		"max-monthly-cost",
+		viper.GetFloat64("max-monthly-cost"),
+		"Fail before creating resources if the estimated monthly cost exceeds this - 0 disables",
+	)
+
+	bindEnv("quota-nodes", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Quota.Nodes,
+		"quota-nodes",
+		viper.GetInt("quota-nodes"),
+		"Maximum VMs per provider/region - 0 is unlimited",
+	)
+
+	bindEnv("quota-networks", 0)
+	rootCmd.Flags().IntVar(
+		&rootOpts.Quota.Networks,
+		"quota-networks",
+		viper.GetInt("quota-networks"),
+		"Maximum networks per provider/region - 0 is unlimited",
+	)
+
+	bindEnv("rate-limit", 0)
+	rootCmd.Flags().Float64Var(
+		&rootOpts.RateLimit.PerSecond,
+		"rate-limit",
+		viper.GetFloat64("rate-limit"),
+		"Provider API calls per second for each provider/region on this worker only - 0 is unlimited",
+	)
+
+	bindEnv("rate-limit-burst", 1)
+	rootCmd.Flags().IntVar(
+		&rootOpts.RateLimit.Burst,
+		"rate-limit-burst",
+		viper.GetInt("rate-limit-burst"),
+		"Provider API calls allowed in a burst",
+	)
+
+	bindEnv("rate-limit-overrides", map[string]string{})
+	rootCmd.Flags().StringToStringVar(
+		&rootOpts.RateLimit.Overrides,
+		"rate-limit-overrides",
+		viper.GetStringMapString("rate-limit-overrides"),
+		"Rate limit for specific providers/regions, eg aws/eu-west-2=5",
+	)
+
+	bindEnv("task-queue-activities-per-second", 0)
+	rootCmd.Flags().Float64Var(
+		&rootOpts.RateLimit.TaskQueueActivitiesPerSecond,
+		"task-queue-activities-per-second",
+		viper.GetFloat64("task-queue-activities-per-second"),
+		"Provider activities per second for each provider/region across all workers - 0 is unlimited",
+	)
+
+	bindEnv("task-queue-activities-per-second-overrides", map[string]string{})
+	rootCmd.Flags().StringToStringVar(
+		&rootOpts.RateLimit.TaskQueueOverrides,
+		"task-queue-activities-per-second-overrides",
+		viper.GetStringMapString("task-queue-activities-per-second-overrides"),
+		"Activities per second across all workers for specific providers/regions, eg aws/eu-west-2=5",
+	)
 }
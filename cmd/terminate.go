@@ -0,0 +1,95 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"go.temporal.io/sdk/client"
+)
+
+var terminateOpts struct {
+	Reason  string
+	Cleanup bool
+}
+
+// terminateCmd represents the terminate command
+var terminateCmd = &cobra.Command{
+	Use:   "terminate <workflow-id>",
+	Short: "Hard-stop a provisioning workflow and start a teardown of its resources",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		workflowID := args[0]
+		ctx := context.Background()
+
+		c, err := newClient()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to create Temporal client")
+		}
+		defer c.Close()
+
+		// Get the run so the teardown targets the run that was terminated
+		desc, err := c.DescribeWorkflowExecution(ctx, workflowID, "")
+		if err != nil {
+			log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to find workflow")
+		}
+		runID := desc.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+
+		reason := terminateOpts.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("terminated by %s", currentIdentity())
+		}
+
+		if err := c.TerminateWorkflow(ctx, workflowID, runID, reason); err != nil {
+			log.Fatal().Err(err).Str("WorkflowID", workflowID).Msg("Unable to terminate workflow")
+		}
+
+		log.Info().Str("WorkflowID", workflowID).Str("RunID", runID).Str("reason", reason).Msg("Terminated workflow")
+
+		if !terminateOpts.Cleanup {
+			log.Warn().Msg("Cleanup disabled - any resources created by the workflow have been left behind")
+			return
+		}
+
+		we, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+			ID:        workflow.TeardownWorkflowID(workflowID),
+			TaskQueue: rootOpts.TaskQueue,
+			Memo: map[string]any{
+				workflow.TriggeredByMemo: currentIdentity(),
+			},
+		}, workflow.TeardownWorkflow, workflow.TeardownRequest{
+			WorkflowID: workflowID,
+			RunID:      runID,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to start teardown workflow")
+		}
+
+		log.Info().Str("WorkflowID", we.GetID()).Str("RunID", we.GetRunID()).Msg("Started teardown workflow")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(terminateCmd)
+
+	terminateCmd.Flags().StringVar(&terminateOpts.Reason, "reason", "", "Why the workflow is being terminated - defaults to who terminated it")
+	terminateCmd.Flags().BoolVar(&terminateOpts.Cleanup, "cleanup", true, "Start a teardown workflow to delete the recorded resources")
+}
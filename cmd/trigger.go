@@ -18,9 +18,15 @@ package cmd
 
 import (
 	"context"
-
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/goombaio/namegenerator"
+	"github.com/mrsimonemms/temporal/pkg/dns"
 	"github.com/mrsimonemms/temporal/pkg/providers"
-	"github.com/mrsimonemms/temporal/pkg/temporal"
 	"github.com/mrsimonemms/temporal/pkg/workflow"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -30,24 +36,66 @@ import (
 
 var triggerOpts providers.CloudConfig
 
-var triggerProvider string
+var triggerPlanFile string
+
+// Spec file used by the command's CloudConfig
+var cloudConfigSpecFile string
+
+var triggerRunOpts struct {
+	Wait   bool
+	Follow bool
+}
+
+// startedWorkflow is printed when trigger doesn't wait for the result
+type startedWorkflow struct {
+	WorkflowID string
+	RunID      string
+}
+
+func printStartedWorkflowTable(w io.Writer, started *startedWorkflow) error {
+	fmt.Fprintf(w, "WORKFLOW ID\t%s\n", started.WorkflowID)
+	fmt.Fprintf(w, "RUN ID\t%s\n", started.RunID)
+	return nil
+}
 
 // triggerCmd represents the trigger command
 var triggerCmd = &cobra.Command{
 	Use:   "trigger",
 	Short: "Run the Temporal workflow",
 	Run: func(cmd *cobra.Command, args []string) {
-		c, err := temporal.NewClient(rootOpts.Host, rootOpts.Namespace, rootOpts.APIKey)
+		c, err := newClient()
 		if err != nil {
 			log.Fatal().Err(err).Msg("Unable to create Temporal client")
 		}
 		defer c.Close()
 
-		workflowOptions := client.StartWorkflowOptions{
-			TaskQueue: "cloud-provisioning",
+		if triggerPlanFile != "" {
+			cfg, err := loadPlan(triggerPlanFile)
+			if err != nil {
+				log.Fatal().Err(err).Str("file", triggerPlanFile).Msg("Unable to load plan")
+			}
+			triggerOpts = *cfg
+		} else {
+			applySpec(&triggerOpts)
 		}
 
-		triggerOpts.Provider = providers.CloudProvider(triggerProvider)
+		// The name is needed up front as the workflow ID is derived from it
+		if triggerOpts.Plan != nil {
+			triggerOpts.Name = triggerOpts.Plan.Project.Name
+		} else if triggerOpts.Name == "" {
+			triggerOpts.Name = namegenerator.NewNameGenerator(time.Now().UTC().UnixNano()).Generate()
+		}
+
+		workflowOptions := client.StartWorkflowOptions{
+			ID:                    workflow.ProvisionWorkflowID(triggerOpts.Name),
+			TaskQueue:             rootOpts.TaskQueue,
+			TypedSearchAttributes: workflow.SearchAttributes(triggerOpts),
+			Memo: map[string]any{
+				"owner":                  triggerOpts.Owner,
+				"environment":            triggerOpts.Environment,
+				workflow.TriggeredByMemo: currentIdentity(),
+			},
+		}
 
 		we, err := c.ExecuteWorkflow(context.Background(), workflowOptions, workflow.CloudProvisionWorkflow, triggerOpts)
 		if err != nil {
@@ -56,28 +104,138 @@ var triggerCmd = &cobra.Command{
 
 		log.Info().Str("WorkflowID", we.GetID()).Str("RunID", we.GetRunID()).Msg("Started workflow")
 
-		// Synchronously wait for the workflow completion.
-		var result providers.ProjectResult
-		err = we.Get(context.Background(), &result)
+		if !triggerRunOpts.Wait && !triggerRunOpts.Follow {
+			log.Info().Str("WorkflowID", we.GetID()).Msgf("Run \"result %s --wait\" to get the result", we.GetID())
+
+			if err := printResult(&startedWorkflow{WorkflowID: we.GetID(), RunID: we.GetRunID()}, printStartedWorkflowTable); err != nil {
+				log.Fatal().Err(err).Msg("Unable to print workflow")
+			}
+			return
+		}
+
+		result, err := waitForProject(context.Background(), c, we.GetID(), we.GetRunID(), triggerRunOpts.Follow)
 		if err != nil {
-			log.Fatal().Err(err).Msg("Unable to get workflow result")
+			log.Fatal().Err(err).Str("WorkflowID", we.GetID()).Msg("Workflow did not complete successfully")
+		}
+
+		if err := printResult(result, printProjectTable); err != nil {
+			log.Fatal().Err(err).Msg("Unable to print result")
 		}
-		log.Info().Interface("result", result).Msg("Workflow finished")
 	},
 }
 
-func init() {
-	rootCmd.AddCommand(triggerCmd)
+// Add the flags which choose the network's range
+func addNetworkFlags(cmd *cobra.Command, cfg *providers.CloudConfig) {
+	bindEnv("subnet", "10.0.0.0/24")
+	cmd.Flags().StringVar(&cfg.Subnet, "subnet", viper.GetString("subnet"), "CIDR of the network, which the subnets are carved from")
+
+	bindEnv("auto-cidr", false)
+	cmd.Flags().BoolVar(
+		&cfg.AutoCIDR,
+		"auto-cidr",
+		viper.GetBool("auto-cidr"),
+		"Pick a free range the size of --subnet from --supernet, avoiding the networks in the inventory",
+	)
+
+	bindEnv("supernet", "10.0.0.0/8")
+	cmd.Flags().StringVar(&cfg.Supernet, "supernet", viper.GetString("supernet"), "Range that --auto-cidr picks the network from")
+}
+
+func addDNSFlags(cmd *cobra.Command, cfg *providers.CloudConfig) {
+	bindEnv("dns-zone", "")
+	cmd.Flags().StringVar(
+		&cfg.DNSZone,
+		"dns-zone",
+		viper.GetString("dns-zone"),
+		"Create <node>.<project>.<zone> records for the nodes in this zone - disabled if empty",
+	)
+
+	bindEnv("dns-ttl", dns.DefaultTTL)
+	cmd.Flags().DurationVar(&cfg.DNSTTL, "dns-ttl", viper.GetDuration("dns-ttl"), "TTL of the DNS records")
+}
+
+// Add the flags used to build a CloudConfig to a command
+func addCloudConfigFlags(cmd *cobra.Command, cfg *providers.CloudConfig) {
+	bindEnv("name", "")
+	cmd.Flags().StringVar(&cfg.Name, "name", viper.GetString("name"), "Name of the project - generated if empty")
 
 	bindEnv("count", 3)
-	triggerCmd.Flags().IntVar(&triggerOpts.VMCount, "count", viper.GetInt("count"), "Number of VMs to build")
+	cmd.Flags().IntVar(&cfg.VMCount, "count", viper.GetInt("count"), "Number of VMs to build")
 
 	bindEnv("region", "eu-west-2")
-	triggerCmd.Flags().StringVar(&triggerOpts.Region, "region", viper.GetString("region"), "Region in which to build the resources")
+	cmd.Flags().StringVar(&cfg.Region, "region", viper.GetString("region"), "Region in which to build the resources")
 
-	bindEnv("subnet", "10.0.0.0/24")
-	triggerCmd.Flags().StringVar(&triggerOpts.Subnet, "subnet", viper.GetString("subnet"), "Subnet to use for the network")
+	addNetworkFlags(cmd, cfg)
+	addDNSFlags(cmd, cfg)
 
 	bindEnv("provider", string(providers.CloudProviderAWS))
-	triggerCmd.Flags().StringVar(&triggerProvider, "provider", viper.GetString("provider"), "Cloud provider to use")
+	cmd.Flags().StringVar((*string)(&cfg.Provider), "provider", viper.GetString("provider"), "Cloud provider to use")
+
+	bindEnv("instance-type", "t3.medium")
+	cmd.Flags().StringVar(&cfg.InstanceType, "instance-type", viper.GetString("instance-type"), "Instance type of the VMs")
+
+	bindEnv("quota-policy", string(providers.QuotaPolicyQueue))
+	cmd.Flags().StringVar(
+		(*string)(&cfg.QuotaPolicy),
+		"quota-policy",
+		viper.GetString("quota-policy"),
+		fmt.Sprintf("What to do when there is not enough quota - %s or %s", providers.QuotaPolicyQueue, providers.QuotaPolicyFailFast),
+	)
+
+	defaultOwner := ""
+	if u, err := user.Current(); err == nil {
+		defaultOwner = u.Username
+	}
+
+	bindEnv("owner", defaultOwner)
+	cmd.Flags().StringVar(&cfg.Owner, "owner", viper.GetString("owner"), "Owner of the project")
+
+	bindEnv("environment", "")
+	cmd.Flags().StringVar(&cfg.Environment, "environment", viper.GetString("environment"), "Environment of the project, eg dev or prod")
+
+	bindEnv("spec", "")
+	cmd.Flags().StringVar(
+		&cloudConfigSpecFile,
+		"spec",
+		viper.GetString("spec"),
+		"YAML file describing the node pools - replaces --count and --instance-type",
+	)
+
+}
+
+// Apply the spec file, if set, to the config
+func applySpec(cfg *providers.CloudConfig) {
+	if cloudConfigSpecFile == "" {
+		return
+	}
+
+	spec, err := providers.LoadSpec(cloudConfigSpecFile)
+	if err != nil {
+		log.Fatal().Err(err).Str("file", cloudConfigSpecFile).Msg("Unable to load spec")
+	}
+	spec.Apply(cfg)
+}
+
+// Who is running the command, eg alice@laptop. This is taken from the local
+// machine, so it is self-asserted rather than authenticated.
+func currentIdentity() string {
+	identity := "unknown"
+	if u, err := user.Current(); err == nil {
+		identity = u.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		identity = fmt.Sprintf("%s@%s", identity, hostname)
+	}
+	return identity
+}
+
+func init() {
+	rootCmd.AddCommand(triggerCmd)
+
+	addCloudConfigFlags(triggerCmd, &triggerOpts)
+
+	triggerCmd.Flags().StringVar(&triggerPlanFile, "plan", "", "Apply a plan saved by the plan command - the worker checks it still matches its config")
+	triggerCmd.Flags().BoolVar(&triggerRunOpts.Wait, "wait", false, "Wait for the workflow to finish and print the result")
+	triggerCmd.Flags().BoolVar(&triggerRunOpts.Follow, "follow", false, "Log each status change while waiting - implies --wait")
+	addOutputFlags(triggerCmd)
 }
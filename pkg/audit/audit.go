@@ -0,0 +1,145 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+)
+
+// Operation is a change made in a cloud provider
+type Operation string
+
+const (
+	OperationCreateProject       Operation = "create-project"
+	OperationCreateNetwork       Operation = "create-network"
+	OperationCreateSecurityGroup Operation = "create-security-group"
+	OperationCreateNode          Operation = "create-node"
+	OperationCreateLoadBalancer  Operation = "create-load-balancer"
+	OperationUpdateLoadBalancer  Operation = "update-load-balancer"
+	OperationDeleteProject       Operation = "delete-project"
+	OperationDeleteNetwork       Operation = "delete-network"
+	OperationDeleteSecurityGroup Operation = "delete-security-group"
+	OperationDeleteNode          Operation = "delete-node"
+	OperationDeleteLoadBalancer  Operation = "delete-load-balancer"
+	OperationUpsertDNSRecord     Operation = "upsert-dns-record"
+	OperationDeleteDNSRecord     Operation = "delete-dns-record"
+)
+
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+)
+
+// Event records who changed what, and when
+type Event struct {
+	// Unique to the attempt at the change so duplicate deliveries can be ignored
+	ID   string
+	Time time.Time
+	// Who started the workflow. This is set by the client that started it and
+	// isn't authenticated, so it's who the client says they are.
+	Actor      string
+	Operation  Operation
+	ResourceID string `json:",omitempty" yaml:",omitempty"`
+	// Used if the resource wasn't created so has no ID
+	ResourceName string `json:",omitempty" yaml:",omitempty"`
+	ProjectID    string `json:",omitempty" yaml:",omitempty"`
+	Provider     providers.CloudProvider
+	Region       string
+	Result       Result
+	Error        string `json:",omitempty" yaml:",omitempty"`
+	WorkflowID   string
+	RunID        string
+	// The activity attempt that made the change. Every attempt is recorded.
+	Attempt int32 `json:",omitempty" yaml:",omitempty"`
+}
+
+// EventID is derived from the workflow run, operation, resource and attempt so
+// the same attempt at a change always has the same ID
+func EventID(workflowID, runID string, operation Operation, resource string, attempt int32) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, fmt.Appendf(nil, "%s/%s/%s/%s/%d", workflowID, runID, operation, resource, attempt)).String()
+}
+
+// Sink receives the events. Events are delivered at least once, so a sink
+// may receive the same event more than once.
+type Sink interface {
+	Write(ctx context.Context, event *Event) error
+}
+
+// Searcher is implemented by the sinks which can be queried
+type Searcher interface {
+	// Search returns the matching events, oldest first, without duplicates
+	Search(ctx context.Context, filter Filter) ([]*Event, error)
+}
+
+// Filter limits the events that are returned. Empty fields match everything.
+type Filter struct {
+	Actor      string
+	Operation  Operation
+	ResourceID string
+	ProjectID  string
+	WorkflowID string
+	Result     Result
+	Since      time.Time
+	Until      time.Time
+	// Return the most recent events. Zero returns all of them.
+	Limit int
+}
+
+// Match reports whether the event passes the filter. The limit is applied by
+// the searcher.
+func (f Filter) Match(e *Event) bool {
+	checks := []struct {
+		Want string
+		Got  string
+	}{
+		{Want: f.Actor, Got: e.Actor},
+		{Want: string(f.Operation), Got: string(e.Operation)},
+		{Want: f.ResourceID, Got: e.ResourceID},
+		{Want: f.ProjectID, Got: e.ProjectID},
+		{Want: f.WorkflowID, Got: e.WorkflowID},
+		{Want: string(f.Result), Got: string(e.Result)},
+	}
+	for _, check := range checks {
+		if check.Want != "" && check.Want != check.Got {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Apply the limit, keeping the most recent events. The events must be oldest
+// first.
+func (f Filter) Apply(events []*Event) []*Event {
+	if f.Limit > 0 && len(events) > f.Limit {
+		return events[len(events)-f.Limit:]
+	}
+	return events
+}
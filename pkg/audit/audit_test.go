@@ -0,0 +1,161 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newEvent(operation audit.Operation, resourceID, actor string, offset time.Duration) *audit.Event {
+	return &audit.Event{
+		ID:         audit.EventID("workflow-id", "run-id", operation, resourceID, 1),
+		Time:       now.Add(offset),
+		Actor:      actor,
+		Operation:  operation,
+		ResourceID: resourceID,
+		Result:     audit.ResultSuccess,
+		WorkflowID: "workflow-id",
+		RunID:      "run-id",
+	}
+}
+
+func Test_EventID(t *testing.T) {
+	id := audit.EventID("workflow-id", "run-id", audit.OperationCreateNode, "node-1", 1)
+
+	assert.Equal(t, id, audit.EventID("workflow-id", "run-id", audit.OperationCreateNode, "node-1", 1))
+	assert.NotEqual(t, id, audit.EventID("workflow-id", "run-id", audit.OperationDeleteNode, "node-1", 1))
+	assert.NotEqual(t, id, audit.EventID("workflow-id", "other-run-id", audit.OperationCreateNode, "node-1", 1))
+	// Each attempt is a separate event
+	assert.NotEqual(t, id, audit.EventID("workflow-id", "run-id", audit.OperationCreateNode, "node-1", 2))
+}
+
+func Test_FileSink(t *testing.T) {
+	ctx := context.Background()
+	sink := audit.NewFileSink(filepath.Join(t.TempDir(), "audit", "audit.jsonl"))
+
+	events, err := sink.Search(ctx, audit.Filter{})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	create := newEvent(audit.OperationCreateNode, "node-1", "alice", 0)
+	remove := newEvent(audit.OperationDeleteNode, "node-1", "bob", time.Hour)
+	other := newEvent(audit.OperationCreateNode, "node-2", "alice", time.Minute)
+
+	// The create is delivered twice, as happens when an activity is retried
+	for _, e := range []*audit.Event{create, create, remove, other} {
+		assert.NoError(t, sink.Write(ctx, e))
+	}
+
+	tests := []struct {
+		Name     string
+		Filter   audit.Filter
+		Expected []*audit.Event
+	}{
+		{
+			Name:     "all",
+			Expected: []*audit.Event{create, other, remove},
+		},
+		{
+			Name:     "by actor",
+			Filter:   audit.Filter{Actor: "alice"},
+			Expected: []*audit.Event{create, other},
+		},
+		{
+			Name:     "by resource",
+			Filter:   audit.Filter{ResourceID: "node-1"},
+			Expected: []*audit.Event{create, remove},
+		},
+		{
+			Name:     "by operation",
+			Filter:   audit.Filter{Operation: audit.OperationDeleteNode},
+			Expected: []*audit.Event{remove},
+		},
+		{
+			Name:     "by time",
+			Filter:   audit.Filter{Since: now.Add(time.Second), Until: now.Add(time.Minute * 2)},
+			Expected: []*audit.Event{other},
+		},
+		{
+			Name:     "limit",
+			Filter:   audit.Filter{Limit: 2},
+			Expected: []*audit.Event{other, remove},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			events, err := sink.Search(ctx, test.Filter)
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, events)
+		})
+	}
+}
+
+func Test_WebhookSink(t *testing.T) {
+	event := newEvent(audit.OperationCreateProject, "project-1", "alice", 0)
+
+	tests := []struct {
+		Name   string
+		Status int
+		Err    bool
+	}{
+		{
+			Name:   "accepted",
+			Status: http.StatusAccepted,
+		},
+		{
+			Name:   "error",
+			Status: http.StatusInternalServerError,
+			Err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var received audit.Event
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, event.ID, r.Header.Get("Idempotency-Key"))
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+				w.WriteHeader(test.Status)
+			}))
+			defer srv.Close()
+
+			err := audit.NewWebhookSink(srv.URL).Write(context.Background(), event)
+			if test.Err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, *event, received)
+		})
+	}
+}
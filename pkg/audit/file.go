@@ -0,0 +1,115 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// FileSink appends the events to a file as JSON lines
+type FileSink struct {
+	File string
+
+	mu sync.Mutex
+}
+
+func NewFileSink(file string) *FileSink {
+	return &FileSink{
+		File: file,
+	}
+}
+
+func (f *FileSink) Write(_ context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding audit event: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.File), 0o700); err != nil {
+		return fmt.Errorf("error creating audit directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening audit file: %w", err)
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error writing audit event: %w", err)
+	}
+
+	return file.Close()
+}
+
+func (f *FileSink) Search(_ context.Context, filter Filter) ([]*Event, error) {
+	file, err := os.Open(f.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Event{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	events := make([]*Event, 0)
+	seen := map[string]struct{}{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("error decoding audit event: %w", err)
+		}
+
+		// Events are delivered at least once
+		if _, ok := seen[event.ID]; ok {
+			continue
+		}
+		seen[event.ID] = struct{}{}
+
+		if filter.Match(&event) {
+			events = append(events, &event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit file: %w", err)
+	}
+
+	slices.SortStableFunc(events, func(a, b *Event) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return filter.Apply(events), nil
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cidr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net"
+)
+
+// ErrNoFreeRange is returned when the supernet has no range that doesn't
+// overlap one in use
+var ErrNoFreeRange = errors.New("no free range")
+
+// Only IPv4 networks can be carved
+func toIPv4(n *net.IPNet) (start uint32, prefix int, err error) {
+	ip := n.IP.To4()
+	if ip == nil {
+		return 0, 0, fmt.Errorf("%s is not an ipv4 network", n)
+	}
+	prefix, size := n.Mask.Size()
+	if size != net.IPv4len*8 {
+		return 0, 0, fmt.Errorf("%s is not an ipv4 network", n)
+	}
+	return binary.BigEndian.Uint32(ip.Mask(n.Mask)), prefix, nil
+}
+
+func fromIPv4(start uint32, prefix int) *net.IPNet {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, start)
+	return &net.IPNet{
+		IP:   ip,
+		Mask: net.CIDRMask(prefix, net.IPv4len*8),
+	}
+}
+
+// Subnets divides the network into every subnet of the new prefix length, in
+// address order
+func Subnets(n *net.IPNet, newPrefix int) ([]*net.IPNet, error) {
+	start, prefix, err := toIPv4(n)
+	if err != nil {
+		return nil, err
+	}
+	if newPrefix < prefix || newPrefix > net.IPv4len*8 {
+		return nil, fmt.Errorf("cannot divide %s into /%d subnets", n, newPrefix)
+	}
+
+	count := uint64(1) << (newPrefix - prefix)
+	step := uint64(1) << (net.IPv4len*8 - newPrefix)
+
+	subnets := make([]*net.IPNet, 0, count)
+	for i := range count {
+		//nolint:gosec // the subnets are within the network so don't overflow
+		subnets = append(subnets, fromIPv4(start+uint32(i*step), newPrefix))
+	}
+	return subnets, nil
+}
+
+// Split divides the network into at least count equal subnets, which are as
+// large as possible. Only the first count subnets are returned.
+func Split(n *net.IPNet, count int) ([]*net.IPNet, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("cannot split %s into %d subnets", n, count)
+	}
+
+	_, prefix, err := toIPv4(n)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enough bits to number every subnet
+	newPrefix := prefix + bits.Len(uint(count-1))
+	if newPrefix > net.IPv4len*8 {
+		return nil, fmt.Errorf("%s is too small to split into %d subnets", n, count)
+	}
+
+	subnets, err := Subnets(n, newPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return subnets[:count], nil
+}
+
+// Overlaps reports whether the networks share any addresses
+func Overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// FindFree returns the first range of the prefix length in the supernet that
+// doesn't overlap any of the used networks
+func FindFree(supernet *net.IPNet, prefix int, used []*net.IPNet) (*net.IPNet, error) {
+	start, superPrefix, err := toIPv4(supernet)
+	if err != nil {
+		return nil, err
+	}
+	if prefix < superPrefix || prefix > net.IPv4len*8 {
+		return nil, fmt.Errorf("cannot find a /%d range in %s", prefix, supernet)
+	}
+
+	step := uint64(1) << (net.IPv4len*8 - prefix)
+	end := uint64(start) + uint64(1)<<(net.IPv4len*8-superPrefix)
+
+	// Walk the candidates, jumping past anything in use rather than checking
+	// every range of a large supernet
+	for next := uint64(start); next < end; {
+		//nolint:gosec // next is within the supernet
+		candidate := fromIPv4(uint32(next), prefix)
+
+		blocked := false
+		for _, u := range used {
+			if !Overlaps(candidate, u) {
+				continue
+			}
+			blocked = true
+
+			// Skip to the first aligned range after the used network
+			if uStart, uPrefix, err := toIPv4(u); err == nil {
+				uEnd := uint64(uStart) + uint64(1)<<(net.IPv4len*8-uPrefix)
+				next = max(next+step, (uEnd+step-1)/step*step)
+			} else {
+				next += step
+			}
+			break
+		}
+
+		if !blocked {
+			return candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w of /%d in %s", ErrNoFreeRange, prefix, supernet)
+}
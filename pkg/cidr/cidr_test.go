@@ -0,0 +1,174 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cidr_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/cidr"
+	"github.com/stretchr/testify/assert"
+)
+
+func parse(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(s)
+	assert.NoError(t, err)
+	return n
+}
+
+func cidrStrings(nets []*net.IPNet) []string {
+	s := make([]string, 0, len(nets))
+	for _, n := range nets {
+		s = append(s, n.String())
+	}
+	return s
+}
+
+func Test_Split(t *testing.T) {
+	tests := []struct {
+		Name     string
+		CIDR     string
+		Count    int
+		Expected []string
+		Err      bool
+	}{
+		{
+			Name:     "one",
+			CIDR:     "10.0.0.0/16",
+			Count:    1,
+			Expected: []string{"10.0.0.0/16"},
+		},
+		{
+			Name:     "power of two",
+			CIDR:     "10.0.0.0/16",
+			Count:    4,
+			Expected: []string{"10.0.0.0/18", "10.0.64.0/18", "10.0.128.0/18", "10.0.192.0/18"},
+		},
+		{
+			Name:     "rounded up",
+			CIDR:     "10.0.0.0/24",
+			Count:    3,
+			Expected: []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26"},
+		},
+		{
+			Name:  "too small",
+			CIDR:  "10.0.0.0/31",
+			Count: 3,
+			Err:   true,
+		},
+		{
+			Name:  "none",
+			CIDR:  "10.0.0.0/24",
+			Count: 0,
+			Err:   true,
+		},
+		{
+			Name:  "ipv6",
+			CIDR:  "2001:db8::/32",
+			Count: 2,
+			Err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			subnets, err := cidr.Split(parse(t, test.CIDR), test.Count)
+			if test.Err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, cidrStrings(subnets))
+		})
+	}
+}
+
+func Test_Overlaps(t *testing.T) {
+	assert.True(t, cidr.Overlaps(parse(t, "10.0.0.0/16"), parse(t, "10.0.1.0/24")))
+	assert.True(t, cidr.Overlaps(parse(t, "10.0.1.0/24"), parse(t, "10.0.0.0/16")))
+	assert.False(t, cidr.Overlaps(parse(t, "10.0.0.0/24"), parse(t, "10.0.1.0/24")))
+}
+
+func Test_FindFree(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Supernet string
+		Prefix   int
+		Used     []string
+		Expected string
+		Err      bool
+	}{
+		{
+			Name:     "empty",
+			Supernet: "10.0.0.0/8",
+			Prefix:   16,
+			Expected: "10.0.0.0/16",
+		},
+		{
+			Name:     "skips used",
+			Supernet: "10.0.0.0/8",
+			Prefix:   16,
+			Used:     []string{"10.0.0.0/16", "10.1.0.0/24"},
+			Expected: "10.2.0.0/16",
+		},
+		{
+			Name:     "skips larger used range",
+			Supernet: "10.0.0.0/8",
+			Prefix:   24,
+			Used:     []string{"10.0.0.0/12"},
+			Expected: "10.16.0.0/24",
+		},
+		{
+			Name:     "ignores ranges outside the supernet",
+			Supernet: "10.0.0.0/8",
+			Prefix:   16,
+			Used:     []string{"192.168.0.0/16"},
+			Expected: "10.0.0.0/16",
+		},
+		{
+			Name:     "full",
+			Supernet: "10.0.0.0/15",
+			Prefix:   16,
+			Used:     []string{"10.0.0.0/16", "10.1.128.0/17"},
+			Err:      true,
+		},
+		{
+			Name:     "prefix larger than supernet",
+			Supernet: "10.0.0.0/16",
+			Prefix:   8,
+			Err:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			used := make([]*net.IPNet, 0, len(test.Used))
+			for _, u := range test.Used {
+				used = append(used, parse(t, u))
+			}
+
+			free, err := cidr.FindFree(parse(t, test.Supernet), test.Prefix, used)
+			if test.Err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, free.String())
+		})
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MetadataEncodingEncrypted marks a payload as encrypted by this codec
+	MetadataEncodingEncrypted = "binary/encrypted"
+	// MetadataEncryptionKeyID is the ID of the key used to encrypt the payload
+	MetadataEncryptionKeyID = "encryption-key-id"
+)
+
+// Keyring is the set of keys the codec can use. New payloads are always
+// encrypted with the active key, but any key in the ring can decrypt. To
+// rotate, add a new key and make it active - the old keys must be kept until
+// the histories encrypted with them have been deleted.
+type Keyring struct {
+	Active string
+	Keys   map[string][]byte
+}
+
+// The key file is YAML with base64-encoded AES keys of 16, 24 or 32 bytes
+type keyFile struct {
+	Active string            `yaml:"active"`
+	Keys   map[string]string `yaml:"keys"`
+}
+
+// LoadKeyring reads the keys from a file
+func LoadKeyring(file string) (*Keyring, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file: %w", err)
+	}
+
+	var f keyFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error parsing key file: %w", err)
+	}
+
+	keyring := &Keyring{
+		Active: f.Active,
+		Keys:   map[string][]byte{},
+	}
+	for id, encoded := range f.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding key %s: %w", id, err)
+		}
+		keyring.Keys[id] = key
+	}
+
+	if err := keyring.Validate(); err != nil {
+		return nil, err
+	}
+
+	return keyring, nil
+}
+
+// Validate ensures the active key exists and every key is a valid AES key
+func (k *Keyring) Validate() error {
+	if _, ok := k.Keys[k.Active]; !ok {
+		return fmt.Errorf("active key not found: %s", k.Active)
+	}
+
+	for id, key := range k.Keys {
+		if _, err := aes.NewCipher(key); err != nil {
+			return fmt.Errorf("invalid key %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// Codec encrypts the payloads with AES-GCM so they're not stored in plain text
+// in the workflow history
+type Codec struct {
+	keyring *Keyring
+}
+
+// New creates the codec
+func New(keyring *Keyring) (*Codec, error) {
+	if err := keyring.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Codec{keyring: keyring}, nil
+}
+
+// NewDataConverter wraps the default data converter with the codec
+func NewDataConverter(c *Codec) converter.DataConverter {
+	return converter.NewCodecDataConverter(converter.GetDefaultDataConverter(), c)
+}
+
+func (c *Codec) aead(keyID string) (cipher.AEAD, error) {
+	key, ok := c.keyring.Keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key: %s", keyID)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Encode encrypts the payloads with the active key
+func (c *Codec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	keyID := c.keyring.Active
+
+	aead, err := c.aead(keyID)
+	if err != nil {
+		return payloads, err
+	}
+
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		data, err := proto.Marshal(p)
+		if err != nil {
+			return payloads, fmt.Errorf("error marshalling payload: %w", err)
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return payloads, fmt.Errorf("error generating nonce: %w", err)
+		}
+
+		result[i] = &commonpb.Payload{
+			Metadata: map[string][]byte{
+				converter.MetadataEncoding: []byte(MetadataEncodingEncrypted),
+				MetadataEncryptionKeyID:    []byte(keyID),
+			},
+			// The nonce is prepended to the ciphertext
+			Data: aead.Seal(nonce, nonce, data, nil),
+		}
+	}
+
+	return result, nil
+}
+
+// Decode decrypts the payloads with the key they were encrypted with. Payloads
+// that are not encrypted are returned unchanged.
+func (c *Codec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		if string(p.Metadata[converter.MetadataEncoding]) != MetadataEncodingEncrypted {
+			result[i] = p
+			continue
+		}
+
+		aead, err := c.aead(string(p.Metadata[MetadataEncryptionKeyID]))
+		if err != nil {
+			return payloads, err
+		}
+
+		if len(p.Data) < aead.NonceSize() {
+			return payloads, fmt.Errorf("encrypted payload too short")
+		}
+
+		nonce, ciphertext := p.Data[:aead.NonceSize()], p.Data[aead.NonceSize():]
+		data, err := aead.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			return payloads, fmt.Errorf("error decrypting payload: %w", err)
+		}
+
+		result[i] = &commonpb.Payload{}
+		if err := proto.Unmarshal(data, result[i]); err != nil {
+			return payloads, fmt.Errorf("error unmarshalling payload: %w", err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/codec"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func newKeyring(active string, ids ...string) *codec.Keyring {
+	keyring := &codec.Keyring{
+		Active: active,
+		Keys:   map[string][]byte{},
+	}
+	for _, id := range ids {
+		keyring.Keys[id] = bytes.Repeat([]byte(id[:1]), 32)
+	}
+	return keyring
+}
+
+func Test_CodecRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := codec.New(newKeyring("a", "a"))
+	assert.NoError(err)
+
+	dc := codec.NewDataConverter(c)
+
+	cfg := providers.CloudConfig{Name: "secret-project", Region: "eu-west-2"}
+	payload, err := dc.ToPayload(cfg)
+	assert.NoError(err)
+
+	assert.Equal(codec.MetadataEncodingEncrypted, string(payload.Metadata[converter.MetadataEncoding]))
+	assert.Equal("a", string(payload.Metadata[codec.MetadataEncryptionKeyID]))
+	assert.NotContains(string(payload.Data), "secret-project")
+
+	var result providers.CloudConfig
+	assert.NoError(dc.FromPayload(payload, &result))
+	assert.Equal(cfg, result)
+}
+
+func Test_CodecRotation(t *testing.T) {
+	assert := assert.New(t)
+
+	old, err := codec.New(newKeyring("a", "a"))
+	assert.NoError(err)
+
+	encrypted, err := old.Encode([]*commonpb.Payload{{Data: []byte("hello")}})
+	assert.NoError(err)
+
+	// Rotate to a new key, keeping the old one to decrypt existing payloads
+	rotated, err := codec.New(newKeyring("b", "a", "b"))
+	assert.NoError(err)
+
+	decrypted, err := rotated.Decode(encrypted)
+	assert.NoError(err)
+	assert.Equal("hello", string(decrypted[0].Data))
+
+	encrypted, err = rotated.Encode([]*commonpb.Payload{{Data: []byte("hello")}})
+	assert.NoError(err)
+	assert.Equal("b", string(encrypted[0].Metadata[codec.MetadataEncryptionKeyID]))
+
+	// The old key cannot decrypt the new payloads
+	_, err = old.Decode(encrypted)
+	assert.ErrorContains(err, "unknown encryption key: b")
+}
+
+func Test_CodecDecodeUnencrypted(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := codec.New(newKeyring("a", "a"))
+	assert.NoError(err)
+
+	payloads := []*commonpb.Payload{{
+		Metadata: map[string][]byte{converter.MetadataEncoding: []byte("json/plain")},
+		Data:     []byte(`"hello"`),
+	}}
+
+	decoded, err := c.Decode(payloads)
+	assert.NoError(err)
+	assert.Equal(payloads, decoded)
+}
+
+func Test_LoadKeyring(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("a"), 32))
+
+	tests := []struct {
+		Name  string
+		File  string
+		Error string
+	}{
+		{
+			Name: "valid",
+			File: "active: a\nkeys:\n  a: " + key + "\n",
+		},
+		{
+			Name:  "missing active key",
+			File:  "active: b\nkeys:\n  a: " + key + "\n",
+			Error: "active key not found: b",
+		},
+		{
+			Name:  "invalid key length",
+			File:  "active: a\nkeys:\n  a: " + base64.StdEncoding.EncodeToString([]byte("short")) + "\n",
+			Error: "invalid key a",
+		},
+		{
+			Name:  "invalid base64",
+			File:  "active: a\nkeys:\n  a: not-base64\n",
+			Error: "error decoding key a",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			file := filepath.Join(t.TempDir(), "keys.yaml")
+			assert.NoError(os.WriteFile(file, []byte(test.File), 0o600))
+
+			keyring, err := codec.LoadKeyring(file)
+			if test.Error != "" {
+				assert.ErrorContains(err, test.Error)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal("a", keyring.Active)
+			assert.Len(keyring.Keys["a"], 32)
+		})
+	}
+}
+
+func Test_NewHTTPHandler(t *testing.T) {
+	c, err := codec.New(newKeyring("a", "a"))
+	assert.NoError(t, err)
+
+	encrypted, err := c.Encode([]*commonpb.Payload{{Data: []byte("hello")}})
+	assert.NoError(t, err)
+
+	body, err := protojson.Marshal(&commonpb.Payloads{Payloads: encrypted})
+	assert.NoError(t, err)
+
+	handler := codec.NewHTTPHandler(c, codec.ServerOptions{
+		AllowedOrigins: []string{"http://localhost:8233"},
+		AuthToken:      "token",
+	})
+
+	tests := []struct {
+		Name   string
+		Method string
+		Token  string
+		Status int
+	}{
+		{
+			Name:   "authorised",
+			Method: http.MethodPost,
+			Token:  "Bearer token",
+			Status: http.StatusOK,
+		},
+		{
+			Name:   "unauthorised",
+			Method: http.MethodPost,
+			Token:  "Bearer wrong",
+			Status: http.StatusUnauthorized,
+		},
+		{
+			Name:   "preflight",
+			Method: http.MethodOptions,
+			Status: http.StatusNoContent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			req := httptest.NewRequest(test.Method, "/decode", bytes.NewReader(body))
+			req.Header.Set("Origin", "http://localhost:8233")
+			if test.Token != "" {
+				req.Header.Set("Authorization", test.Token)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(test.Status, rec.Code)
+			assert.Equal("http://localhost:8233", rec.Header().Get("Access-Control-Allow-Origin"))
+
+			if test.Status == http.StatusOK {
+				var payloads commonpb.Payloads
+				assert.NoError(protojson.Unmarshal(rec.Body.Bytes(), &payloads))
+				assert.Equal("hello", string(payloads.Payloads[0].Data))
+			}
+		})
+	}
+}
+
+func Test_NewHTTPHandlerUnknownOrigin(t *testing.T) {
+	c, err := codec.New(newKeyring("a", "a"))
+	assert.NoError(t, err)
+
+	handler := codec.NewHTTPHandler(c, codec.ServerOptions{
+		AllowedOrigins: []string{"http://localhost:8233"},
+		AuthToken:      "token",
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/decode", nil)
+	req.Header.Set("Origin", "http://evil.example.com")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func Test_CheckListenAddress(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Addr  string
+		Token string
+		Error bool
+	}{
+		{
+			Name: "loopback without token",
+			Addr: "127.0.0.1:8081",
+		},
+		{
+			Name: "localhost without token",
+			Addr: "localhost:8081",
+		},
+		{
+			Name: "ipv6 loopback without token",
+			Addr: "[::1]:8081",
+		},
+		{
+			Name:  "all interfaces without token",
+			Addr:  ":8081",
+			Error: true,
+		},
+		{
+			Name:  "public address without token",
+			Addr:  "10.0.0.1:8081",
+			Error: true,
+		},
+		{
+			Name:  "all interfaces with token",
+			Addr:  ":8081",
+			Token: "token",
+		},
+		{
+			Name:  "invalid address",
+			Addr:  "8081",
+			Error: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := codec.CheckListenAddress(test.Addr, codec.ServerOptions{AuthToken: test.Token})
+
+			if test.Error {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// ServerOptions configures the codec server
+type ServerOptions struct {
+	// Origins allowed to call the codec server, eg the Temporal UI. These
+	// must match exactly - there is no wildcard
+	AllowedOrigins []string
+	// Bearer token required in the Authorization header - disabled if empty
+	AuthToken string
+}
+
+// CheckListenAddress refuses to serve the codec without a token on anything
+// other than the loopback interface, as anyone who can reach it could decrypt
+// every payload
+func CheckListenAddress(addr string, opts ServerOptions) error {
+	if opts.AuthToken != "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+
+	return fmt.Errorf("an auth token is required to listen on %q - set one or listen on 127.0.0.1", addr)
+}
+
+// Allow the Temporal UI to call the codec server from the browser
+func cors(origins []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && slices.Contains(origins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type,X-Namespace")
+			w.Header().Set("Access-Control-Allow-Methods", "POST,OPTIONS")
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Only allow authorised users to decode the payloads
+func auth(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewHTTPHandler serves the codec's /encode and /decode endpoints
+func NewHTTPHandler(c *Codec, opts ServerOptions) http.Handler {
+	handler := converter.NewPayloadCodecHTTPHandler(c)
+
+	if opts.AuthToken != "" {
+		handler = auth(opts.AuthToken, handler)
+	}
+
+	return cors(opts.AllowedOrigins, handler)
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+type AuthMethod string
+
+const (
+	AuthNone   AuthMethod = "none"
+	AuthAPIKey AuthMethod = "api-key"
+	AuthMTLS   AuthMethod = "mtls"
+)
+
+// TLS settings for a profile
+type TLS struct {
+	Enabled    bool   `mapstructure:"enabled" yaml:"enabled,omitempty"`
+	Cert       string `mapstructure:"cert" yaml:"cert,omitempty"`
+	Key        string `mapstructure:"key" yaml:"key,omitempty"`
+	CA         string `mapstructure:"ca" yaml:"ca,omitempty"`
+	ServerName string `mapstructure:"serverName" yaml:"serverName,omitempty"`
+}
+
+// Profile holds the settings to connect to a Temporal server
+type Profile struct {
+	Address   string     `mapstructure:"address" yaml:"address"`
+	Namespace string     `mapstructure:"namespace" yaml:"namespace,omitempty"`
+	Auth      AuthMethod `mapstructure:"auth" yaml:"auth,omitempty"`
+	APIKey    string     `mapstructure:"apiKey" yaml:"apiKey,omitempty"`
+	TLS       TLS        `mapstructure:"tls" yaml:"tls,omitempty"`
+}
+
+// Config is the file of named profiles
+type Config struct {
+	// Profile used when --profile is not set
+	CurrentProfile string              `mapstructure:"currentProfile"`
+	Profiles       map[string]*Profile `mapstructure:"profiles"`
+
+	// Where the config was loaded from
+	File string `mapstructure:"-"`
+}
+
+// DefaultFile is the config file used if one is not specified
+func DefaultFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting config directory: %w", err)
+	}
+	return filepath.Join(dir, "temporal-provisioner", "config.yaml"), nil
+}
+
+// Load reads the config file. If the file does not exist, an empty config is
+// returned.
+func Load(file string) (*Config, error) {
+	cfg := &Config{
+		Profiles: map[string]*Profile{},
+		File:     file,
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Names returns the profile names in order
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Active returns the name of the selected profile, or the current profile if
+// none is selected. An empty name means no profile is used.
+func (c *Config) Active(selected string) (string, *Profile, error) {
+	name := selected
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return "", nil, fmt.Errorf("profile not found: %s", name)
+	}
+
+	return name, profile, nil
+}
+
+// Validate checks the profile has the settings needed for its auth method
+func (p *Profile) Validate() error {
+	errs := make([]error, 0)
+
+	if p.Address == "" {
+		errs = append(errs, fmt.Errorf("address is required"))
+	}
+
+	switch p.Auth {
+	case "", AuthNone:
+		if p.APIKey != "" {
+			errs = append(errs, fmt.Errorf("apiKey is set but auth is %s", AuthNone))
+		}
+	case AuthAPIKey:
+		if p.APIKey == "" {
+			errs = append(errs, fmt.Errorf("apiKey is required for %s auth", AuthAPIKey))
+		}
+	case AuthMTLS:
+		if p.TLS.Cert == "" || p.TLS.Key == "" {
+			errs = append(errs, fmt.Errorf("tls.cert and tls.key are required for %s auth", AuthMTLS))
+		}
+	default:
+		errs = append(errs, fmt.Errorf(
+			"unknown auth method %s - must be one of %v",
+			p.Auth, []AuthMethod{AuthNone, AuthAPIKey, AuthMTLS},
+		))
+	}
+
+	for _, file := range []string{p.TLS.Cert, p.TLS.Key, p.TLS.CA} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			errs = append(errs, fmt.Errorf("tls file not readable: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks every profile. The errors are keyed by the profile name.
+func (c *Config) Validate() map[string]error {
+	errs := map[string]error{}
+
+	if c.CurrentProfile != "" && !slices.Contains(c.Names(), c.CurrentProfile) {
+		errs[c.CurrentProfile] = fmt.Errorf("current profile not found")
+	}
+
+	for name, profile := range c.Profiles {
+		if err := profile.Validate(); err != nil {
+			errs[name] = err
+		}
+	}
+
+	return errs
+}
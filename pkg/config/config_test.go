@@ -0,0 +1,141 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Load(t *testing.T) {
+	assert := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(os.WriteFile(file, []byte(`currentProfile: dev
+profiles:
+  dev:
+    address: localhost:7233
+    namespace: default
+  cloud:
+    address: ns.acct.tmprl.cloud:7233
+    namespace: ns.acct
+    auth: api-key
+    apiKey: secret
+`), 0o600))
+
+	cfg, err := config.Load(file)
+	assert.NoError(err)
+	assert.Equal([]string{"cloud", "dev"}, cfg.Names())
+
+	name, profile, err := cfg.Active("")
+	assert.NoError(err)
+	assert.Equal("dev", name)
+	assert.Equal("localhost:7233", profile.Address)
+
+	name, profile, err = cfg.Active("cloud")
+	assert.NoError(err)
+	assert.Equal("cloud", name)
+	assert.Equal(config.AuthAPIKey, profile.Auth)
+	assert.Equal("secret", profile.APIKey)
+
+	_, _, err = cfg.Active("missing")
+	assert.ErrorContains(err, "profile not found: missing")
+
+	assert.Empty(cfg.Validate())
+}
+
+func Test_LoadMissingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"))
+	assert.NoError(err)
+	assert.Empty(cfg.Profiles)
+
+	name, profile, err := cfg.Active("")
+	assert.NoError(err)
+	assert.Empty(name)
+	assert.Nil(profile)
+}
+
+func Test_ProfileValidate(t *testing.T) {
+	cert := filepath.Join(t.TempDir(), "tls.crt")
+	assert.NoError(t, os.WriteFile(cert, []byte("cert"), 0o600))
+
+	tests := []struct {
+		Name    string
+		Profile config.Profile
+		Error   string
+	}{
+		{
+			Name:    "no auth",
+			Profile: config.Profile{Address: "localhost:7233"},
+		},
+		{
+			Name:    "missing address",
+			Profile: config.Profile{},
+			Error:   "address is required",
+		},
+		{
+			Name:    "api key",
+			Profile: config.Profile{Address: "localhost:7233", Auth: config.AuthAPIKey, APIKey: "key"},
+		},
+		{
+			Name:    "missing api key",
+			Profile: config.Profile{Address: "localhost:7233", Auth: config.AuthAPIKey},
+			Error:   "apiKey is required for api-key auth",
+		},
+		{
+			Name:    "api key without auth",
+			Profile: config.Profile{Address: "localhost:7233", APIKey: "key"},
+			Error:   "apiKey is set but auth is none",
+		},
+		{
+			Name:    "mtls",
+			Profile: config.Profile{Address: "localhost:7233", Auth: config.AuthMTLS, TLS: config.TLS{Cert: cert, Key: cert}},
+		},
+		{
+			Name:    "missing mtls key",
+			Profile: config.Profile{Address: "localhost:7233", Auth: config.AuthMTLS, TLS: config.TLS{Cert: cert}},
+			Error:   "tls.cert and tls.key are required for mtls auth",
+		},
+		{
+			Name:    "missing tls file",
+			Profile: config.Profile{Address: "localhost:7233", TLS: config.TLS{CA: "/missing/ca.crt"}},
+			Error:   "tls file not readable",
+		},
+		{
+			Name:    "unknown auth",
+			Profile: config.Profile{Address: "localhost:7233", Auth: "oauth"},
+			Error:   "unknown auth method oauth",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := test.Profile.Validate()
+			if test.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.Error)
+			}
+		})
+	}
+}
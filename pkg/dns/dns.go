@@ -0,0 +1,83 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dns publishes the addresses of a project's resources as DNS records
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RecordType is the type of a DNS record
+type RecordType string
+
+const (
+	RecordTypeA RecordType = "A"
+)
+
+// DefaultTTL is used when the config doesn't set a TTL
+const DefaultTTL = time.Minute * 5
+
+// ErrInvalidRecord is returned for a record which the server would never
+// accept, so retrying won't help
+var ErrInvalidRecord = errors.New("invalid record")
+
+// Record points a name at an address
+type Record struct {
+	// Fully qualified name, without the trailing dot
+	Name    string
+	Type    RecordType
+	TTL     time.Duration
+	Address net.IP
+}
+
+// Provider makes changes to the records in a zone. Both methods are
+// idempotent so they're safe to retry.
+type Provider interface {
+	// UpsertRecords replaces any records with the same name and type
+	UpsertRecords(ctx context.Context, zone string, records []Record) error
+	// DeleteRecords removes the records - deleting a record which doesn't
+	// exist is not an error
+	DeleteRecords(ctx context.Context, zone string, records []Record) error
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Label converts a name into a valid DNS label, eg "Web Server" becomes
+// "web-server"
+func Label(name string) string {
+	label := invalidLabelChars.ReplaceAllString(strings.ToLower(name), "-")
+	label = strings.Trim(label, "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	return label
+}
+
+// RecordName joins the labels to the zone, eg "node.project.example.com"
+func RecordName(zone string, labels ...string) string {
+	parts := make([]string, 0, len(labels)+1)
+	for _, l := range labels {
+		parts = append(parts, Label(l))
+	}
+	parts = append(parts, strings.TrimSuffix(zone, "."))
+	return strings.Join(parts, ".")
+}
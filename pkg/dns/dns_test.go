@@ -0,0 +1,246 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/mrsimonemms/temporal/pkg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testZone   = "example.com"
+	testKey    = "update-key"
+	testSecret = "c2VjcmV0LXNlY3JldC1zZWNyZXQ="
+)
+
+// zoneServer is an in-process DNS server which applies the dynamic updates to
+// a map of names to addresses. Unsigned updates are refused.
+type zoneServer struct {
+	sync.Mutex
+	records map[string][]string
+}
+
+func (z *zoneServer) ServeDNS(w mdns.ResponseWriter, r *mdns.Msg) {
+	m := new(mdns.Msg)
+	m.SetReply(r)
+
+	switch {
+	case r.IsTsig() == nil:
+		m.Rcode = mdns.RcodeRefused
+	case w.TsigStatus() != nil:
+		m.Rcode = mdns.RcodeNotAuth
+	default:
+		z.apply(r.Ns)
+		t := r.IsTsig()
+		m.SetTsig(t.Hdr.Name, t.Algorithm, 300, time.Now().Unix())
+	}
+
+	_ = w.WriteMsg(m)
+}
+
+func (z *zoneServer) apply(updates []mdns.RR) {
+	z.Lock()
+	defer z.Unlock()
+
+	for _, rr := range updates {
+		name := rr.Header().Name
+		switch rr.Header().Class {
+		case mdns.ClassANY:
+			delete(z.records, name)
+		case mdns.ClassNONE:
+			addr := rr.(*mdns.A).A.String()
+			for i, a := range z.records[name] {
+				if a == addr {
+					z.records[name] = append(z.records[name][:i], z.records[name][i+1:]...)
+					break
+				}
+			}
+			if len(z.records[name]) == 0 {
+				delete(z.records, name)
+			}
+		default:
+			z.records[name] = append(z.records[name], rr.(*mdns.A).A.String())
+		}
+	}
+}
+
+func (z *zoneServer) get(name string) []string {
+	z.Lock()
+	defer z.Unlock()
+	return z.records[mdns.Fqdn(name)]
+}
+
+func startServer(t *testing.T) (*zoneServer, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	zone := &zoneServer{records: map[string][]string{}}
+	started := make(chan struct{})
+	server := &mdns.Server{
+		Listener:   listener,
+		Net:        "tcp",
+		Handler:    zone,
+		TsigSecret: map[string]string{mdns.Fqdn(testKey): testSecret},
+		// The default only accepts queries and notifies
+		MsgAcceptFunc:     func(mdns.Header) mdns.MsgAcceptAction { return mdns.MsgAccept },
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+
+	return zone, listener.Addr().String()
+}
+
+func Test_RFC2136Provider(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	zone, addr := startServer(t)
+
+	p, err := dns.NewRFC2136Provider(addr, testKey, testSecret, "hmac-sha256")
+	assert.NoError(err)
+
+	node := dns.Record{Name: "node.project.example.com", Type: dns.RecordTypeA, Address: net.IPv4(10, 0, 0, 1)}
+	lb := dns.Record{Name: "web.lb.project.example.com", Type: dns.RecordTypeA, Address: net.IPv4(10, 0, 0, 2)}
+
+	assert.NoError(p.UpsertRecords(ctx, testZone, []dns.Record{node, lb}))
+	assert.Equal([]string{"10.0.0.1"}, zone.get(node.Name))
+	assert.Equal([]string{"10.0.0.2"}, zone.get(lb.Name))
+
+	// Upserting a replaced node points the name at the new address
+	replaced := node
+	replaced.Address = net.IPv4(10, 0, 0, 3)
+	assert.NoError(p.UpsertRecords(ctx, testZone, []dns.Record{replaced}))
+	assert.Equal([]string{"10.0.0.3"}, zone.get(node.Name))
+
+	// Deleting the old address leaves the new record alone
+	assert.NoError(p.DeleteRecords(ctx, testZone, []dns.Record{node}))
+	assert.Equal([]string{"10.0.0.3"}, zone.get(node.Name))
+
+	assert.NoError(p.DeleteRecords(ctx, testZone, []dns.Record{replaced, lb}))
+	assert.Empty(zone.get(node.Name))
+	assert.Empty(zone.get(lb.Name))
+
+	// Deleting again is not an error
+	assert.NoError(p.DeleteRecords(ctx, testZone, []dns.Record{lb}))
+}
+
+func Test_RFC2136ProviderErrors(t *testing.T) {
+	_, addr := startServer(t)
+
+	record := dns.Record{Name: "node.project.example.com", Type: dns.RecordTypeA, Address: net.IPv4(10, 0, 0, 1)}
+
+	tests := []struct {
+		Name   string
+		Key    string
+		Secret string
+		Zone   string
+		Record dns.Record
+		Err    string
+	}{
+		{
+			Name:   "unsigned",
+			Zone:   testZone,
+			Record: record,
+			Err:    "dns update rejected: REFUSED",
+		},
+		{
+			Name:   "wrong secret",
+			Key:    testKey,
+			Secret: "d3Jvbmctc2VjcmV0",
+			Zone:   testZone,
+			Record: record,
+			Err:    "dns update rejected: NOTAUTH",
+		},
+		{
+			Name:   "outside zone",
+			Key:    testKey,
+			Secret: testSecret,
+			Zone:   "example.org",
+			Record: record,
+			Err:    "invalid record: node.project.example.com is not in zone example.org",
+		},
+		{
+			Name:   "ipv6",
+			Key:    testKey,
+			Secret: testSecret,
+			Zone:   testZone,
+			Record: dns.Record{Name: record.Name, Type: dns.RecordTypeA, Address: net.ParseIP("2001:db8::1")},
+			Err:    "invalid record: node.project.example.com must be an A record with an IPv4 address",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			p, err := dns.NewRFC2136Provider(addr, test.Key, test.Secret, "hmac-sha256")
+			assert.NoError(t, err)
+
+			err = p.UpsertRecords(context.Background(), test.Zone, []dns.Record{test.Record})
+			assert.ErrorContains(t, err, test.Err)
+		})
+	}
+}
+
+func Test_NewRFC2136Provider(t *testing.T) {
+	_, err := dns.NewRFC2136Provider("", "", "", "")
+	assert.EqualError(t, err, "a dns server is required")
+
+	_, err = dns.NewRFC2136Provider("127.0.0.1:53", testKey, testSecret, "hmac-md5")
+	assert.EqualError(t, err, "unknown tsig algorithm: hmac-md5")
+}
+
+func Test_RecordName(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Labels   []string
+		Zone     string
+		Expected string
+	}{
+		{
+			Name:     "node",
+			Labels:   []string{"silent-butterfly", "my-project"},
+			Zone:     "example.com",
+			Expected: "silent-butterfly.my-project.example.com",
+		},
+		{
+			Name:     "sanitised",
+			Labels:   []string{"Web Server_1", "--Project--"},
+			Zone:     "example.com.",
+			Expected: "web-server-1.project.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, dns.RecordName(test.Zone, test.Labels...))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// Algorithms the TSIG key can use
+var TSIGAlgorithms = []string{"hmac-sha1", "hmac-sha224", "hmac-sha256", "hmac-sha384", "hmac-sha512"}
+
+// How long a signed update is valid for, to allow for clock skew
+const tsigFudge = 300
+
+// RFC2136Provider sends dynamic updates to a DNS server, such as BIND or
+// Knot. The updates are signed if a TSIG key is set.
+type RFC2136Provider struct {
+	// Address of the primary server, eg "ns1.example.com:53"
+	Server string
+	// Name of the TSIG key and its base64 encoded secret
+	TSIGKey    string
+	TSIGSecret string
+	// One of TSIGAlgorithms
+	TSIGAlgorithm string
+	Client        *mdns.Client
+}
+
+func NewRFC2136Provider(server, tsigKey, tsigSecret, tsigAlgorithm string) (*RFC2136Provider, error) {
+	if server == "" {
+		return nil, fmt.Errorf("a dns server is required")
+	}
+	if tsigKey != "" && !slices.Contains(TSIGAlgorithms, strings.TrimSuffix(tsigAlgorithm, ".")) {
+		return nil, fmt.Errorf("unknown tsig algorithm: %s", tsigAlgorithm)
+	}
+
+	// Updates can be too large for UDP
+	client := &mdns.Client{
+		Net:     "tcp",
+		Timeout: time.Second * 10,
+	}
+	if tsigKey != "" {
+		client.TsigSecret = map[string]string{
+			mdns.Fqdn(tsigKey): tsigSecret,
+		}
+	}
+
+	return &RFC2136Provider{
+		Server:        server,
+		TSIGKey:       tsigKey,
+		TSIGSecret:    tsigSecret,
+		TSIGAlgorithm: tsigAlgorithm,
+		Client:        client,
+	}, nil
+}
+
+// UpsertRecords replaces each record's set in a single update, so either all
+// the records are changed or none are
+func (p *RFC2136Provider) UpsertRecords(ctx context.Context, zone string, records []Record) error {
+	rrs, err := toRRs(zone, records)
+	if err != nil {
+		return err
+	}
+
+	m := new(mdns.Msg)
+	m.SetUpdate(mdns.Fqdn(zone))
+	m.RemoveRRset(rrs)
+	m.Insert(rrs)
+
+	return p.send(ctx, m)
+}
+
+// DeleteRecords only removes the records with the same address, so a name
+// which has since been pointed elsewhere is left alone
+func (p *RFC2136Provider) DeleteRecords(ctx context.Context, zone string, records []Record) error {
+	rrs, err := toRRs(zone, records)
+	if err != nil {
+		return err
+	}
+
+	m := new(mdns.Msg)
+	m.SetUpdate(mdns.Fqdn(zone))
+	m.Remove(rrs)
+
+	return p.send(ctx, m)
+}
+
+func (p *RFC2136Provider) send(ctx context.Context, m *mdns.Msg) error {
+	if p.TSIGKey != "" {
+		m.SetTsig(mdns.Fqdn(p.TSIGKey), mdns.Fqdn(p.TSIGAlgorithm), tsigFudge, time.Now().Unix())
+	}
+
+	res, _, err := p.Client.ExchangeContext(ctx, m, p.Server)
+	if err != nil {
+		return fmt.Errorf("error sending dns update: %w", err)
+	}
+	if res.Rcode != mdns.RcodeSuccess {
+		return fmt.Errorf("dns update rejected: %s", mdns.RcodeToString[res.Rcode])
+	}
+	return nil
+}
+
+func toRRs(zone string, records []Record) ([]mdns.RR, error) {
+	rrs := make([]mdns.RR, 0, len(records))
+	for _, r := range records {
+		name := mdns.Fqdn(r.Name)
+		if !mdns.IsSubDomain(mdns.Fqdn(zone), name) {
+			return nil, fmt.Errorf("%w: %s is not in zone %s", ErrInvalidRecord, r.Name, zone)
+		}
+		if r.Type != RecordTypeA || r.Address.To4() == nil {
+			return nil, fmt.Errorf("%w: %s must be an A record with an IPv4 address", ErrInvalidRecord, r.Name)
+		}
+
+		ttl := r.TTL
+		if ttl == 0 {
+			ttl = DefaultTTL
+		}
+
+		rrs = append(rrs, &mdns.A{
+			Hdr: mdns.RR_Header{
+				Name:   name,
+				Rrtype: mdns.TypeA,
+				Class:  mdns.ClassINET,
+				Ttl:    uint32(ttl.Seconds()),
+			},
+			A: r.Address.To4(),
+		})
+	}
+	return rrs, nil
+}
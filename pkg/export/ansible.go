@@ -0,0 +1,72 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"io"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"gopkg.in/yaml.v3"
+)
+
+const FormatAnsible = "ansible"
+
+type ansibleGroup struct {
+	Hosts    map[string]map[string]any `yaml:"hosts,omitempty"`
+	Children map[string]*ansibleGroup  `yaml:"children,omitempty"`
+}
+
+// Ansible renders a YAML inventory. Each node is in a group for its pool and
+// a "<key>_<value>" group for each of its labels.
+func Ansible(w io.Writer, project *providers.ProjectResult) error {
+	all := &ansibleGroup{
+		Hosts:    map[string]map[string]any{},
+		Children: map[string]*ansibleGroup{},
+	}
+
+	addToGroup := func(group, host string) {
+		if _, ok := all.Children[group]; !ok {
+			all.Children[group] = &ansibleGroup{Hosts: map[string]map[string]any{}}
+		}
+		all.Children[group].Hosts[host] = map[string]any{}
+	}
+
+	for _, host := range Hosts(project) {
+		all.Hosts[host.Name] = map[string]any{
+			"ansible_host":  host.Address,
+			"ansible_port":  host.Port,
+			"zone":          host.Zone,
+			"instance_type": host.InstanceType,
+		}
+
+		addToGroup(identifier(host.Pool), host.Name)
+		for key, value := range host.Labels {
+			addToGroup(identifier(key, value), host.Name)
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]*ansibleGroup{"all": all}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func init() {
+	Register(FormatAnsible, ExporterFunc(Ansible))
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+)
+
+// Exporter renders a project in a format used by another tool, eg an Ansible
+// inventory
+type Exporter interface {
+	Export(w io.Writer, project *providers.ProjectResult) error
+}
+
+// ExporterFunc allows a function to be used as an Exporter
+type ExporterFunc func(w io.Writer, project *providers.ProjectResult) error
+
+func (f ExporterFunc) Export(w io.Writer, project *providers.ProjectResult) error {
+	return f(w, project)
+}
+
+var exporters = map[string]Exporter{}
+
+// Register makes an exporter available under the format name. Registering a
+// format twice replaces the existing exporter.
+func Register(format string, exporter Exporter) {
+	exporters[format] = exporter
+}
+
+// Get the exporter for a format
+func Get(format string) (Exporter, error) {
+	exporter, ok := exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %s - must be one of %s", format, strings.Join(Formats(), ", "))
+	}
+	return exporter, nil
+}
+
+// Formats lists the registered formats in alphabetical order
+func Formats() []string {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// Host is a node in the format used by the exporters
+type Host struct {
+	Name         string            `json:"name"`
+	Address      string            `json:"address"`
+	Port         int32             `json:"port"`
+	Pool         string            `json:"pool"`
+	Zone         string            `json:"zone"`
+	InstanceType string            `json:"instanceType"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// Hosts lists the nodes in the project. Nodes without an address are skipped
+// as there is nothing to connect to.
+func Hosts(project *providers.ProjectResult) []Host {
+	hosts := make([]Host, 0, len(project.Nodes))
+	for _, node := range project.Nodes {
+		if node == nil || len(node.Address) == 0 {
+			continue
+		}
+
+		pool := node.Pool
+		if pool == "" {
+			pool = providers.DefaultPoolName
+		}
+
+		hosts = append(hosts, Host{
+			Name:         node.Name,
+			Address:      node.Address.String(),
+			Port:         node.Port,
+			Pool:         pool,
+			Zone:         node.Zone,
+			InstanceType: node.InstanceType,
+			Labels:       node.Labels,
+		})
+	}
+	return hosts
+}
+
+var invalidGroupChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Convert a name to something that's safe to use as an identifier, eg an
+// Ansible group or a Terraform key
+func identifier(parts ...string) string {
+	return invalidGroupChars.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
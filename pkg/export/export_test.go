@@ -0,0 +1,203 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export_test
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/export"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+var project = &providers.ProjectResult{
+	CloudConfig: providers.CloudConfig{
+		Name: "my-project",
+	},
+	ID: "project-id",
+	Network: &providers.NetworkResult{
+		ID: "network-id",
+	},
+	Nodes: []*providers.NodeResult{
+		{
+			Name:         "node-1",
+			Pool:         "web",
+			Labels:       map[string]string{"role": "frontend"},
+			Zone:         "eu-west-2a",
+			InstanceType: "t3.medium",
+			Address:      net.IPv4(10, 0, 0, 1),
+			Port:         22,
+		},
+		{
+			Name:         "node-2",
+			Zone:         "eu-west-2b",
+			InstanceType: "t3.large",
+			Address:      net.IPv4(10, 0, 0, 2),
+			Port:         2222,
+		},
+		{
+			// Not yet created
+			Name: "node-3",
+		},
+	},
+}
+
+func Test_Exporters(t *testing.T) {
+	tests := []struct {
+		Format   string
+		Expected string
+	}{
+		{
+			Format: export.FormatAnsible,
+			Expected: `all:
+  hosts:
+    node-1:
+      ansible_host: 10.0.0.1
+      ansible_port: 22
+      instance_type: t3.medium
+      zone: eu-west-2a
+    node-2:
+      ansible_host: 10.0.0.2
+      ansible_port: 2222
+      instance_type: t3.large
+      zone: eu-west-2b
+  children:
+    default:
+      hosts:
+        node-2: {}
+    role_frontend:
+      hosts:
+        node-1: {}
+    web:
+      hosts:
+        node-1: {}
+`,
+		},
+		{
+			Format: export.FormatSSHConfig,
+			Expected: `Host node-1
+  HostName 10.0.0.1
+  Port 22
+
+Host node-2
+  HostName 10.0.0.2
+  Port 2222
+`,
+		},
+		{
+			Format: export.FormatHosts,
+			Expected: `# my-project (project-id)
+10.0.0.1	node-1
+10.0.0.2	node-2
+`,
+		},
+		{
+			Format: export.FormatTFVars,
+			Expected: `project_id   = "project-id"
+project_name = "my-project"
+network_id   = "network-id"
+
+nodes = {
+  "node-1" = {
+    address       = "10.0.0.1"
+    port          = 22
+    pool          = "web"
+    zone          = "eu-west-2a"
+    instance_type = "t3.medium"
+    labels = {
+      "role" = "frontend"
+    }
+  }
+  "node-2" = {
+    address       = "10.0.0.2"
+    port          = 2222
+    pool          = "default"
+    zone          = "eu-west-2b"
+    instance_type = "t3.large"
+    labels = {
+    }
+  }
+}
+`,
+		},
+		{
+			Format: export.FormatJSON,
+			Expected: `{
+  "id": "project-id",
+  "name": "my-project",
+  "hosts": [
+    {
+      "name": "node-1",
+      "address": "10.0.0.1",
+      "port": 22,
+      "pool": "web",
+      "zone": "eu-west-2a",
+      "instanceType": "t3.medium",
+      "labels": {
+        "role": "frontend"
+      }
+    },
+    {
+      "name": "node-2",
+      "address": "10.0.0.2",
+      "port": 2222,
+      "pool": "default",
+      "zone": "eu-west-2b",
+      "instanceType": "t3.large"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Format, func(t *testing.T) {
+			exporter, err := export.Get(test.Format)
+			assert.NoError(t, err)
+
+			var buf bytes.Buffer
+			assert.NoError(t, exporter.Export(&buf, project))
+			assert.Equal(t, test.Expected, buf.String())
+		})
+	}
+}
+
+func Test_Register(t *testing.T) {
+	_, err := export.Get("names")
+	assert.Error(t, err)
+
+	export.Register("names", export.ExporterFunc(func(w io.Writer, p *providers.ProjectResult) error {
+		for _, host := range export.Hosts(p) {
+			if _, err := io.WriteString(w, host.Name+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	assert.Contains(t, export.Formats(), "names")
+
+	exporter, err := export.Get("names")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, exporter.Export(&buf, project))
+	assert.Equal(t, "node-1\nnode-2\n", buf.String())
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+)
+
+const FormatHosts = "hosts"
+
+// HostsFile renders the nodes in the /etc/hosts format
+func HostsFile(w io.Writer, project *providers.ProjectResult) error {
+	if _, err := fmt.Fprintf(w, "# %s (%s)\n", project.Name, project.ID); err != nil {
+		return err
+	}
+	for _, host := range Hosts(project) {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", host.Address, host.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	Register(FormatHosts, ExporterFunc(HostsFile))
+}
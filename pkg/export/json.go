@@ -0,0 +1,47 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+)
+
+const FormatJSON = "json"
+
+type jsonProject struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Hosts []Host `json:"hosts"`
+}
+
+// JSON renders the project's hosts as a flat JSON document
+func JSON(w io.Writer, project *providers.ProjectResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonProject{
+		ID:    project.ID,
+		Name:  project.Name,
+		Hosts: Hosts(project),
+	})
+}
+
+func init() {
+	Register(FormatJSON, ExporterFunc(JSON))
+}
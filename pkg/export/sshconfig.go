@@ -0,0 +1,46 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+)
+
+const FormatSSHConfig = "ssh-config"
+
+// SSHConfig renders a host entry for each node which can be added to
+// ~/.ssh/config
+func SSHConfig(w io.Writer, project *providers.ProjectResult) error {
+	for i, host := range Hosts(project) {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "Host %s\n  HostName %s\n  Port %d\n", host.Name, host.Address, host.Port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	Register(FormatSSHConfig, ExporterFunc(SSHConfig))
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+)
+
+const FormatTFVars = "tfvars"
+
+// TFVars renders a Terraform variables file with a "nodes" map keyed by the
+// node name
+func TFVars(w io.Writer, project *providers.ProjectResult) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "project_id   = %s\n", strconv.Quote(project.ID))
+	fmt.Fprintf(&b, "project_name = %s\n", strconv.Quote(project.Name))
+	if project.Network != nil {
+		fmt.Fprintf(&b, "network_id   = %s\n", strconv.Quote(project.Network.ID))
+	}
+	b.WriteString("\nnodes = {\n")
+	for _, host := range Hosts(project) {
+		fmt.Fprintf(&b, "  %s = {\n", strconv.Quote(host.Name))
+		fmt.Fprintf(&b, "    address       = %s\n", strconv.Quote(host.Address))
+		fmt.Fprintf(&b, "    port          = %d\n", host.Port)
+		fmt.Fprintf(&b, "    pool          = %s\n", strconv.Quote(host.Pool))
+		fmt.Fprintf(&b, "    zone          = %s\n", strconv.Quote(host.Zone))
+		fmt.Fprintf(&b, "    instance_type = %s\n", strconv.Quote(host.InstanceType))
+		b.WriteString("    labels = {\n")
+		for _, key := range slices.Sorted(maps.Keys(host.Labels)) {
+			fmt.Fprintf(&b, "      %s = %s\n", strconv.Quote(key), strconv.Quote(host.Labels[key]))
+		}
+		b.WriteString("    }\n  }\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func init() {
+	Register(FormatTFVars, ExporterFunc(TFVars))
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	bolt "go.etcd.io/bbolt"
+)
+
+var auditBucket = []byte("audit")
+
+// The BoltStore can also keep the audit events, alongside the resources they
+// changed. Events are keyed by their ID so duplicates replace each other.
+var (
+	_ audit.Sink     = &BoltStore{}
+	_ audit.Searcher = &BoltStore{}
+)
+
+func (b *BoltStore) Write(_ context.Context, event *audit.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding audit event: %w", err)
+	}
+
+	return b.updateBucket(auditBucket, func(bucket *bolt.Bucket) error {
+		return bucket.Put([]byte(event.ID), data)
+	})
+}
+
+func (b *BoltStore) Search(_ context.Context, filter audit.Filter) ([]*audit.Event, error) {
+	events := make([]*audit.Event, 0)
+
+	err := b.viewBucket(auditBucket, func(bucket *bolt.Bucket) error {
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var event audit.Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				return fmt.Errorf("error decoding audit event %s: %w", k, err)
+			}
+			if filter.Match(&event) {
+				events = append(events, &event)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(events, func(a, b *audit.Event) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return filter.Apply(events), nil
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var resourcesBucket = []byte("resources")
+
+// BoltStore keeps the inventory in a BoltDB file. The file is only opened for
+// each operation so it can be read by the CLI whilst the worker is running.
+type BoltStore struct {
+	File string
+	// How long to wait for another process to release the file
+	Timeout time.Duration
+}
+
+func NewBoltStore(file string) *BoltStore {
+	return &BoltStore{
+		File:    file,
+		Timeout: time.Second * 10,
+	}
+}
+
+func (b *BoltStore) open(readOnly bool) (*bolt.DB, error) {
+	if !readOnly {
+		if err := os.MkdirAll(filepath.Dir(b.File), 0o700); err != nil {
+			return nil, fmt.Errorf("error creating inventory directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(b.File, 0o600, &bolt.Options{
+		Timeout:  b.Timeout,
+		ReadOnly: readOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening inventory: %w", err)
+	}
+	return db, nil
+}
+
+func (b *BoltStore) update(fn func(bucket *bolt.Bucket) error) error {
+	return b.updateBucket(resourcesBucket, fn)
+}
+
+func (b *BoltStore) updateBucket(name []byte, fn func(bucket *bolt.Bucket) error) error {
+	db, err := b.open(false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(name)
+		if err != nil {
+			return fmt.Errorf("error creating bucket: %w", err)
+		}
+		return fn(bucket)
+	})
+}
+
+func (b *BoltStore) view(fn func(bucket *bolt.Bucket) error) error {
+	return b.viewBucket(resourcesBucket, fn)
+}
+
+// Read a bucket. The bucket is nil if nothing has been written to it.
+func (b *BoltStore) viewBucket(name []byte, fn func(bucket *bolt.Bucket) error) error {
+	if _, err := os.Stat(b.File); errors.Is(err, os.ErrNotExist) {
+		return fn(nil)
+	}
+
+	db, err := b.open(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	return db.View(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket(name))
+	})
+}
+
+func getRecord(bucket *bolt.Bucket, id string) (*Record, error) {
+	if bucket == nil {
+		return nil, ErrNotFound
+	}
+
+	data := bucket.Get([]byte(id))
+	if data == nil {
+		return nil, ErrNotFound
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("error decoding record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+func putRecord(bucket *bolt.Bucket, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encoding record %s: %w", record.ID, err)
+	}
+	return bucket.Put([]byte(record.ID), data)
+}
+
+func (b *BoltStore) Put(_ context.Context, record *Record) error {
+	if record.ID == "" {
+		return fmt.Errorf("record has no id")
+	}
+
+	return b.update(func(bucket *bolt.Bucket) error {
+		// Don't lose the deletion if the create is replayed
+		if existing, err := getRecord(bucket, record.ID); err == nil && existing.Deleted() && !record.Deleted() {
+			record.DeletedAt = existing.DeletedAt
+			record.DeletedBy = existing.DeletedBy
+		}
+		return putRecord(bucket, record)
+	})
+}
+
+func (b *BoltStore) MarkDeleted(_ context.Context, deletion Deletion) error {
+	return b.update(func(bucket *bolt.Bucket) error {
+		record, err := getRecord(bucket, deletion.ID)
+		if errors.Is(err, ErrNotFound) {
+			// Created before the inventory existed - there's nothing to update
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		record.DeletedAt = &deletion.DeletedAt
+		record.DeletedBy = deletion.WorkflowID
+		return putRecord(bucket, record)
+	})
+}
+
+func (b *BoltStore) Get(_ context.Context, id string) (record *Record, err error) {
+	err = b.view(func(bucket *bolt.Bucket) error {
+		record, err = getRecord(bucket, id)
+		return err
+	})
+	return record, err
+}
+
+func (b *BoltStore) List(_ context.Context, filter Filter) ([]*Record, error) {
+	records := make([]*Record, 0)
+
+	err := b.view(func(bucket *bolt.Bucket) error {
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("error decoding record %s: %w", k, err)
+			}
+			if filter.Match(&record) {
+				records = append(records, &record)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(records, func(a, b *Record) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return records, nil
+}
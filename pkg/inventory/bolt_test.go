@@ -0,0 +1,175 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BoltStore(t *testing.T) {
+	ctx := context.Background()
+	store := inventory.NewBoltStore(filepath.Join(t.TempDir(), "inventory", "inventory.db"))
+
+	// Nothing has been recorded yet
+	records, err := store.List(ctx, inventory.Filter{})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = store.Get(ctx, "project-1")
+	assert.ErrorIs(t, err, inventory.ErrNotFound)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	project := &inventory.Record{
+		Kind:       inventory.KindProject,
+		ID:         "project-1",
+		ProjectID:  "project-1",
+		Owner:      "alice",
+		WorkflowID: "provision-1",
+		CreatedAt:  now,
+	}
+	node := &inventory.Record{
+		Kind:       inventory.KindNode,
+		ID:         "node-1",
+		ProjectID:  "project-1",
+		Owner:      "alice",
+		Details:    map[string]string{"address": "10.0.0.1"},
+		WorkflowID: "provision-1",
+		CreatedAt:  now.Add(time.Minute),
+	}
+	other := &inventory.Record{
+		Kind:       inventory.KindProject,
+		ID:         "project-2",
+		ProjectID:  "project-2",
+		Owner:      "bob",
+		WorkflowID: "provision-2",
+		CreatedAt:  now.Add(time.Second),
+	}
+	for _, r := range []*inventory.Record{node, project, other} {
+		assert.NoError(t, store.Put(ctx, r))
+	}
+
+	got, err := store.Get(ctx, "node-1")
+	assert.NoError(t, err)
+	assert.Equal(t, node, got)
+
+	deletedAt := now.Add(time.Hour)
+	assert.NoError(t, store.MarkDeleted(ctx, inventory.Deletion{ID: "node-1", WorkflowID: "teardown-1", DeletedAt: deletedAt}))
+	// Unknown resources are ignored
+	assert.NoError(t, store.MarkDeleted(ctx, inventory.Deletion{ID: "unknown", DeletedAt: deletedAt}))
+
+	// Replaying the create keeps the deletion
+	assert.NoError(t, store.Put(ctx, node))
+
+	got, err = store.Get(ctx, "node-1")
+	assert.NoError(t, err)
+	assert.True(t, got.Deleted())
+	assert.Equal(t, "teardown-1", got.DeletedBy)
+	assert.True(t, deletedAt.Equal(*got.DeletedAt))
+
+	tests := []struct {
+		Name     string
+		Filter   inventory.Filter
+		Expected []string
+	}{
+		{
+			Name:     "active",
+			Expected: []string{"project-1", "project-2"},
+		},
+		{
+			Name:     "including deleted",
+			Filter:   inventory.Filter{Deleted: true},
+			Expected: []string{"project-1", "project-2", "node-1"},
+		},
+		{
+			Name:     "by project",
+			Filter:   inventory.Filter{ProjectID: "project-1", Deleted: true},
+			Expected: []string{"project-1", "node-1"},
+		},
+		{
+			Name:     "by kind",
+			Filter:   inventory.Filter{Kind: inventory.KindNode, Deleted: true},
+			Expected: []string{"node-1"},
+		},
+		{
+			Name:     "by owner",
+			Filter:   inventory.Filter{Owner: "bob"},
+			Expected: []string{"project-2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			records, err := store.List(ctx, test.Filter)
+			assert.NoError(t, err)
+
+			ids := make([]string, 0, len(records))
+			for _, r := range records {
+				ids = append(ids, r.ID)
+			}
+			assert.Equal(t, test.Expected, ids)
+		})
+	}
+}
+
+func Test_BoltStoreAudit(t *testing.T) {
+	ctx := context.Background()
+	store := inventory.NewBoltStore(filepath.Join(t.TempDir(), "inventory.db"))
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	create := &audit.Event{
+		ID:         audit.EventID("workflow-id", "run-id", audit.OperationCreateNode, "node-1", 1),
+		Time:       now,
+		Actor:      "alice",
+		Operation:  audit.OperationCreateNode,
+		ResourceID: "node-1",
+		Result:     audit.ResultSuccess,
+	}
+	remove := &audit.Event{
+		ID:         audit.EventID("workflow-id", "run-id", audit.OperationDeleteNode, "node-1", 1),
+		Time:       now.Add(time.Hour),
+		Actor:      "bob",
+		Operation:  audit.OperationDeleteNode,
+		ResourceID: "node-1",
+		Result:     audit.ResultFailure,
+		Error:      "some error",
+	}
+
+	// Duplicate deliveries replace each other
+	for _, e := range []*audit.Event{remove, create, create} {
+		assert.NoError(t, store.Write(ctx, e))
+	}
+
+	events, err := store.Search(ctx, audit.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*audit.Event{create, remove}, events)
+
+	events, err = store.Search(ctx, audit.Filter{Result: audit.ResultFailure})
+	assert.NoError(t, err)
+	assert.Equal(t, []*audit.Event{remove}, events)
+
+	// The audit events are kept apart from the resources
+	records, err := store.List(ctx, inventory.Filter{Deleted: true})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+)
+
+// ErrNotFound is returned when a resource isn't in the inventory
+var ErrNotFound = errors.New("resource not found")
+
+// Kind is the type of a resource
+type Kind string
+
+const (
+	KindProject       Kind = "project"
+	KindNetwork       Kind = "network"
+	KindSecurityGroup Kind = "security-group"
+	KindNode          Kind = "node"
+	KindLoadBalancer  Kind = "load-balancer"
+)
+
+// Record is a resource created by a provisioning workflow. These are kept
+// after the resource is deleted so there's a history of what was built.
+type Record struct {
+	Kind      Kind
+	ID        string
+	ProjectID string
+	Name      string `json:",omitempty" yaml:",omitempty"`
+
+	Provider    providers.CloudProvider
+	Region      string
+	Owner       string `json:",omitempty" yaml:",omitempty"`
+	Environment string `json:",omitempty" yaml:",omitempty"`
+	// Kind-specific values, eg the address of a node
+	Details map[string]string `json:",omitempty" yaml:",omitempty"`
+
+	// The workflow which created the resource
+	WorkflowID string
+	RunID      string
+	CreatedAt  time.Time
+
+	// The workflow which deleted the resource
+	DeletedBy string     `json:",omitempty" yaml:",omitempty"`
+	DeletedAt *time.Time `json:",omitempty" yaml:",omitempty"`
+}
+
+// Deleted reports whether the resource has been deleted
+func (r *Record) Deleted() bool {
+	return r.DeletedAt != nil
+}
+
+// Deletion marks a resource as deleted
+type Deletion struct {
+	ID         string
+	WorkflowID string
+	DeletedAt  time.Time
+}
+
+// Filter limits the records that are listed. Empty fields match everything.
+type Filter struct {
+	Kind        Kind
+	ProjectID   string
+	WorkflowID  string
+	Provider    providers.CloudProvider
+	Region      string
+	Owner       string
+	Environment string
+	// Include the resources which have been deleted
+	Deleted bool
+}
+
+// Match reports whether the record passes the filter
+func (f Filter) Match(r *Record) bool {
+	checks := []struct {
+		Want string
+		Got  string
+	}{
+		{Want: string(f.Kind), Got: string(r.Kind)},
+		{Want: f.ProjectID, Got: r.ProjectID},
+		{Want: f.WorkflowID, Got: r.WorkflowID},
+		{Want: string(f.Provider), Got: string(r.Provider)},
+		{Want: f.Region, Got: r.Region},
+		{Want: f.Owner, Got: r.Owner},
+		{Want: f.Environment, Got: r.Environment},
+	}
+	for _, check := range checks {
+		if check.Want != "" && check.Want != check.Got {
+			return false
+		}
+	}
+
+	return f.Deleted || !r.Deleted()
+}
+
+// Store persists the inventory. Writes must be idempotent as they're made by
+// activities which may be retried.
+type Store interface {
+	// Put adds or replaces a record
+	Put(ctx context.Context, record *Record) error
+	// MarkDeleted records that a resource has been deleted
+	MarkDeleted(ctx context.Context, deletion Deletion) error
+	// Get a record by the resource ID
+	Get(ctx context.Context, id string) (*Record, error)
+	// List the records, oldest first
+	List(ctx context.Context, filter Filter) ([]*Record, error)
+}
+
+// DefaultFile is where the inventory is stored if no file is given
+func DefaultFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "temporal-provisioner", "inventory.db")
+}
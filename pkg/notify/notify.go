@@ -0,0 +1,177 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"gopkg.in/yaml.v3"
+)
+
+// EventType is the stage of the project's lifecycle that is being notified.
+// Projects don't have a lease, so there's no lease expiry event.
+type EventType string
+
+const (
+	EventStarted          EventType = "started"
+	EventSucceeded        EventType = "succeeded"
+	EventFailed           EventType = "failed"
+	EventApprovalRequired EventType = "approval-required"
+)
+
+// Event is sent to the notifiers
+type Event struct {
+	Type        EventType
+	Time        time.Time
+	Project     string
+	ProjectID   string `json:",omitempty"`
+	Provider    providers.CloudProvider
+	Region      string
+	Owner       string `json:",omitempty"`
+	Environment string `json:",omitempty"`
+	Actor       string
+	Nodes       int
+	MonthlyCost float64
+	Reasons     []string `json:",omitempty"`
+	Error       string   `json:",omitempty"`
+	WorkflowID  string
+	RunID       string
+	// Human-readable summary, rendered from the event's template
+	Message string
+}
+
+// Notifier tells someone about an event
+type Notifier interface {
+	Notify(ctx context.Context, event *Event) error
+}
+
+// Multi sends the event to every notifier, even if one fails
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, event *Event) error {
+	errs := make([]error, 0)
+	for _, n := range m {
+		// Each notifier renders its own message
+		e := *event
+		if err := n.Notify(ctx, &e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Templates are the Go templates used to render the message of each event type
+type Templates map[EventType]string
+
+// DefaultTemplates are used for any event type without a template
+var DefaultTemplates = Templates{
+	EventStarted: "Provisioning {{ .Project }} started by {{ .Actor }} - {{ .Nodes }} nodes in " +
+		`{{ .Provider }}/{{ .Region }} for ${{ printf "%.2f" .MonthlyCost }}/month`,
+	EventSucceeded:        "Provisioning {{ .Project }} completed - project {{ .ProjectID }}",
+	EventFailed:           "Provisioning {{ .Project }} failed: {{ .Error }}",
+	EventApprovalRequired: `Provisioning {{ .Project }} needs approval: {{ join .Reasons ", " }}`,
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// LoadTemplates reads the templates from a YAML file of event type to
+// template. Event types which aren't in the file use the default template.
+func LoadTemplates(file string) (Templates, error) {
+	//nolint:gosec // file is provided by the user
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading templates: %w", err)
+	}
+
+	templates := Templates{}
+	if err := yaml.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("error decoding templates: %w", err)
+	}
+
+	// Check the templates now rather than when an event is sent
+	for eventType, tmpl := range templates {
+		if _, err := template.New(string(eventType)).Funcs(templateFuncs).Parse(tmpl); err != nil {
+			return nil, fmt.Errorf("error parsing %s template: %w", eventType, err)
+		}
+	}
+
+	return templates, nil
+}
+
+// Render the event's message
+func (t Templates) Render(event *Event) (string, error) {
+	tmpl, ok := t[event.Type]
+	if !ok {
+		tmpl, ok = DefaultTemplates[event.Type]
+	}
+	if !ok {
+		return "", fmt.Errorf("no template for event %s", event.Type)
+	}
+
+	parsed, err := template.New(string(event.Type)).Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("error parsing %s template: %w", event.Type, err)
+	}
+
+	var buf bytes.Buffer
+	if err := parsed.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("error rendering %s template: %w", event.Type, err)
+	}
+	return buf.String(), nil
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Second * 10,
+	}
+}
+
+// POST the body, treating any non-2xx response as a failure
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending notification: %w", err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("notification endpoint returned status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/notify"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEvent(eventType notify.EventType) *notify.Event {
+	return &notify.Event{
+		Type:        eventType,
+		Time:        time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Project:     "some-project",
+		ProjectID:   "project-id",
+		Provider:    providers.CloudProviderAWS,
+		Region:      "eu-west-2",
+		Actor:       "alice",
+		Nodes:       3,
+		MonthlyCost: 123.456,
+		WorkflowID:  "workflow-id",
+		RunID:       "run-id",
+	}
+}
+
+func Test_TemplatesRender(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Templates notify.Templates
+		Event     func() *notify.Event
+		Expected  string
+		Err       bool
+	}{
+		{
+			Name:     "started",
+			Event:    func() *notify.Event { return newEvent(notify.EventStarted) },
+			Expected: "Provisioning some-project started by alice - 3 nodes in aws/eu-west-2 for $123.46/month",
+		},
+		{
+			Name:     "succeeded",
+			Event:    func() *notify.Event { return newEvent(notify.EventSucceeded) },
+			Expected: "Provisioning some-project completed - project project-id",
+		},
+		{
+			Name: "approval required",
+			Event: func() *notify.Event {
+				e := newEvent(notify.EventApprovalRequired)
+				e.Reasons = []string{"reason 1", "reason 2"}
+				return e
+			},
+			Expected: "Provisioning some-project needs approval: reason 1, reason 2",
+		},
+		{
+			Name:      "custom template",
+			Templates: notify.Templates{notify.EventFailed: "{{ .Project }} broke: {{ .Error }}"},
+			Event: func() *notify.Event {
+				e := newEvent(notify.EventFailed)
+				e.Error = "some error"
+				return e
+			},
+			Expected: "some-project broke: some error",
+		},
+		{
+			Name:  "unknown event",
+			Event: func() *notify.Event { return newEvent("unknown") },
+			Err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			msg, err := test.Templates.Render(test.Event())
+			if test.Err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, msg)
+		})
+	}
+}
+
+func Test_LoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	assert.NoError(t, os.WriteFile(valid, []byte("started: \"{{ .Project }} is starting\"\n"), 0o600))
+
+	templates, err := notify.LoadTemplates(valid)
+	assert.NoError(t, err)
+
+	msg, err := templates.Render(newEvent(notify.EventStarted))
+	assert.NoError(t, err)
+	assert.Equal(t, "some-project is starting", msg)
+
+	// Missing event types fall back to the defaults
+	_, err = templates.Render(newEvent(notify.EventFailed))
+	assert.NoError(t, err)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, os.WriteFile(invalid, []byte("started: \"{{ .Project \"\n"), 0o600))
+
+	_, err = notify.LoadTemplates(invalid)
+	assert.Error(t, err)
+}
+
+func Test_WebhookNotifier(t *testing.T) {
+	secret := []byte("some-secret")
+
+	var received notify.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		assert.NoError(t, notify.Verify(
+			secret,
+			r.Header.Get(notify.TimestampHeader),
+			r.Header.Get(notify.SignatureHeader),
+			body,
+			time.Minute,
+			time.Now(),
+		))
+		assert.Equal(t, string(notify.EventSucceeded), r.Header.Get(notify.EventHeader))
+		assert.NoError(t, json.Unmarshal(body, &received))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := notify.NewWebhookNotifier(server.URL, secret, nil)
+	assert.NoError(t, n.Notify(context.Background(), newEvent(notify.EventSucceeded)))
+	assert.Equal(t, "project-id", received.ProjectID)
+	assert.Equal(t, "Provisioning some-project completed - project project-id", received.Message)
+
+	// The signature changes with the secret
+	assert.NotEqual(t, notify.Sign(secret, "0", []byte("body")), notify.Sign([]byte("other-secret"), "0", []byte("body")))
+}
+
+func Test_Verify(t *testing.T) {
+	secret := []byte("some-secret")
+	body := []byte("body")
+	now := time.Unix(1735689600, 0)
+	timestamp := "1735689600"
+
+	tests := []struct {
+		Name      string
+		Timestamp string
+		Signature string
+		Now       time.Time
+		Err       bool
+	}{
+		{
+			Name:      "valid",
+			Timestamp: timestamp,
+			Signature: notify.Sign(secret, timestamp, body),
+			Now:       now.Add(time.Minute),
+		},
+		{
+			Name:      "replayed",
+			Timestamp: timestamp,
+			Signature: notify.Sign(secret, timestamp, body),
+			Now:       now.Add(time.Hour),
+			Err:       true,
+		},
+		{
+			Name:      "timestamp changed",
+			Timestamp: "1735693200",
+			Signature: notify.Sign(secret, timestamp, body),
+			Now:       now.Add(time.Hour),
+			Err:       true,
+		},
+		{
+			Name:      "wrong secret",
+			Timestamp: timestamp,
+			Signature: notify.Sign([]byte("other-secret"), timestamp, body),
+			Now:       now,
+			Err:       true,
+		},
+		{
+			Name:      "invalid timestamp",
+			Timestamp: "yesterday",
+			Signature: notify.Sign(secret, "yesterday", body),
+			Now:       now,
+			Err:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := notify.Verify(secret, test.Timestamp, test.Signature, body, 5*time.Minute, test.Now)
+			if test.Err {
+				assert.ErrorIs(t, err, notify.ErrInvalidSignature)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_SlackNotifier(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Status int
+		Err    bool
+	}{
+		{
+			Name:   "accepted",
+			Status: http.StatusOK,
+		},
+		{
+			Name:   "rejected",
+			Status: http.StatusBadRequest,
+			Err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var payload map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				w.WriteHeader(test.Status)
+			}))
+			defer server.Close()
+
+			e := newEvent(notify.EventFailed)
+			e.Error = "some error"
+
+			err := notify.NewSlackNotifier(server.URL, nil).Notify(context.Background(), e)
+			if test.Err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, map[string]any{"text": "Provisioning some-project failed: some error"}, payload)
+		})
+	}
+}
+
+type notifierFunc func(ctx context.Context, event *notify.Event) error
+
+func (f notifierFunc) Notify(ctx context.Context, event *notify.Event) error {
+	return f(ctx, event)
+}
+
+func Test_Multi(t *testing.T) {
+	called := 0
+	ok := notifierFunc(func(context.Context, *notify.Event) error {
+		called++
+		return nil
+	})
+	fail := notifierFunc(func(context.Context, *notify.Event) error {
+		called++
+		return errors.New("some error")
+	})
+
+	// Every notifier is called even if one fails
+	err := notify.Multi{fail, ok}.Notify(context.Background(), newEvent(notify.EventStarted))
+	assert.Error(t, err)
+	assert.Equal(t, 2, called)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SlackNotifier sends the message to a Slack-compatible incoming webhook
+type SlackNotifier struct {
+	URL       string
+	Templates Templates
+	Client    *http.Client
+}
+
+func NewSlackNotifier(url string, templates Templates) *SlackNotifier {
+	return &SlackNotifier{
+		URL:       url,
+		Templates: templates,
+		Client:    newHTTPClient(),
+	}
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, event *Event) error {
+	msg, err := s.Templates.Render(event)
+	if err != nil {
+		return err
+	}
+	event.Message = msg
+
+	body, err := json.Marshal(slackMessage{Text: msg})
+	if err != nil {
+		return fmt.Errorf("error encoding slack message: %w", err)
+	}
+
+	return post(ctx, s.Client, s.URL, body, nil)
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader is the HMAC-SHA256 of the timestamp and body, as sha256=<hex>
+	SignatureHeader = "X-Notification-Signature"
+	// TimestampHeader is when the notification was signed, in Unix seconds
+	TimestampHeader = "X-Notification-Timestamp"
+	// EventHeader is the event type, so receivers can route without parsing
+	EventHeader = "X-Notification-Event"
+)
+
+// WebhookNotifier POSTs the event as JSON. If there's a secret, the body is
+// signed so the receiver can check it came from us.
+type WebhookNotifier struct {
+	URL       string
+	Secret    []byte
+	Templates Templates
+	Client    *http.Client
+}
+
+func NewWebhookNotifier(url string, secret []byte, templates Templates) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:       url,
+		Secret:    secret,
+		Templates: templates,
+		Client:    newHTTPClient(),
+	}
+}
+
+// ErrInvalidSignature is returned when a notification's signature can't be
+// verified
+var ErrInvalidSignature = errors.New("invalid notification signature")
+
+// Sign the timestamp and body with the secret. The timestamp is included so a
+// receiver can reject old notifications that are sent again.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a notification received at now. This fails
+// if the notification was signed more than tolerance ago.
+func Verify(secret []byte, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: timestamp %q", ErrInvalidSignature, timestamp)
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrInvalidSignature, age)
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, event *Event) error {
+	msg, err := w.Templates.Render(event)
+	if err != nil {
+		return err
+	}
+	event.Message = msg
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %w", err)
+	}
+
+	headers := map[string]string{
+		EventHeader: string(event.Type),
+	}
+	if len(w.Secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[TimestampHeader] = timestamp
+		headers[SignatureHeader] = Sign(w.Secret, timestamp, body)
+	}
+
+	return post(ctx, w.Client, w.URL, body, headers)
+}
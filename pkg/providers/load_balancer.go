@@ -0,0 +1,202 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"time"
+)
+
+type LoadBalancerProtocol string
+
+const (
+	LoadBalancerProtocolTCP  LoadBalancerProtocol = "tcp"
+	LoadBalancerProtocolHTTP LoadBalancerProtocol = "http"
+)
+
+// LoadBalancer sends traffic to the ready nodes of a pool
+type LoadBalancer struct {
+	Name string `yaml:"name"`
+	Pool string `yaml:"pool"`
+	// Public load balancers are reachable from the internet. Defaults to private
+	Tier        SubnetTier  `yaml:"tier"`
+	Listeners   []Listener  `yaml:"listeners"`
+	HealthCheck HealthCheck `yaml:"healthCheck"`
+}
+
+// Listener accepts traffic on a port and forwards it to the nodes
+type Listener struct {
+	Protocol LoadBalancerProtocol `yaml:"protocol"`
+	Port     int32                `yaml:"port"`
+	// Defaults to the listener's port
+	TargetPort int32 `yaml:"targetPort"`
+}
+
+// HealthCheck decides whether a node is sent traffic. Empty fields are given
+// defaults by WithDefaults.
+type HealthCheck struct {
+	Protocol LoadBalancerProtocol `yaml:"protocol"`
+	Port     int32                `yaml:"port"`
+	// Only used by http health checks
+	Path               string        `yaml:"path"`
+	Interval           time.Duration `yaml:"interval"`
+	HealthyThreshold   int           `yaml:"healthyThreshold"`
+	UnhealthyThreshold int           `yaml:"unhealthyThreshold"`
+}
+
+type LoadBalancerResult struct {
+	ID          string
+	Name        string
+	Pool        string
+	Address     net.IP
+	Listeners   []Listener
+	HealthCheck HealthCheck
+	// IDs of the nodes the traffic is sent to
+	Targets []string
+}
+
+// WithDefaults fills in the target ports and health check
+func (lb LoadBalancer) WithDefaults() LoadBalancer {
+	listeners := make([]Listener, 0, len(lb.Listeners))
+	for _, l := range lb.Listeners {
+		if l.TargetPort == 0 {
+			l.TargetPort = l.Port
+		}
+		listeners = append(listeners, l)
+	}
+	lb.Listeners = listeners
+
+	hc := &lb.HealthCheck
+	if len(lb.Listeners) > 0 {
+		if hc.Protocol == "" {
+			hc.Protocol = lb.Listeners[0].Protocol
+		}
+		if hc.Port == 0 {
+			hc.Port = lb.Listeners[0].TargetPort
+		}
+	}
+	if hc.Protocol == LoadBalancerProtocolHTTP && hc.Path == "" {
+		hc.Path = "/"
+	}
+	if hc.Interval == 0 {
+		hc.Interval = time.Second * 30
+	}
+	if hc.HealthyThreshold == 0 {
+		hc.HealthyThreshold = 3
+	}
+	if hc.UnhealthyThreshold == 0 {
+		hc.UnhealthyThreshold = 3
+	}
+
+	return lb
+}
+
+func validPort(port int32) bool {
+	return port >= 1 && port <= 65535
+}
+
+func validLoadBalancerProtocol(protocol LoadBalancerProtocol) bool {
+	return protocol == LoadBalancerProtocolTCP || protocol == LoadBalancerProtocolHTTP
+}
+
+// SubnetTier returns the tier of the subnets the load balancer is put in
+func (lb LoadBalancer) SubnetTier() SubnetTier {
+	if lb.Tier != "" {
+		return lb.Tier
+	}
+	return DefaultSubnetTier
+}
+
+func (lb LoadBalancer) validate(tiers []SubnetTier, pools []NodePool) error {
+	if !slices.ContainsFunc(pools, func(p NodePool) bool { return p.Name == lb.Pool }) {
+		return fmt.Errorf("unknown pool %q", lb.Pool)
+	}
+
+	if len(tiers) == 0 {
+		tiers = []SubnetTier{DefaultSubnetTier}
+	}
+	if !slices.Contains(tiers, lb.SubnetTier()) {
+		return fmt.Errorf("uses subnet tier %s which is not created", lb.SubnetTier())
+	}
+
+	if len(lb.Listeners) == 0 {
+		return fmt.Errorf("at least one listener is required")
+	}
+	ports := map[int32]struct{}{}
+	for i, l := range lb.Listeners {
+		if !validLoadBalancerProtocol(l.Protocol) {
+			return fmt.Errorf("listener %d has unknown protocol %q", i, l.Protocol)
+		}
+		if !validPort(l.Port) || (l.TargetPort != 0 && !validPort(l.TargetPort)) {
+			return fmt.Errorf("listener %d has an invalid port", i)
+		}
+		if _, ok := ports[l.Port]; ok {
+			return fmt.Errorf("port %d has more than one listener", l.Port)
+		}
+		ports[l.Port] = struct{}{}
+	}
+
+	hc := lb.WithDefaults().HealthCheck
+	if !validLoadBalancerProtocol(hc.Protocol) {
+		return fmt.Errorf("health check has unknown protocol %q", hc.Protocol)
+	}
+	if !validPort(hc.Port) {
+		return fmt.Errorf("health check has an invalid port")
+	}
+	if hc.Protocol != LoadBalancerProtocolHTTP && hc.Path != "" {
+		return fmt.Errorf("health check path is only used by http")
+	}
+	if hc.Interval < time.Second || hc.HealthyThreshold < 1 || hc.UnhealthyThreshold < 1 {
+		return fmt.Errorf("health check interval must be at least 1s and thresholds at least 1")
+	}
+
+	return nil
+}
+
+// ValidateLoadBalancers checks the listeners and health checks are
+// well-formed and that every load balancer targets a pool and subnet tier
+// which exist
+func ValidateLoadBalancers(loadBalancers []LoadBalancer, tiers []SubnetTier, pools []NodePool) error {
+	names := map[string]struct{}{}
+	for i, lb := range loadBalancers {
+		if lb.Name == "" {
+			return fmt.Errorf("load balancer %d has no name", i)
+		}
+		if _, ok := names[lb.Name]; ok {
+			return fmt.Errorf("load balancer %s is declared more than once", lb.Name)
+		}
+		names[lb.Name] = struct{}{}
+
+		if err := lb.validate(tiers, pools); err != nil {
+			return fmt.Errorf("load balancer %s: %w", lb.Name, err)
+		}
+	}
+	return nil
+}
+
+// PoolNodes returns the nodes in the load balancer's pool
+func (lb LoadBalancerResult) PoolNodes(nodes []*NodeResult) []*NodeResult {
+	targets := make([]*NodeResult, 0)
+	for _, node := range nodes {
+		if node.Pool == lb.Pool {
+			targets = append(targets, node)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateLoadBalancers(t *testing.T) {
+	web := providers.LoadBalancer{
+		Name: "web",
+		Pool: "workers",
+		Listeners: []providers.Listener{
+			{Protocol: providers.LoadBalancerProtocolHTTP, Port: 80, TargetPort: 8080},
+		},
+	}
+	pools := []providers.NodePool{{Name: "workers"}}
+
+	tests := []struct {
+		Name          string
+		LoadBalancers []providers.LoadBalancer
+		Tiers         []providers.SubnetTier
+		Err           string
+	}{
+		{
+			Name:          "valid",
+			LoadBalancers: []providers.LoadBalancer{web},
+		},
+		{
+			Name: "valid tcp with public tier",
+			LoadBalancers: []providers.LoadBalancer{{
+				Name:        "api",
+				Pool:        "workers",
+				Tier:        providers.SubnetTierPublic,
+				Listeners:   []providers.Listener{{Protocol: providers.LoadBalancerProtocolTCP, Port: 6443}},
+				HealthCheck: providers.HealthCheck{Interval: time.Second * 10},
+			}},
+			Tiers: []providers.SubnetTier{providers.SubnetTierPublic, providers.SubnetTierPrivate},
+		},
+		{
+			Name:          "duplicate name",
+			LoadBalancers: []providers.LoadBalancer{web, web},
+			Err:           "load balancer web is declared more than once",
+		},
+		{
+			Name:          "unknown pool",
+			LoadBalancers: []providers.LoadBalancer{{Name: "web", Pool: "bastion", Listeners: web.Listeners}},
+			Err:           `load balancer web: unknown pool "bastion"`,
+		},
+		{
+			Name: "missing tier",
+			LoadBalancers: []providers.LoadBalancer{
+				{Name: "web", Pool: "workers", Tier: providers.SubnetTierPublic, Listeners: web.Listeners},
+			},
+			Err: "load balancer web: uses subnet tier public which is not created",
+		},
+		{
+			Name:          "no listeners",
+			LoadBalancers: []providers.LoadBalancer{{Name: "web", Pool: "workers"}},
+			Err:           "load balancer web: at least one listener is required",
+		},
+		{
+			Name: "invalid port",
+			LoadBalancers: []providers.LoadBalancer{{Name: "web", Pool: "workers", Listeners: []providers.Listener{
+				{Protocol: providers.LoadBalancerProtocolTCP, Port: 70000},
+			}}},
+			Err: "load balancer web: listener 0 has an invalid port",
+		},
+		{
+			Name: "duplicate listener port",
+			LoadBalancers: []providers.LoadBalancer{{Name: "web", Pool: "workers", Listeners: []providers.Listener{
+				{Protocol: providers.LoadBalancerProtocolTCP, Port: 80},
+				{Protocol: providers.LoadBalancerProtocolHTTP, Port: 80},
+			}}},
+			Err: "load balancer web: port 80 has more than one listener",
+		},
+		{
+			Name: "unknown protocol",
+			LoadBalancers: []providers.LoadBalancer{{Name: "web", Pool: "workers", Listeners: []providers.Listener{
+				{Protocol: "udp", Port: 53},
+			}}},
+			Err: `load balancer web: listener 0 has unknown protocol "udp"`,
+		},
+		{
+			Name: "path on tcp health check",
+			LoadBalancers: []providers.LoadBalancer{{
+				Name:        "web",
+				Pool:        "workers",
+				Listeners:   []providers.Listener{{Protocol: providers.LoadBalancerProtocolTCP, Port: 443}},
+				HealthCheck: providers.HealthCheck{Path: "/healthz"},
+			}},
+			Err: "load balancer web: health check path is only used by http",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := providers.ValidateLoadBalancers(test.LoadBalancers, test.Tiers, pools)
+			if test.Err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.Err)
+			}
+		})
+	}
+}
+
+func Test_LoadBalancerWithDefaults(t *testing.T) {
+	lb := providers.LoadBalancer{
+		Name: "web",
+		Pool: "workers",
+		Listeners: []providers.Listener{
+			{Protocol: providers.LoadBalancerProtocolHTTP, Port: 80, TargetPort: 8080},
+			{Protocol: providers.LoadBalancerProtocolTCP, Port: 443},
+		},
+	}
+
+	assert.Equal(t, providers.LoadBalancer{
+		Name: "web",
+		Pool: "workers",
+		Listeners: []providers.Listener{
+			{Protocol: providers.LoadBalancerProtocolHTTP, Port: 80, TargetPort: 8080},
+			{Protocol: providers.LoadBalancerProtocolTCP, Port: 443, TargetPort: 443},
+		},
+		HealthCheck: providers.HealthCheck{
+			Protocol:           providers.LoadBalancerProtocolHTTP,
+			Port:               8080,
+			Path:               "/",
+			Interval:           time.Second * 30,
+			HealthyThreshold:   3,
+			UnhealthyThreshold: 3,
+		},
+	}, lb.WithDefaults())
+
+	// The config isn't changed
+	assert.Equal(t, int32(0), lb.Listeners[1].TargetPort)
+}
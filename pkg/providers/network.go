@@ -0,0 +1,166 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/mrsimonemms/temporal/pkg/cidr"
+)
+
+// SubnetTier is whether the subnet can be reached from the internet
+type SubnetTier string
+
+const (
+	SubnetTierPublic  SubnetTier = "public"
+	SubnetTierPrivate SubnetTier = "private"
+)
+
+// DefaultSubnetTier is used when no tiers are configured and by the pools
+// which don't choose a tier
+const DefaultSubnetTier = SubnetTierPrivate
+
+// SubnetGrouping is what each tier's subnets are carved for
+type SubnetGrouping string
+
+const (
+	SubnetsPerZone SubnetGrouping = "zone"
+	SubnetsPerPool SubnetGrouping = "pool"
+)
+
+type PlannedSubnet struct {
+	Name string
+	Tier SubnetTier
+	// Set when the subnets are carved per zone
+	Zone string `json:",omitempty"`
+	// Set when the subnets are carved per pool
+	Pool string `json:",omitempty"`
+	CIDR string
+}
+
+type SubnetResult struct {
+	ID   string
+	Name string
+	Tier SubnetTier
+	Zone string `json:",omitempty"`
+	Pool string `json:",omitempty"`
+	CIDR *net.IPNet
+}
+
+// Tiers returns the subnet tiers to create
+func (c CloudConfig) Tiers() []SubnetTier {
+	if len(c.SubnetTiers) > 0 {
+		return c.SubnetTiers
+	}
+	return []SubnetTier{DefaultSubnetTier}
+}
+
+// SubnetTier returns the tier of the subnets the pool's nodes are put in
+func (p NodePool) SubnetTier() SubnetTier {
+	if p.Tier != "" {
+		return p.Tier
+	}
+	return DefaultSubnetTier
+}
+
+// ValidateSubnets checks the tiers and grouping are known and that every pool
+// uses a tier which exists
+func ValidateSubnets(grouping SubnetGrouping, tiers []SubnetTier, pools []NodePool) error {
+	switch grouping {
+	case "", SubnetsPerZone, SubnetsPerPool:
+	default:
+		return fmt.Errorf("unknown subnet grouping %q", grouping)
+	}
+
+	for i, tier := range tiers {
+		switch tier {
+		case SubnetTierPublic, SubnetTierPrivate:
+		default:
+			return fmt.Errorf("unknown subnet tier %q", tier)
+		}
+		if slices.Contains(tiers[:i], tier) {
+			return fmt.Errorf("subnet tier %s is declared more than once", tier)
+		}
+	}
+
+	if len(tiers) == 0 {
+		tiers = []SubnetTier{DefaultSubnetTier}
+	}
+	for _, pool := range pools {
+		if !slices.Contains(tiers, pool.SubnetTier()) {
+			return fmt.Errorf("pool %s uses subnet tier %s which is not created", pool.Name, pool.SubnetTier())
+		}
+	}
+
+	return nil
+}
+
+// PlanSubnets carves the network into equal subnets - one for each tier and
+// zone, or each tier and pool
+func PlanSubnets(network string, grouping SubnetGrouping, tiers []SubnetTier, zones []string, pools []NodePool) ([]PlannedSubnet, error) {
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cidr: %w", err)
+	}
+
+	subnets := make([]PlannedSubnet, 0)
+	for _, tier := range tiers {
+		if grouping == SubnetsPerPool {
+			for _, pool := range pools {
+				subnets = append(subnets, PlannedSubnet{
+					Name: fmt.Sprintf("%s-%s", tier, pool.Name),
+					Tier: tier,
+					Pool: pool.Name,
+				})
+			}
+			continue
+		}
+
+		for _, zone := range zones {
+			subnets = append(subnets, PlannedSubnet{
+				Name: fmt.Sprintf("%s-%s", tier, zone),
+				Tier: tier,
+				Zone: zone,
+			})
+		}
+	}
+
+	cidrs, err := cidr.Split(ipNet, len(subnets))
+	if err != nil {
+		return nil, fmt.Errorf("error carving subnets: %w", err)
+	}
+	for i := range subnets {
+		subnets[i].CIDR = cidrs[i].String()
+	}
+
+	return subnets, nil
+}
+
+// FindSubnet returns the planned subnet for a node in the pool and zone
+func FindSubnet(subnets []PlannedSubnet, grouping SubnetGrouping, pool NodePool, zone string) (string, bool) {
+	for _, s := range subnets {
+		if s.Tier != pool.SubnetTier() {
+			continue
+		}
+		if (grouping == SubnetsPerPool && s.Pool == pool.Name) || (grouping != SubnetsPerPool && s.Zone == zone) {
+			return s.Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PlanSubnets(t *testing.T) {
+	zones := []string{"eu-west-2a", "eu-west-2b", "eu-west-2c"}
+	pools := []providers.NodePool{
+		{Name: "web", Tier: providers.SubnetTierPublic},
+		{Name: "db"},
+	}
+	tiers := []providers.SubnetTier{providers.SubnetTierPublic, providers.SubnetTierPrivate}
+
+	tests := []struct {
+		Name     string
+		CIDR     string
+		Grouping providers.SubnetGrouping
+		Tiers    []providers.SubnetTier
+		Expected []providers.PlannedSubnet
+		Err      bool
+	}{
+		{
+			Name:  "per zone",
+			CIDR:  "10.0.0.0/16",
+			Tiers: tiers,
+			Expected: []providers.PlannedSubnet{
+				{Name: "public-eu-west-2a", Tier: providers.SubnetTierPublic, Zone: "eu-west-2a", CIDR: "10.0.0.0/19"},
+				{Name: "public-eu-west-2b", Tier: providers.SubnetTierPublic, Zone: "eu-west-2b", CIDR: "10.0.32.0/19"},
+				{Name: "public-eu-west-2c", Tier: providers.SubnetTierPublic, Zone: "eu-west-2c", CIDR: "10.0.64.0/19"},
+				{Name: "private-eu-west-2a", Tier: providers.SubnetTierPrivate, Zone: "eu-west-2a", CIDR: "10.0.96.0/19"},
+				{Name: "private-eu-west-2b", Tier: providers.SubnetTierPrivate, Zone: "eu-west-2b", CIDR: "10.0.128.0/19"},
+				{Name: "private-eu-west-2c", Tier: providers.SubnetTierPrivate, Zone: "eu-west-2c", CIDR: "10.0.160.0/19"},
+			},
+		},
+		{
+			Name:     "per pool",
+			CIDR:     "10.0.0.0/24",
+			Grouping: providers.SubnetsPerPool,
+			Tiers:    []providers.SubnetTier{providers.SubnetTierPrivate},
+			Expected: []providers.PlannedSubnet{
+				{Name: "private-web", Tier: providers.SubnetTierPrivate, Pool: "web", CIDR: "10.0.0.0/25"},
+				{Name: "private-db", Tier: providers.SubnetTierPrivate, Pool: "db", CIDR: "10.0.0.128/25"},
+			},
+		},
+		{
+			Name:  "too small",
+			CIDR:  "10.0.0.0/30",
+			Tiers: tiers,
+			Err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			subnets, err := providers.PlanSubnets(test.CIDR, test.Grouping, test.Tiers, zones, pools)
+			if test.Err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, subnets)
+		})
+	}
+
+	// The nodes land in their pool's tier
+	subnets, err := providers.PlanSubnets("10.0.0.0/16", providers.SubnetsPerZone, tiers, zones, pools)
+	assert.NoError(t, err)
+
+	name, ok := providers.FindSubnet(subnets, providers.SubnetsPerZone, pools[0], "eu-west-2b")
+	assert.True(t, ok)
+	assert.Equal(t, "public-eu-west-2b", name)
+
+	name, ok = providers.FindSubnet(subnets, providers.SubnetsPerZone, pools[1], "eu-west-2c")
+	assert.True(t, ok)
+	assert.Equal(t, "private-eu-west-2c", name)
+}
+
+func Test_ValidateSubnets(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Grouping providers.SubnetGrouping
+		Tiers    []providers.SubnetTier
+		Pools    []providers.NodePool
+		Err      string
+	}{
+		{
+			Name:  "defaults",
+			Pools: []providers.NodePool{{Name: "web"}},
+		},
+		{
+			Name:  "public pool without public tier",
+			Pools: []providers.NodePool{{Name: "web", Tier: providers.SubnetTierPublic}},
+			Err:   "pool web uses subnet tier public which is not created",
+		},
+		{
+			Name:     "unknown grouping",
+			Grouping: "rack",
+			Err:      `unknown subnet grouping "rack"`,
+		},
+		{
+			Name:  "unknown tier",
+			Tiers: []providers.SubnetTier{"dmz"},
+			Err:   `unknown subnet tier "dmz"`,
+		},
+		{
+			Name:  "duplicate tier",
+			Tiers: []providers.SubnetTier{providers.SubnetTierPublic, providers.SubnetTierPublic},
+			Err:   "subnet tier public is declared more than once",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := providers.ValidateSubnets(test.Grouping, test.Tiers, test.Pools)
+			if test.Err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.Err)
+		})
+	}
+}
+
+func Test_GenerateIPAddress(t *testing.T) {
+	tests := []struct {
+		Name string
+		CIDR string
+		Err  bool
+	}{
+		{
+			Name: "subnet",
+			CIDR: "10.0.1.0/24",
+		},
+		{
+			Name: "smallest",
+			CIDR: "10.0.1.8/29",
+		},
+		{
+			Name: "no free addresses",
+			CIDR: "10.0.1.8/30",
+			Err:  true,
+		},
+		{
+			Name: "ipv6",
+			CIDR: "fd00::/64",
+			Err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, subnet, err := net.ParseCIDR(test.CIDR)
+			assert.NoError(t, err)
+
+			for range 100 {
+				ip, err := providers.GenerateIPAddress(subnet)
+				if test.Err {
+					assert.Error(t, err)
+					return
+				}
+				assert.NoError(t, err)
+				assert.True(t, subnet.Contains(ip), "%s is not in %s", ip, subnet)
+
+				// The reserved addresses are never given out
+				offset := ip.To4()[3] - subnet.IP.To4()[3]
+				ones, bits := subnet.Mask.Size()
+				assert.GreaterOrEqual(t, int(offset), 4)
+				assert.Less(t, int(offset), 1<<(bits-ones)-1)
+			}
+		})
+	}
+
+	_, err := providers.GenerateIPAddress(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ErrPlanMismatch is returned when a saved plan doesn't match the plan
+// generated from its config
+var ErrPlanMismatch = errors.New("saved plan does not match config")
+
+// ApplySavedPlan checks a plan saved by the client against the plan the
+// worker generated from the same config. The resources must be the same, but
+// the generated node names are taken from the saved plan so the nodes are
+// called what was previewed. Everything else, including the cost, comes from
+// the worker's plan.
+func ApplySavedPlan(planned, saved *Plan) (*Plan, error) {
+	mismatch := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrPlanMismatch, fmt.Sprintf(format, args...))
+	}
+
+	if saved.Project.Name != planned.Project.Name {
+		return nil, mismatch("project is %s, expected %s", saved.Project.Name, planned.Project.Name)
+	}
+	if saved.Network.Region != planned.Network.Region || saved.Network.Subnet != planned.Network.Subnet {
+		return nil, mismatch("network is %s in %s, expected %s in %s",
+			saved.Network.Subnet, saved.Network.Region, planned.Network.Subnet, planned.Network.Region)
+	}
+	if !slices.Equal(saved.Network.Subnets, planned.Network.Subnets) {
+		return nil, mismatch("subnets differ")
+	}
+	if !reflect.DeepEqual(saved.SecurityGroups, planned.SecurityGroups) {
+		return nil, mismatch("security groups differ")
+	}
+	if !reflect.DeepEqual(saved.LoadBalancers, planned.LoadBalancers) {
+		return nil, mismatch("load balancers differ")
+	}
+	if len(saved.Nodes) != len(planned.Nodes) {
+		return nil, mismatch("%d nodes, expected %d", len(saved.Nodes), len(planned.Nodes))
+	}
+
+	result := *planned
+	result.Nodes = make([]*PlannedNode, 0, len(planned.Nodes))
+
+	names := map[string]struct{}{}
+	for i, node := range planned.Nodes {
+		s := saved.Nodes[i]
+
+		if s.Name == "" {
+			return nil, mismatch("node %d has no name", i)
+		}
+		if _, ok := names[s.Name]; ok {
+			return nil, mismatch("node %s is declared more than once", s.Name)
+		}
+		names[s.Name] = struct{}{}
+
+		if s.Pool != node.Pool ||
+			s.InstanceType != node.InstanceType ||
+			s.Zone != node.Zone ||
+			s.Subnet != node.Subnet ||
+			!slices.Equal(s.SecurityGroups, node.SecurityGroups) ||
+			!maps.Equal(s.Labels, node.Labels) {
+			return nil, mismatch("node %s differs from the config", s.Name)
+		}
+
+		n := *node
+		n.Name = s.Name
+		result.Nodes = append(result.Nodes, &n)
+	}
+
+	return &result, nil
+}
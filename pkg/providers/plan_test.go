@@ -0,0 +1,139 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers_test
+
+import (
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPlan(names ...string) *providers.Plan {
+	plan := &providers.Plan{
+		Project: providers.PlannedProject{Name: "some-project"},
+		Network: providers.PlannedNetwork{
+			Region: "eu-west-2",
+			Subnet: "10.0.0.0/24",
+			Subnets: []providers.PlannedSubnet{
+				{Name: "private-eu-west-2a", Tier: providers.SubnetTierPrivate, Zone: "eu-west-2a", CIDR: "10.0.0.0/25"},
+			},
+		},
+		SecurityGroups: []providers.SecurityGroup{{Name: "cluster"}},
+		HourlyCost:     0.1,
+		MonthlyCost:    73,
+	}
+	for _, name := range names {
+		plan.Nodes = append(plan.Nodes, &providers.PlannedNode{
+			Name:           name,
+			Pool:           providers.DefaultPoolName,
+			Labels:         map[string]string{"role": "web"},
+			SecurityGroups: []string{"cluster"},
+			Zone:           "eu-west-2a",
+			Subnet:         "private-eu-west-2a",
+			InstanceType:   "t3.medium",
+			HourlyCost:     0.05,
+			MonthlyCost:    36.5,
+		})
+	}
+	return plan
+}
+
+func Test_ApplySavedPlan(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Modify func(saved *providers.Plan)
+		Err    bool
+	}{
+		{
+			Name:   "matches",
+			Modify: func(saved *providers.Plan) {},
+		},
+		{
+			Name: "cost is ignored",
+			Modify: func(saved *providers.Plan) {
+				saved.MonthlyCost = 1
+				saved.Nodes[0].MonthlyCost = 0.5
+			},
+		},
+		{
+			Name: "extra node",
+			Modify: func(saved *providers.Plan) {
+				saved.Nodes = append(saved.Nodes, &providers.PlannedNode{Name: "extra"})
+			},
+			Err: true,
+		},
+		{
+			Name: "different instance type",
+			Modify: func(saved *providers.Plan) {
+				saved.Nodes[0].InstanceType = "p4d.24xlarge"
+			},
+			Err: true,
+		},
+		{
+			Name: "different labels",
+			Modify: func(saved *providers.Plan) {
+				saved.Nodes[1].Labels = map[string]string{"role": "db"}
+			},
+			Err: true,
+		},
+		{
+			Name: "duplicate name",
+			Modify: func(saved *providers.Plan) {
+				saved.Nodes[1].Name = saved.Nodes[0].Name
+			},
+			Err: true,
+		},
+		{
+			Name: "different network",
+			Modify: func(saved *providers.Plan) {
+				saved.Network.Subnet = "10.1.0.0/24"
+			},
+			Err: true,
+		},
+		{
+			Name: "different security groups",
+			Modify: func(saved *providers.Plan) {
+				saved.SecurityGroups = nil
+			},
+			Err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			planned := newPlan("worker-0", "worker-1")
+			saved := newPlan("saved-0", "saved-1")
+			test.Modify(saved)
+
+			plan, err := providers.ApplySavedPlan(planned, saved)
+			if test.Err {
+				assert.ErrorIs(err, providers.ErrPlanMismatch)
+				return
+			}
+
+			assert.NoError(err)
+
+			// The names are from the saved plan and the costs from the worker
+			expected := newPlan("saved-0", "saved-1")
+			assert.Equal(expected, plan)
+			assert.Equal("worker-0", planned.Nodes[0].Name)
+		})
+	}
+}
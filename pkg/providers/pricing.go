@@ -0,0 +1,165 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Region used when there is no regional price for an instance type
+const PricingAnyRegion = "*"
+
+//go:embed pricing.yaml
+var defaultPricing []byte
+
+// PricingCatalogue holds the hourly price of each instance type, keyed by
+// provider, region and instance type
+type PricingCatalogue map[CloudProvider]map[string]map[string]float64
+
+// Price returns the hourly price of the instance type
+func (p PricingCatalogue) Price(provider CloudProvider, region, instanceType string) (float64, error) {
+	regions, ok := p[provider]
+	if !ok {
+		return 0, fmt.Errorf("no pricing for provider: %s", provider)
+	}
+
+	for _, r := range []string{region, PricingAnyRegion} {
+		if price, ok := regions[r][instanceType]; ok {
+			return price, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no pricing for instance type %s in %s/%s", instanceType, provider, region)
+}
+
+// Estimate sets the cost of each node in the plan and the plan total
+func (p PricingCatalogue) Estimate(provider CloudProvider, plan *Plan) error {
+	plan.HourlyCost = 0
+	plan.MonthlyCost = 0
+
+	for _, node := range plan.Nodes {
+		price, err := p.Price(provider, plan.Network.Region, node.InstanceType)
+		if err != nil {
+			return err
+		}
+
+		node.HourlyCost = price
+		node.MonthlyCost = price * HoursPerMonth
+
+		plan.HourlyCost += node.HourlyCost
+		plan.MonthlyCost += node.MonthlyCost
+	}
+
+	return nil
+}
+
+// CostReport shows the spend of a running project
+type CostReport struct {
+	ProjectID   string
+	ProjectName string
+	HourlyCost  float64
+	MonthlyCost float64
+	AccruedCost float64
+	AsOf        time.Time
+	Nodes       []NodeCost
+}
+
+type NodeCost struct {
+	Name        string
+	HourlyCost  float64
+	CreatedAt   time.Time
+	DeletedAt   *time.Time
+	AccruedCost float64
+}
+
+// NewCostReport calculates the cost accrued by the project's nodes up to the
+// given time. Deleted nodes stop accruing when they were deleted and aren't
+// included in the hourly cost.
+func NewCostReport(project *ProjectResult, asOf time.Time) *CostReport {
+	report := &CostReport{
+		AsOf:  asOf,
+		Nodes: make([]NodeCost, 0),
+	}
+	if project == nil {
+		return report
+	}
+
+	report.ProjectID = project.ID
+	if project.Plan != nil {
+		report.ProjectName = project.Plan.Project.Name
+	}
+
+	for _, node := range project.Nodes {
+		cost := NodeCost{
+			Name:       node.Name,
+			HourlyCost: node.HourlyCost,
+			CreatedAt:  node.CreatedAt,
+			DeletedAt:  node.DeletedAt,
+		}
+
+		end := asOf
+		if node.DeletedAt != nil && node.DeletedAt.Before(end) {
+			end = *node.DeletedAt
+		}
+		if end.After(node.CreatedAt) {
+			cost.AccruedCost = end.Sub(node.CreatedAt).Hours() * node.HourlyCost
+		}
+
+		report.Nodes = append(report.Nodes, cost)
+		if node.DeletedAt == nil {
+			report.HourlyCost += cost.HourlyCost
+		}
+		report.AccruedCost += cost.AccruedCost
+	}
+	report.MonthlyCost = report.HourlyCost * HoursPerMonth
+
+	return report
+}
+
+// LoadPricingCatalogue reads a pricing catalogue from a YAML file
+func LoadPricingCatalogue(file string) (PricingCatalogue, error) {
+	//nolint:gosec // file is provided by the user
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pricing catalogue: %w", err)
+	}
+
+	return parsePricingCatalogue(data)
+}
+
+func parsePricingCatalogue(data []byte) (PricingCatalogue, error) {
+	var catalogue PricingCatalogue
+	if err := yaml.Unmarshal(data, &catalogue); err != nil {
+		return nil, fmt.Errorf("error decoding pricing catalogue: %w", err)
+	}
+	return catalogue, nil
+}
+
+// Pricing is the catalogue used to estimate costs. This can be replaced with
+// one loaded from a file.
+var Pricing = func() PricingCatalogue {
+	catalogue, err := parsePricingCatalogue(defaultPricing)
+	if err != nil {
+		panic(err)
+	}
+	return catalogue
+}()
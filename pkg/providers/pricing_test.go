@@ -0,0 +1,153 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PricingCatalogue(t *testing.T) {
+	catalogue := providers.PricingCatalogue{
+		providers.CloudProviderAWS: {
+			providers.PricingAnyRegion: {
+				"small": 1,
+				"large": 2,
+			},
+			"some-region": {
+				"small": 1.5,
+			},
+		},
+	}
+
+	tests := []struct {
+		Name         string
+		Provider     providers.CloudProvider
+		Region       string
+		InstanceType string
+		Price        float64
+		Err          bool
+	}{
+		{
+			Name:         "regional price",
+			Provider:     providers.CloudProviderAWS,
+			Region:       "some-region",
+			InstanceType: "small",
+			Price:        1.5,
+		},
+		{
+			Name:         "fallback price",
+			Provider:     providers.CloudProviderAWS,
+			Region:       "some-region",
+			InstanceType: "large",
+			Price:        2,
+		},
+		{
+			Name:         "unknown instance type",
+			Provider:     providers.CloudProviderAWS,
+			Region:       "some-region",
+			InstanceType: "huge",
+			Err:          true,
+		},
+		{
+			Name:         "unknown provider",
+			Provider:     "some-provider",
+			InstanceType: "small",
+			Err:          true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			price, err := catalogue.Price(test.Provider, test.Region, test.InstanceType)
+			if test.Err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.Price, price)
+		})
+	}
+}
+
+func Test_PricingCatalogueEstimate(t *testing.T) {
+	catalogue := providers.PricingCatalogue{
+		providers.CloudProviderAWS: {
+			providers.PricingAnyRegion: {
+				"small": 1,
+				"large": 2,
+			},
+		},
+	}
+
+	plan := &providers.Plan{
+		Nodes: []*providers.PlannedNode{
+			{InstanceType: "small"},
+			{InstanceType: "large"},
+		},
+	}
+
+	assert.NoError(t, catalogue.Estimate(providers.CloudProviderAWS, plan))
+	assert.Equal(t, float64(3), plan.HourlyCost)
+	assert.Equal(t, float64(3*providers.HoursPerMonth), plan.MonthlyCost)
+	assert.Equal(t, float64(2*providers.HoursPerMonth), plan.Nodes[1].MonthlyCost)
+}
+
+func Test_NewCostReport(t *testing.T) {
+	now := time.Now()
+
+	project := &providers.ProjectResult{
+		ID: "some-id",
+		Nodes: []*providers.NodeResult{
+			{Name: "node0", HourlyCost: 1, CreatedAt: now.Add(-time.Hour * 2)},
+			{Name: "node1", HourlyCost: 2, CreatedAt: now.Add(-time.Hour)},
+		},
+	}
+
+	report := providers.NewCostReport(project, now)
+
+	assert.Equal(t, "some-id", report.ProjectID)
+	assert.Equal(t, float64(3), report.HourlyCost)
+	assert.InDelta(t, 4, report.AccruedCost, 0.0001)
+	assert.Len(t, report.Nodes, 2)
+}
+
+func Test_NewCostReportDeleted(t *testing.T) {
+	now := time.Now()
+	deletedAt := now.Add(-time.Hour * 2)
+
+	project := &providers.ProjectResult{
+		ID: "some-id",
+		Nodes: []*providers.NodeResult{
+			{Name: "node0", HourlyCost: 1, CreatedAt: now.Add(-time.Hour * 3), DeletedAt: &deletedAt},
+			{Name: "node1", HourlyCost: 2, CreatedAt: now.Add(-time.Hour)},
+		},
+	}
+
+	// The deleted node stops accruing, however late the report is
+	for _, asOf := range []time.Time{now, now.Add(time.Hour * 24 * 365)} {
+		report := providers.NewCostReport(project, asOf)
+
+		assert.Equal(t, float64(2), report.HourlyCost)
+		assert.InDelta(t, 1, report.Nodes[0].AccruedCost, 0.0001)
+		assert.Equal(t, &deletedAt, report.Nodes[0].DeletedAt)
+	}
+}
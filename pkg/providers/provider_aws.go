@@ -21,13 +21,18 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"net"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/goombaio/namegenerator"
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 )
 
+// Number of availability zones to spread the nodes over
+const awsZoneCount = 3
+
 type aws struct {
 	cfg *CloudConfig
 }
@@ -49,6 +54,71 @@ func (a aws) CheckNodeReady(ctx context.Context, node *NodeResult) error {
 	return nil
 }
 
+// CheckTargetHealthy implements Provider.
+func (a aws) CheckTargetHealthy(ctx context.Context, lb *LoadBalancerResult, node *NodeResult) error {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate load balancer health check", "loadBalancerId", lb.ID, "nodeId", node.ID)
+	time.Sleep(time.Second * 2)
+
+	if !slices.Contains(lb.Targets, node.ID) {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("node %s is not a target of load balancer %s", node.ID, lb.ID),
+			"TargetNotRegistered",
+			nil,
+		)
+	}
+
+	if err := SimulateFailure(); err != nil {
+		return fmt.Errorf("simulated cloud failure: %w", err)
+	}
+	return nil
+}
+
+func (a aws) CreateLoadBalancer(ctx context.Context, network *NetworkResult, lb *LoadBalancer) (*LoadBalancerResult, error) {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate load balancer setup job", "name", lb.Name)
+	time.Sleep(time.Second * 5)
+
+	if err := SimulateFailure(); err != nil {
+		return nil, fmt.Errorf("simulated cloud failure: %w", err)
+	}
+
+	// The load balancer is put in the subnets of its tier
+	if len(network.Subnets) > 0 && !slices.ContainsFunc(network.Subnets, func(s *SubnetResult) bool {
+		return s.Tier == lb.SubnetTier()
+	}) {
+		return nil, fmt.Errorf("no %s subnets for load balancer %s", lb.SubnetTier(), lb.Name)
+	}
+
+	// The address is taken from the first subnet of the tier, or the network
+	// if it has no subnets
+	subnet := network.Subnet
+	if i := slices.IndexFunc(network.Subnets, func(s *SubnetResult) bool {
+		return s.Tier == lb.SubnetTier()
+	}); i >= 0 {
+		subnet = network.Subnets[i].CIDR
+	}
+
+	address, err := GenerateIPAddress(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating load balancer address: %w", err)
+	}
+
+	withDefaults := lb.WithDefaults()
+
+	return &LoadBalancerResult{
+		ID:          fmt.Sprintf("lb-%s", uuid.NewString()),
+		Name:        lb.Name,
+		Pool:        lb.Pool,
+		Address:     address,
+		Listeners:   withDefaults.Listeners,
+		HealthCheck: withDefaults.HealthCheck,
+		Targets:     []string{},
+	}, nil
+}
+
 func (a aws) CreateNetwork(ctx context.Context, project *ProjectResult) (*NetworkResult, error) {
 	logger := activity.GetLogger(ctx)
 
@@ -59,20 +129,45 @@ func (a aws) CreateNetwork(ctx context.Context, project *ProjectResult) (*Networ
 		return nil, fmt.Errorf("simulated cloud failure: %w", err)
 	}
 
-	_, subnet, err := net.ParseCIDR(project.Subnet)
+	cidr := project.Subnet
+	if project.Plan != nil {
+		cidr = project.Plan.Network.Subnet
+	}
+
+	_, subnet, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing cidr: %w", err)
 	}
 
+	subnets := make([]*SubnetResult, 0)
+	if project.Plan != nil {
+		for _, s := range project.Plan.Network.Subnets {
+			_, ipNet, err := net.ParseCIDR(s.CIDR)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing subnet cidr: %w", err)
+			}
+
+			subnets = append(subnets, &SubnetResult{
+				ID:   fmt.Sprintf("subnet-%s", uuid.NewString()),
+				Name: s.Name,
+				Tier: s.Tier,
+				Zone: s.Zone,
+				Pool: s.Pool,
+				CIDR: ipNet,
+			})
+		}
+	}
+
 	// These values may come from the project or from input variables
 	return &NetworkResult{
-		ID:     uuid.NewString(),
-		Region: a.cfg.Region,
-		Subnet: subnet,
+		ID:      uuid.NewString(),
+		Region:  a.cfg.Region,
+		Subnet:  subnet,
+		Subnets: subnets,
 	}, nil
 }
 
-func (a aws) CreateNode(ctx context.Context, project *ProjectResult) (*NodeResult, error) {
+func (a aws) CreateNode(ctx context.Context, project *ProjectResult, node *PlannedNode) (*NodeResult, error) {
 	logger := activity.GetLogger(ctx)
 
 	logger.Debug("Sleeping to simulate node setup job")
@@ -82,15 +177,57 @@ func (a aws) CreateNode(ctx context.Context, project *ProjectResult) (*NodeResul
 		return nil, fmt.Errorf("simulated cloud failure: %w", err)
 	}
 
-	// Generate a machine name - real service could be more descriptive (pets), entirely arbitrary (cattle) or from default provider's name
-	seed := time.Now().UTC().UnixNano()
-	generator := namegenerator.NewNameGenerator(seed)
+	// Attach the node to the groups that have been created for it
+	groups := make([]string, 0, len(node.SecurityGroups))
+	for _, name := range node.SecurityGroups {
+		i := slices.IndexFunc(project.SecurityGroups, func(g *SecurityGroupResult) bool {
+			return g.Name == name
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("security group %s has not been created", name)
+		}
+		groups = append(groups, project.SecurityGroups[i].ID)
+	}
+
+	// The address is taken from the node's subnet, or the network if it's
+	// not put in a subnet
+	var subnetID string
+	var subnet *net.IPNet
+	if project.Network != nil {
+		subnet = project.Network.Subnet
+	}
+	if node.Subnet != "" {
+		i := -1
+		if project.Network != nil {
+			i = slices.IndexFunc(project.Network.Subnets, func(s *SubnetResult) bool {
+				return s.Name == node.Subnet
+			})
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("subnet %s has not been created", node.Subnet)
+		}
+		subnetID = project.Network.Subnets[i].ID
+		subnet = project.Network.Subnets[i].CIDR
+	}
+
+	address, err := GenerateIPAddress(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating node address: %w", err)
+	}
 
 	return &NodeResult{
-		ID:      uuid.NewString(),
-		Name:    generator.Generate(),
-		Address: GenerateIPAddress(),
-		Port:    22,
+		ID:             uuid.NewString(),
+		Name:           node.Name,
+		Pool:           node.Pool,
+		Labels:         node.Labels,
+		SecurityGroups: groups,
+		Zone:           node.Zone,
+		Subnet:         subnetID,
+		InstanceType:   node.InstanceType,
+		Address:        address,
+		Port:           22,
+		HourlyCost:     node.HourlyCost,
+		CreatedAt:      time.Now().UTC(),
 	}, nil
 }
 
@@ -111,6 +248,207 @@ func (a aws) CreateProject(ctx context.Context) (*ProjectResult, error) {
 	}, nil
 }
 
+func (a aws) CreateSecurityGroup(ctx context.Context, network *NetworkResult, group *SecurityGroup) (*SecurityGroupResult, error) {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate security group setup job", "name", group.Name)
+	time.Sleep(time.Second * 2)
+
+	if err := SimulateFailure(); err != nil {
+		return nil, fmt.Errorf("simulated cloud failure: %w", err)
+	}
+
+	return &SecurityGroupResult{
+		ID:        fmt.Sprintf("sg-%s", uuid.NewString()),
+		Name:      group.Name,
+		NetworkID: network.ID,
+		Rules:     group.Rules,
+	}, nil
+}
+
+// The deletions are idempotent - deleting a resource which doesn't exist is
+// not an error
+
+func (a aws) DeleteLoadBalancer(ctx context.Context, lb *LoadBalancerResult) error {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate load balancer deletion job", "loadBalancerId", lb.ID)
+	time.Sleep(time.Second * 2)
+
+	if err := SimulateFailure(); err != nil {
+		return fmt.Errorf("simulated cloud failure: %w", err)
+	}
+	return nil
+}
+
+func (a aws) DeleteNetwork(ctx context.Context, network *NetworkResult) error {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate network deletion job", "networkId", network.ID)
+	time.Sleep(time.Second * 2)
+
+	if err := SimulateFailure(); err != nil {
+		return fmt.Errorf("simulated cloud failure: %w", err)
+	}
+	return nil
+}
+
+func (a aws) DeleteNode(ctx context.Context, node *NodeResult) error {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate node deletion job", "nodeId", node.ID)
+	time.Sleep(time.Second * 2)
+
+	if err := SimulateFailure(); err != nil {
+		return fmt.Errorf("simulated cloud failure: %w", err)
+	}
+	return nil
+}
+
+func (a aws) DeleteProject(ctx context.Context, project *ProjectResult) error {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate project deletion job", "projectId", project.ID)
+	time.Sleep(time.Second)
+
+	if err := SimulateFailure(); err != nil {
+		return fmt.Errorf("simulated cloud failure: %w", err)
+	}
+	return nil
+}
+
+func (a aws) DeleteSecurityGroup(ctx context.Context, group *SecurityGroupResult) error {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate security group deletion job", "securityGroupId", group.ID)
+	time.Sleep(time.Second)
+
+	if err := SimulateFailure(); err != nil {
+		return fmt.Errorf("simulated cloud failure: %w", err)
+	}
+	return nil
+}
+
+// Plan calculates the resources to be created. This must not make any changes
+// to the cloud account.
+func (a aws) Plan(ctx context.Context) (*Plan, error) {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Planning resources")
+
+	_, subnet, err := net.ParseCIDR(a.cfg.Subnet)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cidr: %w", err)
+	}
+
+	// The rules would be rejected by the provider, so retrying won't help
+	if err := ValidateSecurityGroups(a.cfg.SecurityGroups, a.cfg.NodePools()); err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidSecurityGroups", err)
+	}
+	if err := ValidateLoadBalancers(a.cfg.LoadBalancers, a.cfg.Tiers(), a.cfg.NodePools()); err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidLoadBalancers", err)
+	}
+
+	zones := make([]string, 0, awsZoneCount)
+	for i := range awsZoneCount {
+		zones = append(zones, fmt.Sprintf("%s%c", a.cfg.Region, 'a'+rune(i)))
+	}
+
+	subnets, err := a.planSubnets(subnet.String(), zones)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate machine names - real service could be more descriptive (pets), entirely arbitrary (cattle) or from default provider's name
+	seed := time.Now().UTC().UnixNano()
+	generator := namegenerator.NewNameGenerator(seed)
+
+	name := a.cfg.Name
+	if name == "" {
+		name = generator.Generate()
+	}
+
+	plan := &Plan{
+		Project: PlannedProject{
+			Name: name,
+		},
+		Network: PlannedNetwork{
+			Region:  a.cfg.Region,
+			Subnet:  subnet.String(),
+			Subnets: subnets,
+		},
+		SecurityGroups: a.cfg.SecurityGroups,
+		Nodes:          make([]*PlannedNode, 0, a.cfg.NodeCount()),
+		LoadBalancers:  a.cfg.LoadBalancers,
+	}
+
+	names := map[string]struct{}{}
+	for _, pool := range a.cfg.NodePools() {
+		for range pool.Count {
+			// Node names must be unique within the project
+			nodeName := generator.Generate()
+			for _, exists := names[nodeName]; exists; _, exists = names[nodeName] {
+				nodeName = generator.Generate()
+			}
+			names[nodeName] = struct{}{}
+
+			// Spread all the nodes over the zones, regardless of pool
+			zone := zones[len(plan.Nodes)%len(zones)]
+			subnetName, _ := FindSubnet(subnets, a.cfg.SubnetsPer, pool, zone)
+
+			plan.Nodes = append(plan.Nodes, &PlannedNode{
+				Name:           nodeName,
+				Pool:           pool.Name,
+				Labels:         pool.Labels,
+				SecurityGroups: pool.SecurityGroups,
+				Zone:           zone,
+				Subnet:         subnetName,
+				InstanceType:   pool.InstanceType,
+			})
+		}
+	}
+
+	if err := Pricing.Estimate(CloudProviderAWS, plan); err != nil {
+		return nil, fmt.Errorf("error estimating cost: %w", err)
+	}
+
+	return plan, nil
+}
+
+// SetLoadBalancerTargets is idempotent - the targets are replaced with the
+// nodes given
+func (a aws) SetLoadBalancerTargets(ctx context.Context, lb *LoadBalancerResult, nodes []*NodeResult) (*LoadBalancerResult, error) {
+	logger := activity.GetLogger(ctx)
+
+	logger.Debug("Sleeping to simulate load balancer target registration", "loadBalancerId", lb.ID, "targets", len(nodes))
+	time.Sleep(time.Second)
+
+	if err := SimulateFailure(); err != nil {
+		return nil, fmt.Errorf("simulated cloud failure: %w", err)
+	}
+
+	result := *lb
+	result.Targets = make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		result.Targets = append(result.Targets, node.ID)
+	}
+	return &result, nil
+}
+
+// Carve the network into the subnets. A layout which can't be carved would be
+// rejected by the provider, so retrying won't help.
+func (a aws) planSubnets(network string, zones []string) ([]PlannedSubnet, error) {
+	if err := ValidateSubnets(a.cfg.SubnetsPer, a.cfg.SubnetTiers, a.cfg.NodePools()); err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidSubnets", err)
+	}
+
+	subnets, err := PlanSubnets(network, a.cfg.SubnetsPer, a.cfg.Tiers(), zones, a.cfg.NodePools())
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidSubnets", err)
+	}
+	return subnets, nil
+}
+
 func NewAWS(cfg *CloudConfig) (Provider, error) {
 	return aws{
 		cfg: cfg,
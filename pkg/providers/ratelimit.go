@@ -0,0 +1,104 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitError is returned when the provider is throttling requests
+type RateLimitError struct {
+	// How long the provider asked us to wait before trying again
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited by provider - retry after %s", e.RetryAfter)
+}
+
+// RateLimiter is a token bucket for each provider and region. This is local to
+// the worker process, so N workers serving the same provider and region allow
+// N times the rate. The limit across every worker is the provider task queue's
+// TaskQueueActivitiesPerSecond, which is enforced by the Temporal server.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	overrides map[string]rate.Limit
+	limiters  map[string]*rate.Limiter
+}
+
+// Rate limits are keyed as "<provider>/<region>"
+func RateLimitKey(provider CloudProvider, region string) string {
+	return fmt.Sprintf("%s/%s", provider, region)
+}
+
+// NewRateLimiter sets the default requests per second for each provider and
+// region, with any overrides keyed by RateLimitKey. Zero is unlimited.
+func NewRateLimiter(perSecond float64, burst int, overrides map[string]float64) *RateLimiter {
+	r := &RateLimiter{
+		limit:     toLimit(perSecond),
+		burst:     max(burst, 1),
+		overrides: map[string]rate.Limit{},
+		limiters:  map[string]*rate.Limiter{},
+	}
+
+	for key, perSecond := range overrides {
+		r.overrides[key] = toLimit(perSecond)
+	}
+
+	return r
+}
+
+func toLimit(perSecond float64) rate.Limit {
+	if perSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(perSecond)
+}
+
+// Wait blocks until the provider and region can receive another request
+func (r *RateLimiter) Wait(ctx context.Context, provider CloudProvider, region string) error {
+	return r.get(RateLimitKey(provider, region)).Wait(ctx)
+}
+
+func (r *RateLimiter) get(key string) *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.limiters[key]
+	if !ok {
+		limit, ok := r.overrides[key]
+		if !ok {
+			limit = r.limit
+		}
+
+		l = rate.NewLimiter(limit, r.burst)
+		r.limiters[key] = l
+	}
+
+	return l
+}
+
+// Limiter is used before every call to a provider by this worker. This is
+// unlimited unless replaced when the worker starts.
+var Limiter = NewRateLimiter(0, 1, nil)
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RateLimiter(t *testing.T) {
+	limiter := providers.NewRateLimiter(1, 1, map[string]float64{
+		providers.RateLimitKey(providers.CloudProviderAWS, "unlimited"): 0,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	// The first request uses the burst
+	assert.NoError(t, limiter.Wait(ctx, providers.CloudProviderAWS, "limited"))
+
+	// Each region has its own bucket
+	assert.NoError(t, limiter.Wait(ctx, providers.CloudProviderAWS, "other"))
+
+	// The second request would need to wait longer than the context allows
+	assert.Error(t, limiter.Wait(ctx, providers.CloudProviderAWS, "limited"))
+
+	// Overrides are respected
+	for range 10 {
+		assert.NoError(t, limiter.Wait(ctx, providers.CloudProviderAWS, "unlimited"))
+	}
+}
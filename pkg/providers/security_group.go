@@ -0,0 +1,257 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type RuleDirection string
+
+const (
+	RuleDirectionIngress RuleDirection = "ingress"
+	RuleDirectionEgress  RuleDirection = "egress"
+)
+
+type RuleProtocol string
+
+const (
+	RuleProtocolAll  RuleProtocol = "all"
+	RuleProtocolICMP RuleProtocol = "icmp"
+	RuleProtocolTCP  RuleProtocol = "tcp"
+	RuleProtocolUDP  RuleProtocol = "udp"
+)
+
+type RuleAction string
+
+const (
+	RuleActionAllow RuleAction = "allow"
+	RuleActionDeny  RuleAction = "deny"
+)
+
+// SecurityGroup is a set of firewall rules which is attached to the nodes of
+// the pools that reference it
+type SecurityGroup struct {
+	Name  string         `yaml:"name"`
+	Rules []SecurityRule `yaml:"rules"`
+}
+
+// SecurityRule allows or denies traffic to or from a peer. The peer is exactly
+// one of a CIDR, the project's subnet or the nodes in another security group.
+type SecurityRule struct {
+	Direction RuleDirection `yaml:"direction"`
+	// Defaults to allow
+	Action   RuleAction   `yaml:"action"`
+	Protocol RuleProtocol `yaml:"protocol"`
+	// A single port or a range, eg 22 or 30000-32767. Only used by tcp and udp
+	Ports  string `yaml:"ports"`
+	CIDR   string `yaml:"cidr"`
+	Subnet bool   `yaml:"subnet"`
+	Group  string `yaml:"group"`
+}
+
+type SecurityGroupResult struct {
+	ID        string
+	Name      string
+	NetworkID string
+	Rules     []SecurityRule
+}
+
+// PortRange returns the first and last port of the rule. Protocols without
+// ports cover every port.
+func (r SecurityRule) PortRange() (from, to int, err error) {
+	if r.Protocol != RuleProtocolTCP && r.Protocol != RuleProtocolUDP {
+		if r.Ports != "" {
+			return 0, 0, fmt.Errorf("ports cannot be set for protocol %s", r.Protocol)
+		}
+		return 0, 65535, nil
+	}
+
+	if r.Ports == "" {
+		return 0, 0, fmt.Errorf("ports are required for protocol %s", r.Protocol)
+	}
+
+	first, last, isRange := strings.Cut(r.Ports, "-")
+	if from, err = parsePort(first); err != nil {
+		return 0, 0, err
+	}
+	to = from
+	if isRange {
+		if to, err = parsePort(last); err != nil {
+			return 0, 0, err
+		}
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("port range %s is backwards", r.Ports)
+	}
+	return from, to, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// The peer as a comparable string
+func (r SecurityRule) peer() (string, error) {
+	peers := make([]string, 0, 1)
+	if r.CIDR != "" {
+		_, ipNet, err := net.ParseCIDR(r.CIDR)
+		if err != nil {
+			return "", fmt.Errorf("invalid cidr %s: %w", r.CIDR, err)
+		}
+		peers = append(peers, "cidr:"+ipNet.String())
+	}
+	if r.Subnet {
+		peers = append(peers, "subnet")
+	}
+	if r.Group != "" {
+		peers = append(peers, "group:"+r.Group)
+	}
+
+	if len(peers) != 1 {
+		return "", fmt.Errorf("exactly one of cidr, subnet or group must be set")
+	}
+	return peers[0], nil
+}
+
+func (r SecurityRule) validate(groups map[string]struct{}) error {
+	switch r.Direction {
+	case RuleDirectionIngress, RuleDirectionEgress:
+	default:
+		return fmt.Errorf("unknown direction %q", r.Direction)
+	}
+
+	switch r.Action {
+	case "", RuleActionAllow, RuleActionDeny:
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+
+	switch r.Protocol {
+	case RuleProtocolAll, RuleProtocolICMP, RuleProtocolTCP, RuleProtocolUDP:
+	default:
+		return fmt.Errorf("unknown protocol %q", r.Protocol)
+	}
+
+	if _, _, err := r.PortRange(); err != nil {
+		return err
+	}
+
+	if _, err := r.peer(); err != nil {
+		return err
+	}
+	if _, ok := groups[r.Group]; r.Group != "" && !ok {
+		return fmt.Errorf("unknown security group %s", r.Group)
+	}
+	return nil
+}
+
+// Two rules conflict if they contradict each other - one allows and the other
+// denies the same protocol and ports and the allowed peer falls entirely within
+// the denied one, so the allow can never match. A deny which carves a narrower
+// peer out of an allow is fine. Both rules must be valid.
+func (r SecurityRule) conflicts(other SecurityRule) bool {
+	allow, deny := r, other
+	if allow.action() == RuleActionDeny {
+		allow, deny = deny, allow
+	}
+	if allow.action() != RuleActionAllow || deny.action() != RuleActionDeny {
+		return false
+	}
+
+	if r.Direction != other.Direction || r.Protocol != other.Protocol {
+		return false
+	}
+
+	from, to, _ := r.PortRange()
+	otherFrom, otherTo, _ := other.PortRange()
+	if from != otherFrom || to != otherTo {
+		return false
+	}
+
+	return allow.peerWithin(deny)
+}
+
+// The action, defaulting to allow
+func (r SecurityRule) action() RuleAction {
+	if r.Action == "" {
+		return RuleActionAllow
+	}
+	return r.Action
+}
+
+// Whether every address in the rule's peer is also in the other rule's peer.
+// Subnets and groups only match themselves. Both rules must be valid.
+func (r SecurityRule) peerWithin(other SecurityRule) bool {
+	if r.CIDR == "" || other.CIDR == "" {
+		peer, _ := r.peer()
+		otherPeer, _ := other.peer()
+		return peer == otherPeer
+	}
+
+	_, ipNet, _ := net.ParseCIDR(r.CIDR)
+	_, otherNet, _ := net.ParseCIDR(other.CIDR)
+	ones, bits := ipNet.Mask.Size()
+	otherOnes, otherBits := otherNet.Mask.Size()
+	return bits == otherBits && otherOnes <= ones && otherNet.Contains(ipNet.IP)
+}
+
+// ValidateSecurityGroups checks the rules are well-formed, that no two rules
+// in a group conflict and that the pools only use groups which exist
+func ValidateSecurityGroups(groups []SecurityGroup, pools []NodePool) error {
+	names := map[string]struct{}{}
+	for i, group := range groups {
+		if group.Name == "" {
+			return fmt.Errorf("security group %d has no name", i)
+		}
+		if _, ok := names[group.Name]; ok {
+			return fmt.Errorf("security group %s is declared more than once", group.Name)
+		}
+		names[group.Name] = struct{}{}
+	}
+
+	for _, group := range groups {
+		for i, rule := range group.Rules {
+			if err := rule.validate(names); err != nil {
+				return fmt.Errorf("security group %s rule %d: %w", group.Name, i, err)
+			}
+
+			for j, other := range group.Rules[:i] {
+				if rule.conflicts(other) {
+					return fmt.Errorf("security group %s rule %d conflicts with rule %d", group.Name, i, j)
+				}
+			}
+		}
+	}
+
+	for _, pool := range pools {
+		for _, name := range pool.SecurityGroups {
+			if _, ok := names[name]; !ok {
+				return fmt.Errorf("pool %s uses unknown security group %s", pool.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers_test
+
+import (
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateSecurityGroups(t *testing.T) {
+	ssh := providers.SecurityRule{
+		Direction: providers.RuleDirectionIngress,
+		Protocol:  providers.RuleProtocolTCP,
+		Ports:     "22",
+		Group:     "bastion",
+	}
+	api := providers.SecurityRule{
+		Direction: providers.RuleDirectionIngress,
+		Protocol:  providers.RuleProtocolTCP,
+		Ports:     "6443",
+		Subnet:    true,
+	}
+	bastion := providers.SecurityGroup{
+		Name: "bastion",
+		Rules: []providers.SecurityRule{
+			{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "22", CIDR: "0.0.0.0/0"},
+		},
+	}
+
+	tests := []struct {
+		Name   string
+		Groups []providers.SecurityGroup
+		Pools  []providers.NodePool
+		Err    string
+	}{
+		{
+			Name: "valid",
+			Groups: []providers.SecurityGroup{
+				bastion,
+				{
+					Name: "cluster",
+					Rules: []providers.SecurityRule{
+						ssh,
+						api,
+						{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolUDP, Ports: "30000-32767", Subnet: true},
+						{Direction: providers.RuleDirectionEgress, Protocol: providers.RuleProtocolAll, CIDR: "0.0.0.0/0"},
+					},
+				},
+			},
+			Pools: []providers.NodePool{
+				{Name: "bastion", SecurityGroups: []string{"bastion"}},
+				{Name: "workers", SecurityGroups: []string{"cluster"}},
+			},
+		},
+		{
+			Name:   "duplicate group",
+			Groups: []providers.SecurityGroup{bastion, bastion},
+			Err:    "security group bastion is declared more than once",
+		},
+		{
+			Name:   "unknown peer group",
+			Groups: []providers.SecurityGroup{{Name: "cluster", Rules: []providers.SecurityRule{ssh}}},
+			Err:    "security group cluster rule 0: unknown security group bastion",
+		},
+		{
+			Name:   "unknown pool group",
+			Groups: []providers.SecurityGroup{bastion},
+			Pools:  []providers.NodePool{{Name: "workers", SecurityGroups: []string{"cluster"}}},
+			Err:    "pool workers uses unknown security group cluster",
+		},
+		{
+			Name: "invalid direction",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: "sideways", Protocol: providers.RuleProtocolTCP, Ports: "22", Subnet: true},
+			}}},
+			Err: `security group sg rule 0: unknown direction "sideways"`,
+		},
+		{
+			Name: "invalid port",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "70000", Subnet: true},
+			}}},
+			Err: `security group sg rule 0: invalid port "70000"`,
+		},
+		{
+			Name: "backwards range",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "443-80", Subnet: true},
+			}}},
+			Err: "security group sg rule 0: port range 443-80 is backwards",
+		},
+		{
+			Name: "ports on icmp",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolICMP, Ports: "22", Subnet: true},
+			}}},
+			Err: "security group sg rule 0: ports cannot be set for protocol icmp",
+		},
+		{
+			Name: "invalid cidr",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "22", CIDR: "10.0.0.0/33"},
+			}}},
+			Err: "security group sg rule 0: invalid cidr 10.0.0.0/33",
+		},
+		{
+			Name: "multiple peers",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "22", CIDR: "10.0.0.0/8", Subnet: true},
+			}}},
+			Err: "security group sg rule 0: exactly one of cidr, subnet or group must be set",
+		},
+		{
+			Name: "contradicting allow and deny",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				api,
+				{
+					Direction: providers.RuleDirectionIngress,
+					Action:    providers.RuleActionDeny,
+					Protocol:  providers.RuleProtocolTCP,
+					Ports:     "6443",
+					Subnet:    true,
+				},
+			}}},
+			Err: "security group sg rule 1 conflicts with rule 0",
+		},
+		{
+			Name: "allow within a denied cidr",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: providers.RuleDirectionIngress, Action: providers.RuleActionDeny, Protocol: providers.RuleProtocolTCP, Ports: "22", CIDR: "10.0.0.0/8"},
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "22", CIDR: "10.1.0.0/16"},
+			}}},
+			Err: "security group sg rule 1 conflicts with rule 0",
+		},
+		{
+			Name: "narrower deny within an allowed cidr",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "22", CIDR: "10.0.0.0/8"},
+				{Direction: providers.RuleDirectionIngress, Action: providers.RuleActionDeny, Protocol: providers.RuleProtocolTCP, Ports: "22", CIDR: "10.1.2.3/32"},
+			}}},
+		},
+		{
+			Name: "deny on overlapping ports",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				api,
+				{
+					Direction: providers.RuleDirectionIngress,
+					Action:    providers.RuleActionDeny,
+					Protocol:  providers.RuleProtocolTCP,
+					Ports:     "6000-7000",
+					Subnet:    true,
+				},
+			}}},
+		},
+		{
+			Name: "overlapping allows",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				api,
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolAll, Subnet: true},
+			}}},
+		},
+		{
+			Name: "same ports different peers",
+			Groups: []providers.SecurityGroup{{Name: "sg", Rules: []providers.SecurityRule{
+				api,
+				{Direction: providers.RuleDirectionIngress, Protocol: providers.RuleProtocolTCP, Ports: "6443", CIDR: "10.1.0.0/16"},
+			}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := providers.ValidateSecurityGroups(test.Groups, test.Pools)
+			if test.Err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, test.Err)
+		})
+	}
+}
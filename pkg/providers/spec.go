@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes the shape of a project in more detail than the flags allow
+type Spec struct {
+	Network        SpecNetwork     `yaml:"network"`
+	Pools          []NodePool      `yaml:"pools"`
+	SecurityGroups []SecurityGroup `yaml:"securityGroups"`
+	LoadBalancers  []LoadBalancer  `yaml:"loadBalancers"`
+}
+
+// SpecNetwork describes how the network is carved into subnets
+type SpecNetwork struct {
+	// Replaces --subnet
+	CIDR       string         `yaml:"cidr"`
+	SubnetsPer SubnetGrouping `yaml:"subnetsPer"`
+	Tiers      []SubnetTier   `yaml:"tiers"`
+}
+
+// LoadSpec reads a spec from a YAML file
+func LoadSpec(file string) (*Spec, error) {
+	//nolint:gosec // file is provided by the user
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading spec: %w", err)
+	}
+
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("error decoding spec: %w", err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+func (s *Spec) Validate() error {
+	names := map[string]struct{}{}
+	for i, pool := range s.Pools {
+		if pool.Name == "" {
+			return fmt.Errorf("pool %d has no name", i)
+		}
+		if _, ok := names[pool.Name]; ok {
+			return fmt.Errorf("pool %s is declared more than once", pool.Name)
+		}
+		names[pool.Name] = struct{}{}
+
+		if pool.Count < 1 {
+			return fmt.Errorf("pool %s must have at least one node", pool.Name)
+		}
+	}
+
+	if s.Network.CIDR != "" {
+		if _, _, err := net.ParseCIDR(s.Network.CIDR); err != nil {
+			return fmt.Errorf("invalid network cidr: %w", err)
+		}
+	}
+	if err := ValidateSubnets(s.Network.SubnetsPer, s.Network.Tiers, s.Pools); err != nil {
+		return err
+	}
+
+	if err := ValidateSecurityGroups(s.SecurityGroups, s.Pools); err != nil {
+		return err
+	}
+
+	// Without any pools, the nodes are put in the default pool
+	pools := s.Pools
+	if len(pools) == 0 {
+		pools = []NodePool{{Name: DefaultPoolName}}
+	}
+	return ValidateLoadBalancers(s.LoadBalancers, s.Network.Tiers, pools)
+}
+
+// Apply the spec to the config. Pools without an instance type use the
+// config's instance type.
+func (s *Spec) Apply(cfg *CloudConfig) {
+	cfg.SecurityGroups = s.SecurityGroups
+	cfg.LoadBalancers = s.LoadBalancers
+	cfg.SubnetsPer = s.Network.SubnetsPer
+	cfg.SubnetTiers = s.Network.Tiers
+	if s.Network.CIDR != "" {
+		cfg.Subnet = s.Network.CIDR
+	}
+
+	if len(s.Pools) == 0 {
+		return
+	}
+
+	cfg.Pools = make([]NodePool, 0, len(s.Pools))
+	for _, pool := range s.Pools {
+		if pool.InstanceType == "" {
+			pool.InstanceType = cfg.InstanceType
+		}
+		cfg.Pools = append(cfg.Pools, pool)
+	}
+	cfg.VMCount = cfg.NodeCount()
+}
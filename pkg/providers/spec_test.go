@@ -0,0 +1,151 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadSpec(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Spec     string
+		Expected []providers.NodePool
+		Count    int
+		Err      bool
+	}{
+		{
+			Name: "pools",
+			Spec: `pools:
+  - name: web
+    count: 2
+    labels:
+      role: web
+  - name: db
+    count: 1
+    instanceType: r5.large
+`,
+			Expected: []providers.NodePool{
+				{Name: "web", Count: 2, InstanceType: "t3.medium", Labels: map[string]string{"role": "web"}},
+				{Name: "db", Count: 1, InstanceType: "r5.large"},
+			},
+			Count: 3,
+		},
+		{
+			Name: "security groups",
+			Spec: `securityGroups:
+  - name: ssh
+    rules:
+      - direction: ingress
+        protocol: tcp
+        ports: "22"
+        cidr: 10.0.0.0/24
+pools:
+  - name: bastion
+    count: 1
+    securityGroups:
+      - ssh
+`,
+			Expected: []providers.NodePool{
+				{Name: "bastion", Count: 1, InstanceType: "t3.medium", SecurityGroups: []string{"ssh"}},
+			},
+			Count: 1,
+		},
+		{
+			Name: "unknown security group",
+			Spec: "pools:\n  - name: web\n    count: 1\n    securityGroups:\n      - ssh\n",
+			Err:  true,
+		},
+		{
+			Name: "load balancers",
+			Spec: `loadBalancers:
+  - name: web
+    pool: web
+    listeners:
+      - protocol: http
+        port: 80
+        targetPort: 8080
+    healthCheck:
+      path: /healthz
+      interval: 10s
+pools:
+  - name: web
+    count: 2
+`,
+			Expected: []providers.NodePool{{Name: "web", Count: 2, InstanceType: "t3.medium"}},
+			Count:    2,
+		},
+		{
+			Name:     "load balancer on default pool",
+			Spec:     "loadBalancers:\n  - name: web\n    pool: default\n    listeners:\n      - protocol: tcp\n        port: 443\n",
+			Expected: []providers.NodePool{{Name: providers.DefaultPoolName, Count: 4, InstanceType: "t3.medium"}},
+			Count:    4,
+		},
+		{
+			Name: "load balancer unknown pool",
+			Spec: "loadBalancers:\n  - name: web\n    pool: db\n    listeners:\n      - protocol: tcp\n        port: 443\n",
+			Err:  true,
+		},
+		{
+			Name:     "no pools",
+			Spec:     "pools: []\n",
+			Expected: []providers.NodePool{{Name: providers.DefaultPoolName, Count: 4, InstanceType: "t3.medium"}},
+			Count:    4,
+		},
+		{
+			Name: "duplicate pool",
+			Spec: "pools:\n  - name: web\n    count: 1\n  - name: web\n    count: 1\n",
+			Err:  true,
+		},
+		{
+			Name: "empty pool",
+			Spec: "pools:\n  - name: web\n",
+			Err:  true,
+		},
+		{
+			Name: "missing name",
+			Spec: "pools:\n  - count: 1\n",
+			Err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "spec.yaml")
+			assert.NoError(t, os.WriteFile(file, []byte(test.Spec), 0o600))
+
+			spec, err := providers.LoadSpec(file)
+			if test.Err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			cfg := providers.CloudConfig{VMCount: 4, InstanceType: "t3.medium"}
+			spec.Apply(&cfg)
+
+			assert.Equal(t, test.Expected, cfg.NodePools())
+			assert.Equal(t, test.Count, cfg.NodeCount())
+			assert.Equal(t, test.Count, cfg.VMCount)
+		})
+	}
+}
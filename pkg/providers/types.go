@@ -22,13 +22,28 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"net"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/dns"
 )
 
 type Provider interface {
 	CheckNodeReady(ctx context.Context, node *NodeResult) error
+	// Returns an error until the node passes the load balancer's health check
+	CheckTargetHealthy(ctx context.Context, lb *LoadBalancerResult, node *NodeResult) error
 	CreateNetwork(ctx context.Context, project *ProjectResult) (*NetworkResult, error)
-	CreateNode(ctx context.Context, project *ProjectResult) (*NodeResult, error)
+	CreateNode(ctx context.Context, project *ProjectResult, node *PlannedNode) (*NodeResult, error)
+	CreateLoadBalancer(ctx context.Context, network *NetworkResult, lb *LoadBalancer) (*LoadBalancerResult, error)
 	CreateProject(ctx context.Context) (*ProjectResult, error)
+	CreateSecurityGroup(ctx context.Context, network *NetworkResult, group *SecurityGroup) (*SecurityGroupResult, error)
+	DeleteLoadBalancer(ctx context.Context, lb *LoadBalancerResult) error
+	DeleteNetwork(ctx context.Context, network *NetworkResult) error
+	DeleteNode(ctx context.Context, node *NodeResult) error
+	DeleteProject(ctx context.Context, project *ProjectResult) error
+	DeleteSecurityGroup(ctx context.Context, group *SecurityGroupResult) error
+	Plan(ctx context.Context) (*Plan, error)
+	// Replace the nodes the load balancer sends traffic to
+	SetLoadBalancerTargets(ctx context.Context, lb *LoadBalancerResult, nodes []*NodeResult) (*LoadBalancerResult, error)
 }
 
 type ProjectResult struct {
@@ -36,21 +51,39 @@ type ProjectResult struct {
 
 	ID string
 
-	Network *NetworkResult
-	Nodes   []*NodeResult
+	Approval       *ApprovalDecision
+	Network        *NetworkResult
+	SecurityGroups []*SecurityGroupResult
+	Nodes          []*NodeResult
+	LoadBalancers  []*LoadBalancerResult
+	DNSRecords     []dns.Record
 }
 
 type NetworkResult struct {
 	ID     string
 	Region string
-	Subnet *net.IPNet
+	// The CIDR of the whole network, which the subnets are carved from
+	Subnet  *net.IPNet
+	Subnets []*SubnetResult
 }
 
 type NodeResult struct {
-	ID      string
-	Name    string
-	Address net.IP
-	Port    int32
+	ID     string
+	Name   string
+	Pool   string
+	Labels map[string]string
+	// IDs of the security groups attached to the node
+	SecurityGroups []string
+	Zone           string
+	// ID of the subnet the node is in
+	Subnet       string
+	InstanceType string
+	Address      net.IP
+	Port         int32
+	HourlyCost   float64
+	CreatedAt    time.Time
+	// Set once the node has been deleted so it stops accruing cost
+	DeletedAt *time.Time
 }
 
 type NodeReadyResult struct {
@@ -67,11 +100,146 @@ const (
 	CloudProviderAWS CloudProvider = "aws"
 )
 
+// QuotaPolicy decides what happens when there is not enough quota
+type QuotaPolicy string
+
+const (
+	// Wait until enough capacity has been released
+	QuotaPolicyQueue QuotaPolicy = "queue"
+	// Fail the provisioning immediately
+	QuotaPolicyFailFast QuotaPolicy = "fail-fast"
+)
+
+// Used to convert hourly prices to a monthly estimate
+const HoursPerMonth = 730
+
 type CloudConfig struct {
+	Name     string
 	Provider CloudProvider
 	Region   string
-	Subnet   string
-	VMCount  int
+	// CIDR of the network. The subnets are carved from this
+	Subnet string
+	// Pick a free range the size of Subnet from Supernet, avoiding the
+	// networks of the other projects in the inventory
+	AutoCIDR bool
+	Supernet string
+	// Carve a subnet for each tier in each zone, or in each pool
+	SubnetsPer   SubnetGrouping
+	SubnetTiers  []SubnetTier
+	VMCount      int
+	InstanceType string
+	QuotaPolicy  QuotaPolicy
+	// Who the project is for - used to find the project in Temporal
+	Owner       string
+	Environment string
+	// Groups of nodes to create. If empty, VMCount nodes of InstanceType are
+	// created in the default pool
+	Pools []NodePool
+	// Firewall rules attached to the nodes of the pools which use them
+	SecurityGroups []SecurityGroup
+	// Load balancers in front of the pools, with their listeners and health
+	// checks
+	LoadBalancers []LoadBalancer
+	// Publish the addresses of the nodes and load balancers in this zone.
+	// Empty disables DNS
+	DNSZone string
+	DNSTTL  time.Duration
+
+	// If set, these exact resources will be created
+	Plan *Plan
+}
+
+// Plan describes the resources that will be created from a config. This has
+// no side effects so can be previewed and saved before being applied.
+type Plan struct {
+	Project        PlannedProject
+	Network        PlannedNetwork
+	SecurityGroups []SecurityGroup
+	Nodes          []*PlannedNode
+	LoadBalancers  []LoadBalancer
+	HourlyCost     float64
+	MonthlyCost    float64
+}
+
+type PlannedProject struct {
+	Name string
+}
+
+type PlannedNetwork struct {
+	Region  string
+	Subnet  string
+	Subnets []PlannedSubnet
+}
+
+type PlannedNode struct {
+	Name   string
+	Pool   string
+	Labels map[string]string
+	// Names of the security groups to attach to the node
+	SecurityGroups []string
+	Zone           string
+	// Name of the planned subnet the node is put in
+	Subnet       string
+	InstanceType string
+	HourlyCost   float64
+	MonthlyCost  float64
+}
+
+// The pool used when no pools are configured
+const DefaultPoolName = "default"
+
+// NodePool is a group of identical nodes, eg the web servers
+type NodePool struct {
+	Name           string            `yaml:"name"`
+	Count          int               `yaml:"count"`
+	InstanceType   string            `yaml:"instanceType"`
+	Labels         map[string]string `yaml:"labels"`
+	SecurityGroups []string          `yaml:"securityGroups"`
+	// Defaults to private
+	Tier SubnetTier `yaml:"tier"`
+}
+
+// NodePools returns the pools to create. If none are configured, a single
+// pool is built from VMCount and InstanceType.
+func (c CloudConfig) NodePools() []NodePool {
+	if len(c.Pools) > 0 {
+		return c.Pools
+	}
+	return []NodePool{
+		{
+			Name:         DefaultPoolName,
+			Count:        c.VMCount,
+			InstanceType: c.InstanceType,
+		},
+	}
+}
+
+// NodeCount is the total number of nodes in all the pools
+func (c CloudConfig) NodeCount() int {
+	count := 0
+	for _, pool := range c.NodePools() {
+		count += pool.Count
+	}
+	return count
+}
+
+// ApprovalPolicy decides when a human must sign off the provisioning before
+// any resources are created. This is set on the workflow workers.
+type ApprovalPolicy struct {
+	// Require approval when more than this number of nodes is requested. Zero disables the check
+	MaxNodes int
+	// Instance types which are considered expensive and always require approval
+	InstanceTypes []string
+	// How long to wait for a decision. Zero waits forever
+	Timeout time.Duration
+}
+
+// ApprovalDecision records who signed off (or refused) the provisioning
+type ApprovalDecision struct {
+	Approved  bool
+	Approver  string
+	Comment   string
+	Timestamp time.Time
 }
 
 func (c CloudConfig) GetProvider() (Provider, error) {
@@ -90,20 +258,49 @@ var GetProvider = func(c CloudConfig) (Provider, error) {
 
 // Pseudo-randomise failure - this is obviously not going to be in a real-world
 // version, but it exists to demonstrate that cloud APIs are a black box and we
-// have no control over the failures
+// have no control over the failures. Half of the failures are the provider
+// throttling the requests.
 func SimulateFailure() error {
-	if rand.IntN(9) == 1 {
-		return fmt.Errorf("simulate failure")
+	if rand.IntN(9) != 1 {
+		return nil
 	}
-	return nil
+	if rand.IntN(2) == 0 {
+		//nolint:gosec // ignore weak number generator error
+		return &RateLimitError{RetryAfter: time.Duration(rand.IntN(10)+1) * time.Second}
+	}
+	return fmt.Errorf("simulate failure")
 }
 
-// Generate an IP address - this simulates the cloud provider's process of assigning an IP
-func GenerateIPAddress() net.IP {
-	buf := make([]byte, 4)
+// The addresses at the start and end of a subnet which can't be given to a
+// resource. AWS keeps the network address and the next three, and the last
+// address.
+const (
+	reservedStartAddresses = 4
+	reservedEndAddresses   = 1
+)
+
+// Generate an IP address in the subnet - this simulates the cloud provider's
+// process of assigning an IP
+func GenerateIPAddress(subnet *net.IPNet) (net.IP, error) {
+	if subnet == nil {
+		return nil, fmt.Errorf("no subnet to allocate an address from")
+	}
+
+	ip := subnet.IP.To4()
+	ones, bits := subnet.Mask.Size()
+	if ip == nil || bits != net.IPv4len*8 {
+		return nil, fmt.Errorf("subnet %s is not ipv4", subnet)
+	}
+
+	size := uint64(1) << (bits - ones)
+	if size <= reservedStartAddresses+reservedEndAddresses {
+		return nil, fmt.Errorf("subnet %s has no free addresses", subnet)
+	}
 
-	ip := rand.Uint32()
+	//nolint:gosec // ignore weak number generator error
+	offset := reservedStartAddresses + rand.Uint64N(size-reservedStartAddresses-reservedEndAddresses)
 
-	binary.LittleEndian.PutUint32(buf, ip)
-	return net.IP(buf)
+	buf := make([]byte, net.IPv4len)
+	binary.BigEndian.PutUint32(buf, binary.BigEndian.Uint32(ip)+uint32(offset))
+	return net.IP(buf), nil
 }
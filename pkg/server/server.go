@@ -0,0 +1,105 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.temporal.io/sdk/client"
+)
+
+// Server exposes the health of the worker process to Kubernetes and the
+// metrics to Prometheus
+type Server struct {
+	client  client.Client
+	running atomic.Bool
+	server  *http.Server
+}
+
+// SetRunning marks whether the workers are running. The worker is not ready
+// until this is true.
+func (s *Server) SetRunning(running bool) {
+	s.running.Store(running)
+}
+
+// Liveness - the process is able to respond
+func (s *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// Readiness - the client is connected to Temporal and the workers are running
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if !s.running.Load() {
+		http.Error(w, "workers not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	if _, err := s.client.CheckHealth(ctx, &client.CheckHealthRequest{}); err != nil {
+		log.Debug().Err(err).Msg("Temporal health check failed")
+		http.Error(w, "temporal not connected", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// Start listens in the background
+func (s *Server) Start() {
+	go func() {
+		log.Info().Str("addr", s.server.Addr).Msg("Starting HTTP server")
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("Unable to start HTTP server")
+		}
+	}()
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// New creates the HTTP server. If the metrics handler is nil, /metrics is
+// not served.
+func New(addr string, c client.Client, metrics http.Handler) *Server {
+	s := &Server{
+		client: c,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+	if metrics != nil {
+		mux.Handle("GET /metrics", metrics)
+	}
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 10,
+	}
+
+	return s
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package telemetry
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"github.com/uber-go/tally/v4"
+	"github.com/uber-go/tally/v4/prometheus"
+	"go.temporal.io/sdk/client"
+	sdktally "go.temporal.io/sdk/contrib/tally"
+)
+
+// Metrics exports the Temporal SDK metrics, and any custom metrics recorded
+// through the SDK's metrics handler, in Prometheus format
+type Metrics struct {
+	Handler     client.MetricsHandler
+	HTTPHandler http.Handler
+
+	closer io.Closer
+}
+
+func (m *Metrics) Close() error {
+	return m.closer.Close()
+}
+
+func NewPrometheusMetrics() *Metrics {
+	reporter := prometheus.NewReporter(prometheus.Options{
+		Registerer: prom.NewRegistry(),
+		OnRegisterError: func(err error) {
+			log.Warn().Err(err).Msg("Error registering Prometheus metric")
+		},
+	})
+
+	scope, closer := tally.NewRootScope(tally.ScopeOptions{
+		CachedReporter:  reporter,
+		Separator:       prometheus.DefaultSeparator,
+		SanitizeOptions: &sdktally.PrometheusSanitizeOptions,
+		Prefix:          "temporal",
+	}, time.Second)
+
+	return &Metrics{
+		Handler:     sdktally.NewMetricsHandler(sdktally.NewPrometheusNamingScope(scope)),
+		HTTPHandler: reporter.HTTPHandler(),
+		closer:      closer,
+	}
+}
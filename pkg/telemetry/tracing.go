@@ -0,0 +1,112 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// ServiceName identifies this application in the traces
+const ServiceName = "temporal-provisioner"
+
+type TracingExporter string
+
+const (
+	TracingExporterNone   TracingExporter = "none"
+	TracingExporterOTLP   TracingExporter = "otlp"
+	TracingExporterStdout TracingExporter = "stdout"
+)
+
+// Tracing sends the spans created by the Temporal SDK and the provider calls
+// to the configured exporter
+type Tracing struct {
+	Interceptor interceptor.Interceptor
+
+	provider *sdktrace.TracerProvider
+}
+
+// Shutdown flushes any buffered spans to the exporter
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+func newTraceExporter(ctx context.Context, exporter TracingExporter, endpoint string) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case TracingExporterOTLP:
+		opts := make([]otlptracegrpc.Option, 0)
+		if endpoint != "" {
+			// The OTEL_EXPORTER_OTLP_* environment variables are used if not set
+			opts = append(opts, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case TracingExporterStdout:
+		// Stdout is kept for the command output so the spans go to stderr
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", exporter)
+	}
+}
+
+// NewTracing registers a global tracer provider and creates the Temporal
+// interceptor, which propagates the spans from the client through the
+// workflows and into the activities
+func NewTracing(ctx context.Context, exporter TracingExporter, endpoint string) (*Tracing, error) {
+	spanExporter, err := newTraceExporter(ctx, exporter, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	tracingInterceptor, err := opentelemetry.NewTracingInterceptor(opentelemetry.TracerOptions{
+		Tracer: provider.Tracer(ServiceName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating tracing interceptor: %w", err)
+	}
+
+	return &Tracing{
+		Interceptor: tracingInterceptor,
+		provider:    provider,
+	}, nil
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/rs/zerolog/log"
 	slogzerolog "github.com/samber/slog-zerolog/v2"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
 	tLog "go.temporal.io/sdk/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -49,8 +51,41 @@ func apiKeyInterceptor(namespace string) grpc.UnaryClientInterceptor {
 	}
 }
 
+// Option changes the client options before connecting
+type Option func(*client.Options)
+
+// WithMetricsHandler sends the SDK metrics to the handler
+func WithMetricsHandler(handler client.MetricsHandler) Option {
+	return func(o *client.Options) {
+		o.MetricsHandler = handler
+	}
+}
+
+// WithInterceptors adds interceptors to the client and any workers created
+// from it
+func WithInterceptors(interceptors ...interceptor.ClientInterceptor) Option {
+	return func(o *client.Options) {
+		o.Interceptors = append(o.Interceptors, interceptors...)
+	}
+}
+
+// WithTLS connects to the server with TLS. This can be used with or without an
+// API key.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *client.Options) {
+		o.ConnectionOptions.TLS = cfg
+	}
+}
+
+// WithDataConverter converts the payloads, eg to encrypt them
+func WithDataConverter(dc converter.DataConverter) Option {
+	return func(o *client.Options) {
+		o.DataConverter = dc
+	}
+}
+
 // Common function to create a Temporal client
-func NewClient(host, namespace, apiKey string) (client.Client, error) {
+func NewClient(host, namespace, apiKey string, opts ...Option) (client.Client, error) {
 	var credentials client.Credentials
 	var connectionOptions client.ConnectionOptions
 
@@ -67,7 +102,7 @@ func NewClient(host, namespace, apiKey string) (client.Client, error) {
 			},
 		}
 	}
-	return client.Dial(client.Options{
+	clientOptions := client.Options{
 		HostPort:          host,
 		Namespace:         namespace,
 		ConnectionOptions: connectionOptions,
@@ -75,5 +110,11 @@ func NewClient(host, namespace, apiKey string) (client.Client, error) {
 		Logger: tLog.NewStructuredLogger(slog.New(slogzerolog.Option{
 			Logger: &log.Logger,
 		}.NewZerologHandler())),
-	})
+	}
+
+	for _, opt := range opts {
+		opt(&clientOptions)
+	}
+
+	return client.Dial(clientOptions)
 }
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package temporal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOptions configures the connection to a Temporal server that uses TLS or
+// mutual TLS
+type TLSOptions struct {
+	// Client certificate and key - both are required for mutual TLS
+	CertFile string
+	KeyFile  string
+	// CA bundle to verify the server - the system roots are used if empty
+	CAFile string
+	// Override the name used to verify the server certificate
+	ServerName string
+}
+
+// certReloader loads the client certificate from disk when it's requested by
+// the server, reloading it if the files have changed since the last load. This
+// allows the certificates to be rotated without restarting.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func modTime(file string) (time.Time, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading file info: %w", err)
+	}
+	return info.ModTime(), nil
+}
+
+func (c *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	certModTime, err := modTime(c.certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyModTime, err := modTime(c.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cert != nil && certModTime.Equal(c.certModTime) && keyModTime.Equal(c.keyModTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		// The files may be mid-rotation so keep using the previous certificate
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("error loading client certificate: %w", err)
+	}
+
+	c.cert = &cert
+	c.certModTime = certModTime
+	c.keyModTime = keyModTime
+
+	return c.cert, nil
+}
+
+// NewTLSConfig creates the TLS config for the connection. The client
+// certificate is reloaded when it changes on disk, but the CA bundle is only
+// read on creation.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: opts.ServerName,
+	}
+
+	if opts.CAFile != "" {
+		ca, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in ca file: %s", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must both be set")
+		}
+
+		reloader := &certReloader{
+			certFile: opts.CertFile,
+			keyFile:  opts.KeyFile,
+		}
+
+		// Fail early if the certificate cannot be loaded
+		if _, err := reloader.GetClientCertificate(nil); err != nil {
+			return nil, err
+		}
+
+		cfg.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	return cfg, nil
+}
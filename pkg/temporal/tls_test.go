@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package temporal_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/temporal"
+	"github.com/stretchr/testify/assert"
+)
+
+// Write a self-signed certificate and key to the files
+func writeCert(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func commonName(t *testing.T, der []byte) string {
+	t.Helper()
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return cert.Subject.CommonName
+}
+
+func Test_NewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeCert(t, certFile, keyFile, "first")
+
+	tests := []struct {
+		Name  string
+		Opts  temporal.TLSOptions
+		Error string
+	}{
+		{
+			Name: "tls only",
+		},
+		{
+			Name: "mutual tls",
+			Opts: temporal.TLSOptions{CertFile: certFile, KeyFile: keyFile, CAFile: certFile, ServerName: "temporal"},
+		},
+		{
+			Name:  "missing key",
+			Opts:  temporal.TLSOptions{CertFile: certFile},
+			Error: "client certificate and key must both be set",
+		},
+		{
+			Name:  "invalid ca",
+			Opts:  temporal.TLSOptions{CAFile: keyFile},
+			Error: "no certificates found in ca file",
+		},
+		{
+			Name:  "missing cert",
+			Opts:  temporal.TLSOptions{CertFile: filepath.Join(dir, "missing"), KeyFile: keyFile},
+			Error: "error reading file info",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cfg, err := temporal.NewTLSConfig(test.Opts)
+			if test.Error != "" {
+				assert.ErrorContains(err, test.Error)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(test.Opts.ServerName, cfg.ServerName)
+			assert.Equal(test.Opts.CAFile != "", cfg.RootCAs != nil)
+			assert.Equal(test.Opts.CertFile != "", cfg.GetClientCertificate != nil)
+		})
+	}
+}
+
+func Test_NewTLSConfigReload(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeCert(t, certFile, keyFile, "first")
+
+	cfg, err := temporal.NewTLSConfig(temporal.TLSOptions{CertFile: certFile, KeyFile: keyFile})
+	assert.NoError(err)
+
+	cert, err := cfg.GetClientCertificate(nil)
+	assert.NoError(err)
+	assert.Equal("first", commonName(t, cert.Certificate[0]))
+
+	// Rotate the certificate
+	writeCert(t, certFile, keyFile, "second")
+	future := time.Now().Add(time.Minute)
+	assert.NoError(os.Chtimes(certFile, future, future))
+	assert.NoError(os.Chtimes(keyFile, future, future))
+
+	cert, err = cfg.GetClientCertificate(nil)
+	assert.NoError(err)
+	assert.Equal("second", commonName(t, cert.Certificate[0]))
+
+	// A broken rotation keeps the previous certificate
+	assert.NoError(os.WriteFile(keyFile, []byte("invalid"), 0o600))
+	future = future.Add(time.Minute)
+	assert.NoError(os.Chtimes(keyFile, future, future))
+
+	cert, err = cfg.GetClientCertificate(nil)
+	assert.NoError(err)
+	assert.Equal("second", commonName(t, cert.Certificate[0]))
+}
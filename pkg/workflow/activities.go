@@ -18,12 +18,238 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/mrsimonemms/temporal/pkg/audit"
 	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
 )
 
+// QuotaActivities talk to the quota manager workflows so need a Temporal
+// client. These are registered with the worker as a struct.
+type QuotaActivities struct {
+	Client client.Client
+	// Only used if this worker starts the quota manager
+	Limits QuotaLimits
+	// Task queue the quota managers run on
+	TaskQueue string
+}
+
+// Used to reference the activity methods from the workflows
+var quotaActivities *QuotaActivities
+
+// Ask the quota manager for capacity, starting it if it's not running
+func (q *QuotaActivities) ReserveQuotaActivity(ctx context.Context, req QuotaRequest) (*QuotaReservation, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("ReserveQuotaActivity", "provider", req.Provider, "region", req.Region)
+
+	startOp := q.Client.NewWithStartWorkflowOperation(client.StartWorkflowOptions{
+		ID:                       QuotaManagerWorkflowID(req.Provider, req.Region),
+		TaskQueue:                q.TaskQueue,
+		WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING,
+	}, QuotaManagerWorkflow, QuotaManagerState{Limits: q.Limits})
+
+	handle, err := q.Client.UpdateWithStartWorkflow(ctx, client.UpdateWithStartWorkflowOptions{
+		StartWorkflowOperation: startOp,
+		UpdateOptions: client.UpdateWorkflowOptions{
+			// Make retries idempotent
+			UpdateID:     fmt.Sprintf("%s-reserve", activity.GetInfo(ctx).WorkflowExecution.RunID),
+			UpdateName:   QuotaReserveUpdate,
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+			Args:         []any{req},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error sending quota request: %w", err)
+	}
+
+	var reservation *QuotaReservation
+	if err := handle.Get(ctx, &reservation); err != nil {
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.NonRetryable() {
+			return nil, temporal.NewNonRetryableApplicationError(appErr.Error(), appErr.Type(), err)
+		}
+		return nil, fmt.Errorf("error getting quota reservation: %w", err)
+	}
+
+	return reservation, nil
+}
+
+// Return capacity to the quota manager
+func (q *QuotaActivities) ReleaseQuotaActivity(ctx context.Context, req QuotaRequest) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("ReleaseQuotaActivity", "provider", req.Provider, "region", req.Region)
+
+	handle, err := q.Client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   QuotaManagerWorkflowID(req.Provider, req.Region),
+		UpdateID:     fmt.Sprintf("%s-release", activity.GetInfo(ctx).WorkflowExecution.RunID),
+		UpdateName:   QuotaReleaseUpdate,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []any{req},
+	})
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			// No manager means no reservation to release
+			logger.Warn("Quota manager not running")
+			return nil
+		}
+		return fmt.Errorf("error sending quota release: %w", err)
+	}
+
+	return handle.Get(ctx, nil)
+}
+
+// Maximum time to back off when the provider is throttling requests
+const maxRateLimitBackoff = time.Minute * 5
+
+var tracer = otel.Tracer("github.com/mrsimonemms/temporal/pkg/workflow")
+
+// Each provider call has its own span, under the activity's span if tracing
+// is enabled in the worker
+func startProviderSpan(
+	ctx context.Context,
+	config providers.CloudConfig,
+	operation string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return tracer.Start(ctx, fmt.Sprintf("%s %s", config.Provider, operation), trace.WithAttributes(
+		append([]attribute.KeyValue{
+			attribute.String("provider", string(config.Provider)),
+			attribute.String("region", config.Region),
+			attribute.String("operation", operation),
+		}, attrs...)...,
+	))
+}
+
+func endProviderSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Get the ID of the resource created by the provider
+func resourceIDAttribute(res any) (attribute.KeyValue, bool) {
+	switch r := res.(type) {
+	case *providers.ProjectResult:
+		if r != nil {
+			return attribute.String("project.id", r.ID), true
+		}
+	case *providers.NetworkResult:
+		if r != nil {
+			return attribute.String("network.id", r.ID), true
+		}
+	case *providers.SecurityGroupResult:
+		if r != nil {
+			return attribute.String("security_group.id", r.ID), true
+		}
+	case *providers.NodeResult:
+		if r != nil {
+			return attribute.String("node.id", r.ID), true
+		}
+	case *providers.LoadBalancerResult:
+		if r != nil {
+			return attribute.String("load_balancer.id", r.ID), true
+		}
+	}
+	return attribute.KeyValue{}, false
+}
+
+// A change which was made but couldn't be audited. This is kept in the
+// heartbeat so the next attempt records it rather than making it again.
+type unrecordedChange[T any] struct {
+	Result T
+	Events []*audit.Event
+}
+
+// Every call to the provider's API is rate limited and, if the provider is
+// throttling the requests, the retry is backed off. The latency and failures
+// are recorded in the metrics, the call is traced and each attempt is audited.
+func callProvider[T any](
+	ctx context.Context,
+	config providers.CloudConfig,
+	operation string,
+	fn func(ctx context.Context) (T, error),
+	attrs ...attribute.KeyValue,
+) (T, error) {
+	if activity.HasHeartbeatDetails(ctx) {
+		var change unrecordedChange[T]
+		if err := activity.GetHeartbeatDetails(ctx, &change); err == nil && len(change.Events) > 0 {
+			activity.GetLogger(ctx).Info("Recording change made by a previous attempt", "operation", operation)
+			return change.Result, writeAudit(ctx, change.Events)
+		}
+	}
+
+	if err := providers.Limiter.Wait(ctx, config.Provider, config.Region); err != nil {
+		var empty T
+		return empty, fmt.Errorf("error waiting for rate limiter: %w", err)
+	}
+
+	metrics := activity.GetMetricsHandler(ctx).WithTags(map[string]string{
+		"provider":  string(config.Provider),
+		"region":    config.Region,
+		"operation": operation,
+	})
+
+	spanCtx, span := startProviderSpan(ctx, config, operation, attrs...)
+
+	start := time.Now()
+	res, err := fn(spanCtx)
+
+	metrics.Timer("provider_call_latency").Record(time.Since(start))
+	metrics.Counter("provider_calls").Inc(1)
+	if err != nil {
+		metrics.Counter("provider_call_failures").Inc(1)
+	}
+
+	if attr, ok := resourceIDAttribute(res); ok && err == nil {
+		span.SetAttributes(attr)
+	}
+	endProviderSpan(span, err)
+
+	id := ""
+	if err == nil {
+		id = resourceID(res)
+	}
+	events := auditEvents(ctx, id, err)
+	if auditErr := writeAudit(ctx, events); auditErr != nil {
+		if err == nil {
+			activity.RecordHeartbeat(ctx, unrecordedChange[T]{Result: res, Events: events})
+			return res, auditErr
+		}
+		// The activity is retried anyway
+		activity.GetLogger(ctx).Error("Unable to record failed attempt", "operation", operation, "error", auditErr)
+	}
+
+	var rateLimitErr *providers.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		// Exponentially increase the provider's suggested delay on each attempt
+		attempt := min(activity.GetInfo(ctx).Attempt, 10)
+		delay := min(rateLimitErr.RetryAfter*time.Duration(1<<(attempt-1)), maxRateLimitBackoff)
+
+		activity.GetLogger(ctx).Warn("Rate limited by provider", "provider", config.Provider, "region", config.Region, "delay", delay)
+
+		return res, temporal.NewApplicationErrorWithOptions(err.Error(), "RateLimited", temporal.ApplicationErrorOptions{
+			NextRetryDelay: delay,
+			Cause:          err,
+		})
+	}
+
+	return res, err
+}
+
 func CreateProjectActivity(ctx context.Context, config providers.CloudConfig) (*providers.ProjectResult, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("CreateProjectActivity", "provider", config.Provider)
@@ -33,7 +259,27 @@ func CreateProjectActivity(ctx context.Context, config providers.CloudConfig) (*
 		return nil, fmt.Errorf("error initializing provider: %w", err)
 	}
 
-	return cloudProvider.CreateProject(ctx)
+	return callProvider(ctx, config, "CreateProject", func(ctx context.Context) (*providers.ProjectResult, error) {
+		return cloudProvider.CreateProject(ctx)
+	})
+}
+
+// Calculate the resources that would be created - this has no side effects
+func PlanProjectActivity(ctx context.Context, config providers.CloudConfig) (*providers.Plan, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("PlanProjectActivity", "provider", config.Provider)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	ctx, span := startProviderSpan(ctx, config, "Plan")
+
+	plan, err := cloudProvider.Plan(ctx)
+	endProviderSpan(span, err)
+
+	return plan, err
 }
 
 func SetupNetworkActivity(
@@ -49,7 +295,28 @@ func SetupNetworkActivity(
 		return nil, fmt.Errorf("error initializing provider: %w", err)
 	}
 
-	return cloudProvider.CreateNetwork(ctx, project)
+	return callProvider(ctx, config, "CreateNetwork", func(ctx context.Context) (*providers.NetworkResult, error) {
+		return cloudProvider.CreateNetwork(ctx, project)
+	}, attribute.String("project.id", project.ID))
+}
+
+func CreateSecurityGroupActivity(
+	ctx context.Context,
+	config providers.CloudConfig,
+	network *providers.NetworkResult,
+	group *providers.SecurityGroup,
+) (*providers.SecurityGroupResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("CreateSecurityGroupActivity", "provider", config.Provider, "name", group.Name)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	return callProvider(ctx, config, "CreateSecurityGroup", func(ctx context.Context) (*providers.SecurityGroupResult, error) {
+		return cloudProvider.CreateSecurityGroup(ctx, network, group)
+	}, attribute.String("network.id", network.ID), attribute.String("security_group.name", group.Name))
 }
 
 // Simulate making an SSH connection and checking for cloud-config to become ready
@@ -66,7 +333,11 @@ func AwaitForNodeRunningActivity(
 		return nil, fmt.Errorf("error initializing provider: %w", err)
 	}
 
-	if err := cloudProvider.CheckNodeReady(ctx, node); err != nil {
+	ctx, span := startProviderSpan(ctx, config, "CheckNodeReady", attribute.String("node.id", node.ID))
+
+	err = cloudProvider.CheckNodeReady(ctx, node)
+	endProviderSpan(span, err)
+	if err != nil {
 		return nil, err
 	}
 
@@ -77,6 +348,7 @@ func AwaitForNodeRunningActivity(
 func ProvisionNodeActivity(ctx context.Context,
 	config providers.CloudConfig,
 	project *providers.ProjectResult,
+	node *providers.PlannedNode,
 ) (*providers.NodeResult, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("ProvisionNodeActivity", "provider", config.Provider)
@@ -86,5 +358,142 @@ func ProvisionNodeActivity(ctx context.Context,
 		return nil, fmt.Errorf("error initializing provider: %w", err)
 	}
 
-	return cloudProvider.CreateNode(ctx, project)
+	return callProvider(ctx, config, "CreateNode", func(ctx context.Context) (*providers.NodeResult, error) {
+		return cloudProvider.CreateNode(ctx, project, node)
+	}, attribute.String("project.id", project.ID), attribute.String("node.name", node.Name))
+}
+
+func CreateLoadBalancerActivity(
+	ctx context.Context,
+	config providers.CloudConfig,
+	network *providers.NetworkResult,
+	lb *providers.LoadBalancer,
+) (*providers.LoadBalancerResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("CreateLoadBalancerActivity", "provider", config.Provider, "name", lb.Name)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	return callProvider(ctx, config, "CreateLoadBalancer", func(ctx context.Context) (*providers.LoadBalancerResult, error) {
+		return cloudProvider.CreateLoadBalancer(ctx, network, lb)
+	}, attribute.String("network.id", network.ID), attribute.String("load_balancer.name", lb.Name))
+}
+
+func SetLoadBalancerTargetsActivity(
+	ctx context.Context,
+	config providers.CloudConfig,
+	lb *providers.LoadBalancerResult,
+	nodes []*providers.NodeResult,
+) (*providers.LoadBalancerResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("SetLoadBalancerTargetsActivity", "provider", config.Provider, "loadBalancerId", lb.ID, "targets", len(nodes))
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	return callProvider(ctx, config, "SetLoadBalancerTargets", func(ctx context.Context) (*providers.LoadBalancerResult, error) {
+		return cloudProvider.SetLoadBalancerTargets(ctx, lb, nodes)
+	}, attribute.String("load_balancer.id", lb.ID))
+}
+
+// Fails until the node is passing the load balancer's health check, so it's
+// retried until the node is healthy
+func AwaitForTargetHealthyActivity(
+	ctx context.Context,
+	config providers.CloudConfig,
+	lb *providers.LoadBalancerResult,
+	node *providers.NodeResult,
+) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("AwaitForTargetHealthyActivity", "provider", config.Provider, "loadBalancerId", lb.ID, "nodeId", node.ID)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	_, err = callProvider(ctx, config, "CheckTargetHealthy", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, cloudProvider.CheckTargetHealthy(ctx, lb, node)
+	}, attribute.String("load_balancer.id", lb.ID), attribute.String("node.id", node.ID))
+	return err
+}
+
+func DeleteNodeActivity(ctx context.Context, config providers.CloudConfig, node *providers.NodeResult) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("DeleteNodeActivity", "provider", config.Provider, "nodeId", node.ID)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	_, err = callProvider(ctx, config, "DeleteNode", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, cloudProvider.DeleteNode(ctx, node)
+	}, attribute.String("node.id", node.ID))
+	return err
+}
+
+func DeleteLoadBalancerActivity(ctx context.Context, config providers.CloudConfig, lb *providers.LoadBalancerResult) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("DeleteLoadBalancerActivity", "provider", config.Provider, "loadBalancerId", lb.ID)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	_, err = callProvider(ctx, config, "DeleteLoadBalancer", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, cloudProvider.DeleteLoadBalancer(ctx, lb)
+	}, attribute.String("load_balancer.id", lb.ID))
+	return err
+}
+
+func DeleteNetworkActivity(ctx context.Context, config providers.CloudConfig, network *providers.NetworkResult) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("DeleteNetworkActivity", "provider", config.Provider, "networkId", network.ID)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	_, err = callProvider(ctx, config, "DeleteNetwork", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, cloudProvider.DeleteNetwork(ctx, network)
+	}, attribute.String("network.id", network.ID))
+	return err
+}
+
+func DeleteSecurityGroupActivity(ctx context.Context, config providers.CloudConfig, group *providers.SecurityGroupResult) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("DeleteSecurityGroupActivity", "provider", config.Provider, "securityGroupId", group.ID)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	_, err = callProvider(ctx, config, "DeleteSecurityGroup", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, cloudProvider.DeleteSecurityGroup(ctx, group)
+	}, attribute.String("security_group.id", group.ID))
+	return err
+}
+
+func DeleteProjectActivity(ctx context.Context, config providers.CloudConfig, project *providers.ProjectResult) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("DeleteProjectActivity", "provider", config.Provider, "projectId", project.ID)
+
+	cloudProvider, err := config.GetProvider()
+	if err != nil {
+		return fmt.Errorf("error initializing provider: %w", err)
+	}
+
+	_, err = callProvider(ctx, config, "DeleteProject", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, cloudProvider.DeleteProject(ctx, project)
+	}, attribute.String("project.id", project.ID))
+	return err
 }
@@ -20,14 +20,31 @@ package workflow_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/mrsimonemms/temporal/pkg/notify"
 	"github.com/mrsimonemms/temporal/pkg/providers"
 	"github.com/mrsimonemms/temporal/pkg/workflow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
 )
 
 type MockedProvider struct {
@@ -39,12 +56,34 @@ func (m *MockedProvider) CheckNodeReady(ctx context.Context, node *providers.Nod
 	return args.Error(0)
 }
 
+func (m *MockedProvider) CheckTargetHealthy(
+	ctx context.Context,
+	lb *providers.LoadBalancerResult,
+	node *providers.NodeResult,
+) error {
+	args := m.Called(node)
+	return args.Error(0)
+}
+
+func (m *MockedProvider) CreateLoadBalancer(
+	ctx context.Context,
+	network *providers.NetworkResult,
+	lb *providers.LoadBalancer,
+) (*providers.LoadBalancerResult, error) {
+	args := m.Called()
+	return args.Get(0).(*providers.LoadBalancerResult), args.Error(1)
+}
+
 func (m *MockedProvider) CreateNetwork(ctx context.Context, project *providers.ProjectResult) (*providers.NetworkResult, error) {
 	args := m.Called()
 	return args.Get(0).(*providers.NetworkResult), args.Error(1)
 }
 
-func (m *MockedProvider) CreateNode(ctx context.Context, project *providers.ProjectResult) (*providers.NodeResult, error) {
+func (m *MockedProvider) CreateNode(
+	ctx context.Context,
+	project *providers.ProjectResult,
+	node *providers.PlannedNode,
+) (*providers.NodeResult, error) {
 	args := m.Called()
 	return args.Get(0).(*providers.NodeResult), args.Error(1)
 }
@@ -54,6 +93,54 @@ func (m *MockedProvider) CreateProject(ctx context.Context) (*providers.ProjectR
 	return args.Get(0).(*providers.ProjectResult), args.Error(1)
 }
 
+func (m *MockedProvider) CreateSecurityGroup(
+	ctx context.Context,
+	network *providers.NetworkResult,
+	group *providers.SecurityGroup,
+) (*providers.SecurityGroupResult, error) {
+	args := m.Called()
+	return args.Get(0).(*providers.SecurityGroupResult), args.Error(1)
+}
+
+func (m *MockedProvider) DeleteLoadBalancer(ctx context.Context, lb *providers.LoadBalancerResult) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockedProvider) DeleteNetwork(ctx context.Context, network *providers.NetworkResult) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockedProvider) DeleteNode(ctx context.Context, node *providers.NodeResult) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockedProvider) DeleteProject(ctx context.Context, project *providers.ProjectResult) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockedProvider) DeleteSecurityGroup(ctx context.Context, group *providers.SecurityGroupResult) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockedProvider) Plan(ctx context.Context) (*providers.Plan, error) {
+	args := m.Called()
+	return args.Get(0).(*providers.Plan), args.Error(1)
+}
+
+func (m *MockedProvider) SetLoadBalancerTargets(
+	ctx context.Context,
+	lb *providers.LoadBalancerResult,
+	nodes []*providers.NodeResult,
+) (*providers.LoadBalancerResult, error) {
+	args := m.Called(nodes)
+	return args.Get(0).(*providers.LoadBalancerResult), args.Error(1)
+}
+
 func Test_CreateProjectActivity(t *testing.T) {
 	tests := []struct {
 		Name   string
@@ -115,6 +202,69 @@ func Test_CreateProjectActivity(t *testing.T) {
 	}
 }
 
+func Test_PlanProjectActivity(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Result *providers.Plan
+		Err    error
+	}{
+		{
+			Name: "valid provider",
+			Result: &providers.Plan{
+				Project: providers.PlannedProject{Name: "some-project"},
+			},
+		},
+		{
+			Name: "invalid provider",
+			Err:  fmt.Errorf("some error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Create the test suite
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+			env.RegisterActivity(workflow.PlanProjectActivity)
+
+			config := providers.CloudConfig{}
+
+			// Create a mocked provider
+			mockedProvider := new(MockedProvider)
+
+			// Mock the GetProvider function and restore after run
+			orig := providers.GetProvider
+			defer func() {
+				providers.GetProvider = orig
+			}()
+			providers.GetProvider = func(c providers.CloudConfig) (providers.Provider, error) {
+				return mockedProvider, test.Err
+			}
+
+			mockedProvider.On("Plan").Return(test.Result, nil)
+
+			val, err := env.ExecuteActivity(workflow.PlanProjectActivity, config)
+
+			if test.Result != nil {
+				assert.NoError(err)
+
+				var plan *providers.Plan
+				assert.NoError(val.Get(&plan))
+				assert.Equal(plan, test.Result)
+
+				mockedProvider.AssertExpectations(t)
+				mockedProvider.AssertCalled(t, "Plan")
+			}
+
+			if test.Err != nil {
+				assert.ErrorContains(err, test.Err.Error())
+			}
+		})
+	}
+}
+
 func Test_SetupNetworkActivity(t *testing.T) {
 	tests := []struct {
 		Name   string
@@ -281,7 +431,7 @@ func Test_ProvisionNodeActivity(t *testing.T) {
 
 			mockedProvider.On("CreateNode").Return(test.Result, nil)
 
-			val, err := env.ExecuteActivity(workflow.ProvisionNodeActivity, config, project)
+			val, err := env.ExecuteActivity(workflow.ProvisionNodeActivity, config, project, &providers.PlannedNode{})
 
 			if test.Result != nil {
 				assert.NoError(err)
@@ -300,3 +450,439 @@ func Test_ProvisionNodeActivity(t *testing.T) {
 		})
 	}
 }
+
+func Test_CreateProjectActivityRateLimited(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+	env.RegisterActivity(workflow.CreateProjectActivity)
+
+	mockedProvider := new(MockedProvider)
+
+	orig := providers.GetProvider
+	defer func() {
+		providers.GetProvider = orig
+	}()
+	providers.GetProvider = func(c providers.CloudConfig) (providers.Provider, error) {
+		return mockedProvider, nil
+	}
+
+	mockedProvider.On("CreateProject").Return((*providers.ProjectResult)(nil), &providers.RateLimitError{RetryAfter: time.Second})
+
+	_, err := env.ExecuteActivity(workflow.CreateProjectActivity, providers.CloudConfig{})
+
+	var appErr *temporal.ApplicationError
+	assert.ErrorAs(err, &appErr)
+	assert.Equal("RateLimited", appErr.Type())
+	assert.Equal(time.Second, appErr.NextRetryDelay())
+}
+
+func Test_ProvisionNodeActivityTracing(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+	env.RegisterActivity(workflow.ProvisionNodeActivity)
+
+	mockedProvider := new(MockedProvider)
+
+	orig := providers.GetProvider
+	defer func() {
+		providers.GetProvider = orig
+	}()
+	providers.GetProvider = func(c providers.CloudConfig) (providers.Provider, error) {
+		return mockedProvider, nil
+	}
+
+	mockedProvider.On("CreateNode").Return(&providers.NodeResult{ID: "node-id"}, nil)
+
+	config := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Region:   "eu-west-2",
+	}
+	project := &providers.ProjectResult{ID: "project-id"}
+
+	_, err := env.ExecuteActivity(workflow.ProvisionNodeActivity, config, project, &providers.PlannedNode{Name: "node"})
+	assert.NoError(err)
+
+	spans := recorder.Ended()
+	assert.Len(spans, 1)
+	assert.Equal("aws CreateNode", spans[0].Name())
+	assert.ElementsMatch([]attribute.KeyValue{
+		attribute.String("provider", "aws"),
+		attribute.String("region", "eu-west-2"),
+		attribute.String("operation", "CreateNode"),
+		attribute.String("project.id", "project-id"),
+		attribute.String("node.name", "node"),
+		attribute.String("node.id", "node-id"),
+	}, spans[0].Attributes())
+}
+
+func Test_SetLoadBalancerTargetsActivity(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+	env.RegisterActivity(workflow.SetLoadBalancerTargetsActivity)
+
+	mockedProvider := new(MockedProvider)
+
+	orig := providers.GetProvider
+	defer func() {
+		providers.GetProvider = orig
+	}()
+	providers.GetProvider = func(c providers.CloudConfig) (providers.Provider, error) {
+		return mockedProvider, nil
+	}
+
+	lb := &providers.LoadBalancerResult{ID: "lb-id", Pool: "web"}
+	nodes := []*providers.NodeResult{{ID: "node-0", Pool: "web"}}
+	expected := &providers.LoadBalancerResult{ID: "lb-id", Pool: "web", Targets: []string{"node-0"}}
+
+	mockedProvider.On("SetLoadBalancerTargets", nodes).Return(expected, nil)
+
+	val, err := env.ExecuteActivity(workflow.SetLoadBalancerTargetsActivity, providers.CloudConfig{}, lb, nodes)
+	assert.NoError(err)
+
+	var result *providers.LoadBalancerResult
+	assert.NoError(val.Get(&result))
+	assert.Equal(expected, result)
+
+	mockedProvider.AssertExpectations(t)
+}
+
+func Test_AwaitForTargetHealthyActivity(t *testing.T) {
+	tests := []struct {
+		Name string
+		Err  error
+	}{
+		{
+			Name: "healthy",
+		},
+		{
+			Name: "unhealthy",
+			Err:  errors.New("unhealthy"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+			env.RegisterActivity(workflow.AwaitForTargetHealthyActivity)
+
+			mockedProvider := new(MockedProvider)
+
+			orig := providers.GetProvider
+			defer func() {
+				providers.GetProvider = orig
+			}()
+			providers.GetProvider = func(c providers.CloudConfig) (providers.Provider, error) {
+				return mockedProvider, nil
+			}
+
+			lb := &providers.LoadBalancerResult{ID: "lb-id", Pool: "web", Targets: []string{"node-0"}}
+			node := &providers.NodeResult{ID: "node-0", Pool: "web"}
+
+			mockedProvider.On("CheckTargetHealthy", node).Return(test.Err)
+
+			_, err := env.ExecuteActivity(workflow.AwaitForTargetHealthyActivity, providers.CloudConfig{}, lb, node)
+			if test.Err != nil {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+
+			mockedProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_DeleteActivities(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Method   string
+		Activity any
+		Args     []any
+	}{
+		{
+			Name:     "load balancer",
+			Method:   "DeleteLoadBalancer",
+			Activity: workflow.DeleteLoadBalancerActivity,
+			Args:     []any{&providers.LoadBalancerResult{ID: "lb-id"}},
+		},
+		{
+			Name:     "node",
+			Method:   "DeleteNode",
+			Activity: workflow.DeleteNodeActivity,
+			Args:     []any{&providers.NodeResult{ID: "node-id"}},
+		},
+		{
+			Name:     "security group",
+			Method:   "DeleteSecurityGroup",
+			Activity: workflow.DeleteSecurityGroupActivity,
+			Args:     []any{&providers.SecurityGroupResult{ID: "sg-id"}},
+		},
+		{
+			Name:     "network",
+			Method:   "DeleteNetwork",
+			Activity: workflow.DeleteNetworkActivity,
+			Args:     []any{&providers.NetworkResult{ID: "network-id"}},
+		},
+		{
+			Name:     "project",
+			Method:   "DeleteProject",
+			Activity: workflow.DeleteProjectActivity,
+			Args:     []any{&providers.ProjectResult{ID: "project-id"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+			env.RegisterActivity(test.Activity)
+
+			mockedProvider := new(MockedProvider)
+
+			orig := providers.GetProvider
+			defer func() {
+				providers.GetProvider = orig
+			}()
+			providers.GetProvider = func(c providers.CloudConfig) (providers.Provider, error) {
+				return mockedProvider, nil
+			}
+
+			mockedProvider.On(test.Method).Return(nil)
+
+			_, err := env.ExecuteActivity(test.Activity, append([]any{providers.CloudConfig{}}, test.Args...)...)
+			assert.NoError(err)
+
+			mockedProvider.AssertCalled(t, test.Method)
+		})
+	}
+}
+
+func Test_InventoryActivities(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+
+	store := inventory.NewBoltStore(filepath.Join(t.TempDir(), "inventory.db"))
+	activities := &workflow.InventoryActivities{Store: store}
+	env.RegisterActivity(activities)
+
+	record := &inventory.Record{
+		Kind:      inventory.KindNode,
+		ID:        "node-id",
+		ProjectID: "project-id",
+		CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	_, err := env.ExecuteActivity(activities.RecordResourcesActivity, []*inventory.Record{record})
+	assert.NoError(err)
+
+	deletedAt := record.CreatedAt.Add(time.Hour)
+	_, err = env.ExecuteActivity(activities.RecordDeletionsActivity, []inventory.Deletion{
+		{ID: record.ID, WorkflowID: "teardown-id", DeletedAt: deletedAt},
+	})
+	assert.NoError(err)
+
+	got, err := store.Get(context.Background(), record.ID)
+	assert.NoError(err)
+	assert.Equal(record.ProjectID, got.ProjectID)
+	assert.Equal("teardown-id", got.DeletedBy)
+	assert.True(deletedAt.Equal(*got.DeletedAt))
+}
+
+// Keeps the audit events in memory, failing if there's an error
+type memorySink struct {
+	mu     sync.Mutex
+	events []*audit.Event
+	err    error
+}
+
+func (m *memorySink) Write(_ context.Context, event *audit.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+func Test_AuditedActivity(t *testing.T) {
+	template := audit.Event{
+		Actor:        "alice",
+		Operation:    audit.OperationCreateNode,
+		ResourceName: "silent-butterfly",
+		ProjectID:    "project-id",
+		WorkflowID:   "workflow-id",
+		RunID:        "run-id",
+	}
+	node := &providers.NodeResult{ID: "node-id", Name: "silent-butterfly"}
+
+	// An event from a previous attempt which made the change
+	previous := template
+	previous.ID = audit.EventID("workflow-id", "run-id", audit.OperationCreateNode, "node-id", 1)
+	previous.ResourceID = "node-id"
+	previous.Attempt = 1
+	previous.Result = audit.ResultSuccess
+
+	tests := []struct {
+		Name        string
+		Heartbeat   any
+		ProviderErr error
+		SinkErr     error
+		Called      bool
+		Expected    *audit.Event
+		Err         bool
+		Heartbeated bool
+	}{
+		{
+			Name:     "change recorded",
+			Called:   true,
+			Expected: &previous,
+		},
+		{
+			Name:        "failure recorded",
+			ProviderErr: temporal.NewNonRetryableApplicationError("some error", "some-type", nil),
+			Called:      true,
+			Expected: &audit.Event{
+				ID:     audit.EventID("workflow-id", "run-id", audit.OperationCreateNode, "silent-butterfly", 1),
+				Result: audit.ResultFailure,
+			},
+			Err: true,
+		},
+		{
+			Name:        "change not recorded",
+			SinkErr:     fmt.Errorf("some error"),
+			Called:      true,
+			Err:         true,
+			Heartbeated: true,
+		},
+		{
+			Name: "change from previous attempt recorded",
+			Heartbeat: map[string]any{
+				"Result": node,
+				"Events": []*audit.Event{&previous},
+			},
+			Expected: &previous,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			sink := &memorySink{err: test.SinkErr}
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+			env.SetWorkerOptions(worker.Options{
+				Interceptors: []interceptor.WorkerInterceptor{&workflow.AuditInterceptor{Sink: sink}},
+			})
+			env.RegisterActivity(workflow.ProvisionNodeActivity)
+
+			payload, err := converter.GetDefaultDataConverter().ToPayload([]audit.Event{template})
+			assert.NoError(err)
+			env.SetHeader(&commonpb.Header{Fields: map[string]*commonpb.Payload{workflow.AuditHeader: payload}})
+
+			if test.Heartbeat != nil {
+				env.SetHeartbeatDetails(test.Heartbeat)
+			}
+			heartbeated := false
+			env.SetOnActivityHeartbeatListener(func(*activity.Info, converter.EncodedValues) {
+				heartbeated = true
+			})
+
+			mockedProvider := new(MockedProvider)
+			orig := providers.GetProvider
+			defer func() {
+				providers.GetProvider = orig
+			}()
+			providers.GetProvider = func(c providers.CloudConfig) (providers.Provider, error) {
+				return mockedProvider, nil
+			}
+			if test.Called {
+				mockedProvider.On("CreateNode").Return(node, test.ProviderErr).Once()
+			}
+
+			val, err := env.ExecuteActivity(
+				workflow.ProvisionNodeActivity,
+				providers.CloudConfig{},
+				&providers.ProjectResult{ID: "project-id"},
+				&providers.PlannedNode{Name: "silent-butterfly"},
+			)
+			mockedProvider.AssertExpectations(t)
+			assert.Equal(test.Heartbeated, heartbeated)
+
+			if test.Err {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+
+				var result *providers.NodeResult
+				assert.NoError(val.Get(&result))
+				assert.Equal(node, result)
+			}
+
+			if test.Expected == nil {
+				assert.Empty(sink.events)
+				return
+			}
+
+			assert.Len(sink.events, 1)
+			event := sink.events[0]
+			assert.Equal(test.Expected.ID, event.ID)
+			assert.Equal(test.Expected.Result, event.Result)
+			assert.Equal("alice", event.Actor)
+			assert.Equal("project-id", event.ProjectID)
+			assert.Equal(int32(1), event.Attempt)
+		})
+	}
+}
+
+type notifierFunc func(ctx context.Context, event *notify.Event) error
+
+func (f notifierFunc) Notify(ctx context.Context, event *notify.Event) error {
+	return f(ctx, event)
+}
+
+func Test_NotifyActivity(t *testing.T) {
+	assert := assert.New(t)
+
+	called := make([]int, 0)
+	target := func(i int, err error) notify.Notifier {
+		return notifierFunc(func(context.Context, *notify.Event) error {
+			called = append(called, i)
+			return err
+		})
+	}
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+
+	activities := &workflow.NotificationActivities{
+		Notifier: notify.Multi{target(0, nil), target(1, errors.New("some error")), target(2, nil)},
+	}
+	env.RegisterActivity(activities)
+
+	// A retry only sends to the targets which haven't been sent to
+	env.SetHeartbeatDetails(map[int]bool{0: true})
+
+	_, err := env.ExecuteActivity(activities.NotifyActivity, &notify.Event{Type: notify.EventStarted})
+	assert.ErrorContains(err, "some error")
+	assert.Equal([]int{1, 2}, called)
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/mrsimonemms/temporal/pkg/notify"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	ApproveUpdate = "approve"
+	RejectUpdate  = "reject"
+	ApprovalQuery = "approval"
+)
+
+// ApprovalInput is sent by the approver with the approve/reject update
+type ApprovalInput struct {
+	Approver string
+	Comment  string
+}
+
+// ApprovalRequest is the plan that is presented to the approver
+type ApprovalRequest struct {
+	Plan     *providers.Plan
+	Reasons  []string
+	Decision *providers.ApprovalDecision
+}
+
+// NewApprovalRequest compares the plan against the worker's approval policy.
+// If there are no reasons, approval is not required.
+func NewApprovalRequest(plan *providers.Plan, policy providers.ApprovalPolicy) *ApprovalRequest {
+	req := &ApprovalRequest{
+		Plan:    plan,
+		Reasons: make([]string, 0),
+	}
+
+	if plan == nil {
+		return req
+	}
+
+	nodeCount := len(plan.Nodes)
+	if policy.MaxNodes > 0 && nodeCount > policy.MaxNodes {
+		req.Reasons = append(req.Reasons, fmt.Sprintf("%d nodes requested exceeds limit of %d", nodeCount, policy.MaxNodes))
+	}
+
+	instanceTypes := make([]string, 0)
+	for _, node := range plan.Nodes {
+		if slices.Contains(policy.InstanceTypes, node.InstanceType) && !slices.Contains(instanceTypes, node.InstanceType) {
+			instanceTypes = append(instanceTypes, node.InstanceType)
+			req.Reasons = append(req.Reasons, fmt.Sprintf("instance type %s requires approval", node.InstanceType))
+		}
+	}
+
+	return req
+}
+
+// Required returns whether the request needs a human to sign it off
+func (a *ApprovalRequest) Required() bool {
+	return len(a.Reasons) > 0
+}
+
+// Block the workflow until the approval decision has been made. If no approval
+// is required, a nil decision is returned.
+func awaitApproval(ctx workflow.Context, cfg providers.CloudConfig, policy providers.ApprovalPolicy) (*providers.ApprovalDecision, error) {
+	logger := workflow.GetLogger(ctx)
+
+	req := NewApprovalRequest(cfg.Plan, policy)
+
+	if err := workflow.SetQueryHandler(ctx, ApprovalQuery, func() (*ApprovalRequest, error) {
+		return req, nil
+	}); err != nil {
+		return nil, fmt.Errorf("error setting approval query handler: %w", err)
+	}
+
+	if !req.Required() {
+		logger.Debug("Approval not required")
+		return nil, nil
+	}
+
+	logger.Info("Awaiting approval", "reasons", req.Reasons)
+	setPhase(ctx, PhaseAwaitingApproval)
+	sendNotification(ctx, cfg, notify.Event{
+		Type:    notify.EventApprovalRequired,
+		Reasons: req.Reasons,
+	})
+
+	for _, name := range []string{ApproveUpdate, RejectUpdate} {
+		approved := name == ApproveUpdate
+		handler := func(ctx workflow.Context, input ApprovalInput) (*providers.ApprovalDecision, error) {
+			req.Decision = &providers.ApprovalDecision{
+				Approved:  approved,
+				Approver:  input.Approver,
+				Comment:   input.Comment,
+				Timestamp: workflow.Now(ctx),
+			}
+			return req.Decision, nil
+		}
+
+		if err := workflow.SetUpdateHandlerWithOptions(ctx, name, handler, workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, input ApprovalInput) error {
+				if req.Decision != nil {
+					return fmt.Errorf("decision already made by %s", req.Decision.Approver)
+				}
+				if input.Approver == "" {
+					return fmt.Errorf("approver is required")
+				}
+				return nil
+			},
+		}); err != nil {
+			return nil, fmt.Errorf("error setting %s update handler: %w", name, err)
+		}
+	}
+
+	decided := func() bool { return req.Decision != nil }
+
+	if policy.Timeout > 0 {
+		ok, err := workflow.AwaitWithTimeout(ctx, policy.Timeout, decided)
+		if err != nil {
+			return nil, fmt.Errorf("error awaiting approval: %w", err)
+		}
+		if !ok {
+			return nil, temporal.NewNonRetryableApplicationError("approval timed out", "ApprovalTimeout", nil)
+		}
+	} else if err := workflow.Await(ctx, decided); err != nil {
+		return nil, fmt.Errorf("error awaiting approval: %w", err)
+	}
+
+	if !req.Decision.Approved {
+		logger.Info("Provisioning rejected", "approver", req.Decision.Approver)
+		return req.Decision, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("provisioning rejected by %s: %s", req.Decision.Approver, req.Decision.Comment),
+			"ApprovalRejected",
+			nil,
+			req.Decision,
+		)
+	}
+
+	logger.Info("Provisioning approved", "approver", req.Decision.Approver)
+
+	return req.Decision, nil
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// TriggeredByMemo is the memo key of who started the workflow. The child
+// workflows are given the same memo.
+//
+// This is set by the client that starts the workflow and isn't authenticated,
+// so it's who the client says they are rather than who they've proved to be.
+const TriggeredByMemo = "triggeredBy"
+
+// AuditHeader is the activity header with the changes the activity will make
+const AuditHeader = "audit"
+
+type auditContextKey struct{}
+
+// Who the workflow is acting for. The memo isn't encrypted so can be read with
+// the default data converter.
+func actor(ctx workflow.Context) string {
+	memo := workflow.GetInfo(ctx).Memo
+	if memo == nil {
+		return "unknown"
+	}
+
+	payload, ok := memo.GetFields()[TriggeredByMemo]
+	if !ok {
+		return "unknown"
+	}
+
+	var triggeredBy string
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &triggeredBy); err != nil {
+		workflow.GetLogger(ctx).Warn("Unable to decode memo", "key", TriggeredByMemo, "error", err)
+		return "unknown"
+	}
+	return triggeredBy
+}
+
+// Audit the changes made by the activity executed with the returned context.
+// The caller sets the operation and the resource - the rest is taken from the
+// workflow, and the resource ID from the activity's result if it's not set.
+//
+// The events are recorded by the activity on every attempt, so use this for
+// a single activity call rather than keeping the context.
+func withAudit(ctx workflow.Context, cfg providers.CloudConfig, events ...audit.Event) workflow.Context {
+	info := workflow.GetInfo(ctx)
+	triggeredBy := actor(ctx)
+
+	for i := range events {
+		events[i].Actor = triggeredBy
+		events[i].Provider = cfg.Provider
+		events[i].Region = cfg.Region
+		events[i].WorkflowID = info.WorkflowExecution.ID
+		events[i].RunID = info.WorkflowExecution.RunID
+	}
+
+	return workflow.WithValue(ctx, auditContextKey{}, events)
+}
+
+// AuditInterceptor records the changes made by the activities in the sink.
+// The workflow sends the changes it's expecting in the activity's header, and
+// the activity records the result of each attempt. Nothing is recorded if
+// there is no sink.
+type AuditInterceptor struct {
+	interceptor.WorkerInterceptorBase
+
+	Sink audit.Sink
+}
+
+func (a *AuditInterceptor) InterceptWorkflow(
+	ctx workflow.Context,
+	next interceptor.WorkflowInboundInterceptor,
+) interceptor.WorkflowInboundInterceptor {
+	i := &auditWorkflowInboundInterceptor{}
+	i.Next = next
+	return i
+}
+
+func (a *AuditInterceptor) InterceptActivity(
+	ctx context.Context,
+	next interceptor.ActivityInboundInterceptor,
+) interceptor.ActivityInboundInterceptor {
+	i := &auditActivityInboundInterceptor{sink: a.Sink}
+	i.Next = next
+	return i
+}
+
+type auditWorkflowInboundInterceptor struct {
+	interceptor.WorkflowInboundInterceptorBase
+}
+
+func (a *auditWorkflowInboundInterceptor) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	i := &auditWorkflowOutboundInterceptor{}
+	i.Next = outbound
+	return a.Next.Init(i)
+}
+
+type auditWorkflowOutboundInterceptor struct {
+	interceptor.WorkflowOutboundInterceptorBase
+}
+
+func (a *auditWorkflowOutboundInterceptor) ExecuteActivity(
+	ctx workflow.Context,
+	activityType string,
+	args ...any,
+) workflow.Future {
+	if events, ok := ctx.Value(auditContextKey{}).([]audit.Event); ok {
+		payload, err := converter.GetDefaultDataConverter().ToPayload(events)
+		if err != nil {
+			workflow.GetLogger(ctx).Error("Unable to encode audit events", "activity", activityType, "error", err)
+		} else {
+			interceptor.WorkflowHeader(ctx)[AuditHeader] = payload
+		}
+	}
+	return a.Next.ExecuteActivity(ctx, activityType, args...)
+}
+
+type auditActivityInboundInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+
+	sink audit.Sink
+}
+
+func (a *auditActivityInboundInterceptor) ExecuteActivity(
+	ctx context.Context,
+	in *interceptor.ExecuteActivityInput,
+) (any, error) {
+	if payload, ok := interceptor.Header(ctx)[AuditHeader]; ok && a.sink != nil {
+		var events []audit.Event
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &events); err != nil {
+			// Nothing can be changed without being recorded
+			return nil, temporal.NewNonRetryableApplicationError("unable to decode audit events", "InvalidAuditEvents", err)
+		}
+		ctx = context.WithValue(ctx, auditContextKey{}, &auditor{sink: a.sink, events: events})
+	}
+	return a.Next.ExecuteActivity(ctx, in)
+}
+
+// The changes the activity is making and where they're recorded
+type auditor struct {
+	sink   audit.Sink
+	events []audit.Event
+}
+
+// The events for the changes made by this attempt of the activity, which is
+// nil if the activity isn't audited. The event ID includes the attempt so
+// each attempt is recorded.
+func auditEvents(ctx context.Context, resourceID string, opErr error) []*audit.Event {
+	a, ok := ctx.Value(auditContextKey{}).(*auditor)
+	if !ok {
+		return nil
+	}
+
+	info := activity.GetInfo(ctx)
+
+	events := make([]*audit.Event, 0, len(a.events))
+	for _, e := range a.events {
+		if e.ResourceID == "" {
+			e.ResourceID = resourceID
+		}
+		if e.Operation == audit.OperationCreateProject {
+			e.ProjectID = e.ResourceID
+		}
+
+		resource := e.ResourceID
+		if resource == "" {
+			resource = e.ResourceName
+		}
+
+		e.ID = audit.EventID(e.WorkflowID, e.RunID, e.Operation, resource, info.Attempt)
+		e.Time = time.Now()
+		e.Attempt = info.Attempt
+		e.Result = audit.ResultSuccess
+		if opErr != nil {
+			e.Result = audit.ResultFailure
+			e.Error = opErr.Error()
+		}
+		events = append(events, &e)
+	}
+	return events
+}
+
+// Write the events to the sink
+func writeAudit(ctx context.Context, events []*audit.Event) error {
+	a, ok := ctx.Value(auditContextKey{}).(*auditor)
+	if !ok {
+		return nil
+	}
+
+	logger := activity.GetLogger(ctx)
+	for _, event := range events {
+		logger.Debug("Recording audit event", "id", event.ID, "operation", event.Operation)
+
+		if err := a.sink.Write(ctx, event); err != nil {
+			return fmt.Errorf("error recording audit event: %w", err)
+		}
+	}
+	return nil
+}
+
+// Record the changes made by this attempt of the activity. This returns an
+// error if a successful change couldn't be recorded, so the activity is
+// retried. Use this for changes which are safe to make again - anything else
+// is recorded by callProvider.
+func recordAttempt(ctx context.Context, resourceID string, opErr error) error {
+	if err := writeAudit(ctx, auditEvents(ctx, resourceID, opErr)); err != nil {
+		if opErr == nil {
+			return err
+		}
+		// The activity is retried anyway
+		activity.GetLogger(ctx).Error("Unable to record failed attempt", "error", err)
+	}
+	return opErr
+}
+
+// The ID of a resource, which is empty if the resource wasn't created
+func resourceID(res any) string {
+	if attr, ok := resourceIDAttribute(res); ok {
+		return attr.Value.AsString()
+	}
+	return ""
+}
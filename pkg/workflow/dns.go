@@ -0,0 +1,185 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/dns"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// DNSActivities change the records in the DNS provider. This is configured on
+// the worker so the credentials are never in the workflow history.
+type DNSActivities struct {
+	Provider dns.Provider
+}
+
+// Used to reference the activity methods from the workflows
+var dnsActivities *DNSActivities
+
+func (d *DNSActivities) provider() (dns.Provider, error) {
+	if d.Provider == nil {
+		return nil, temporal.NewNonRetryableApplicationError("no dns provider is configured on the worker", "DNSNotConfigured", nil)
+	}
+	return d.Provider, nil
+}
+
+func (d *DNSActivities) UpsertDNSRecordsActivity(ctx context.Context, zone string, records []dns.Record) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("UpsertDNSRecordsActivity", "zone", zone, "records", len(records))
+
+	p, err := d.provider()
+	if err != nil {
+		return err
+	}
+	// Upserting is idempotent, so it's done again if it can't be recorded
+	return recordAttempt(ctx, "", dnsError(p.UpsertRecords(ctx, zone, records)))
+}
+
+func (d *DNSActivities) DeleteDNSRecordsActivity(ctx context.Context, zone string, records []dns.Record) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("DeleteDNSRecordsActivity", "zone", zone, "records", len(records))
+
+	p, err := d.provider()
+	if err != nil {
+		return err
+	}
+	// Deleting is idempotent, so it's done again if it can't be recorded
+	return recordAttempt(ctx, "", dnsError(p.DeleteRecords(ctx, zone, records)))
+}
+
+// Records which will never be accepted aren't retried
+func dnsError(err error) error {
+	if errors.Is(err, dns.ErrInvalidRecord) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "InvalidDNSRecord", err)
+	}
+	return err
+}
+
+// The records the project should have - "<node>.<project>.<zone>" for each
+// node and "<lb>.lb.<project>.<zone>" for each load balancer
+func wantedDNSRecords(cfg providers.CloudConfig, project *providers.ProjectResult) []dns.Record {
+	name := cfg.Name
+	if cfg.Plan != nil {
+		name = cfg.Plan.Project.Name
+	}
+
+	records := make([]dns.Record, 0, len(project.Nodes)+len(project.LoadBalancers))
+	for _, node := range project.Nodes {
+		records = append(records, dns.Record{
+			Name:    dns.RecordName(cfg.DNSZone, node.Name, name),
+			Type:    dns.RecordTypeA,
+			TTL:     cfg.DNSTTL,
+			Address: node.Address,
+		})
+	}
+	for _, lb := range project.LoadBalancers {
+		records = append(records, dns.Record{
+			Name:    dns.RecordName(cfg.DNSZone, lb.Name, "lb", name),
+			Type:    dns.RecordTypeA,
+			TTL:     cfg.DNSTTL,
+			Address: lb.Address,
+		})
+	}
+	return records
+}
+
+// Publish the addresses of the project's nodes and load balancers, and remove
+// the records of any which have gone or moved. ReplaceNodeWorkflow calls this
+// again once the new node is taking traffic.
+func syncDNSRecords(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	if cfg.DNSZone == "" {
+		return nil
+	}
+
+	logger := workflow.GetLogger(ctx)
+
+	wanted := wantedDNSRecords(cfg, project)
+	stale := make([]dns.Record, 0)
+	for _, r := range project.DNSRecords {
+		if !slices.ContainsFunc(wanted, func(w dns.Record) bool {
+			return w.Name == r.Name && w.Address.Equal(r.Address)
+		}) {
+			stale = append(stale, r)
+		}
+	}
+
+	// Keep track of the records before they're created, so they're cleaned
+	// up even if the response to the update is lost
+	project.DNSRecords = append(slices.Clone(wanted), stale...)
+
+	logger.Debug("Upsert DNS records", "zone", cfg.DNSZone, "records", len(wanted), "stale", len(stale))
+	if len(wanted) > 0 {
+		auditCtx := withDNSAudit(ctx, cfg, project, audit.OperationUpsertDNSRecord, wanted)
+		if err := workflow.ExecuteActivity(auditCtx, dnsActivities.UpsertDNSRecordsActivity, cfg.DNSZone, wanted).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+
+	if len(stale) > 0 {
+		auditCtx := withDNSAudit(ctx, cfg, project, audit.OperationDeleteDNSRecord, stale)
+		if err := workflow.ExecuteActivity(auditCtx, dnsActivities.DeleteDNSRecordsActivity, cfg.DNSZone, stale).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	project.DNSRecords = wanted
+
+	return nil
+}
+
+// Remove the project's records. These are deleted first so nothing resolves
+// to an address which is about to be released.
+func deleteDNSRecords(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	if len(project.DNSRecords) == 0 {
+		return nil
+	}
+
+	auditCtx := withDNSAudit(ctx, cfg, project, audit.OperationDeleteDNSRecord, project.DNSRecords)
+	if err := workflow.ExecuteActivity(auditCtx, dnsActivities.DeleteDNSRecordsActivity, cfg.DNSZone, project.DNSRecords).Get(ctx, nil); err != nil {
+		return err
+	}
+	project.DNSRecords = nil
+
+	return nil
+}
+
+// Each record is audited, although they're changed in a single update
+func withDNSAudit(
+	ctx workflow.Context,
+	cfg providers.CloudConfig,
+	project *providers.ProjectResult,
+	operation audit.Operation,
+	records []dns.Record,
+) workflow.Context {
+	events := make([]audit.Event, 0, len(records))
+	for _, r := range records {
+		events = append(events, audit.Event{
+			Operation:    operation,
+			ResourceID:   r.Name,
+			ResourceName: r.Address.String(),
+			ProjectID:    project.ID,
+		})
+	}
+	return withAudit(ctx, cfg, events...)
+}
@@ -0,0 +1,280 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/dns"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// Used to reference the DNS activity methods
+var dnsActivities *workflow.DNSActivities
+
+type MockedDNSProvider struct {
+	mock.Mock
+}
+
+func (m *MockedDNSProvider) UpsertRecords(ctx context.Context, zone string, records []dns.Record) error {
+	args := m.Called(zone, records)
+	return args.Error(0)
+}
+
+func (m *MockedDNSProvider) DeleteRecords(ctx context.Context, zone string, records []dns.Record) error {
+	args := m.Called(zone, records)
+	return args.Error(0)
+}
+
+func Test_CloudProvisionWorkflowDNS(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	events := mockAudit(env)
+	mockNotify(env)
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		DNSZone:  "example.com",
+		DNSTTL:   time.Minute,
+		Plan: &providers.Plan{
+			Project: providers.PlannedProject{Name: "My Project"},
+			Nodes:   []*providers.PlannedNode{{Name: "silent-butterfly", Pool: "web"}},
+			LoadBalancers: []providers.LoadBalancer{
+				{Name: "web", Pool: "web", Listeners: []providers.Listener{{Protocol: providers.LoadBalancerProtocolTCP, Port: 443}}},
+			},
+		},
+	}
+	expectedProject := &providers.ProjectResult{CloudConfig: cfg, ID: "some-id"}
+	expectedNetwork := &providers.NetworkResult{ID: "some-network-id"}
+	node := &providers.NodeResult{ID: "node-id", Name: "silent-butterfly", Pool: "web", Address: net.IPv4(10, 0, 0, 1)}
+	lb := &providers.LoadBalancerResult{ID: "lb-id", Name: "web", Pool: "web", Address: net.IPv4(10, 0, 0, 2)}
+
+	env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, mock.Anything).Return(&workflow.QuotaReservation{Granted: true}, nil)
+	env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(expectedProject, nil)
+	env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, expectedProject).Return(expectedNetwork, nil)
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, mock.Anything).Return(nil)
+	env.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
+	env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, cfg, mock.Anything, cfg.Plan.Nodes[0]).Return(node, nil).Once()
+	env.OnActivity(workflow.CreateLoadBalancerActivity, mock.Anything, cfg, expectedNetwork, mock.Anything).Return(lb, nil).Once()
+	env.OnActivity(workflow.SetLoadBalancerTargetsActivity, mock.Anything, cfg, lb, mock.Anything).Return(lb, nil).Once()
+	env.OnActivity(workflow.AwaitForTargetHealthyActivity, mock.Anything, cfg, lb, node).Return(nil).Once()
+
+	// Both records are published once everything is ready
+	expectedRecords := []dns.Record{
+		{Name: "silent-butterfly.my-project.example.com", Type: dns.RecordTypeA, TTL: time.Minute, Address: node.Address},
+		{Name: "web.lb.my-project.example.com", Type: dns.RecordTypeA, TTL: time.Minute, Address: lb.Address},
+	}
+	env.OnActivity(dnsActivities.UpsertDNSRecordsActivity, mock.Anything, "example.com", expectedRecords).Return(nil).Once()
+
+	mockPlan(env, cfg.Plan)
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(env.IsWorkflowCompleted())
+
+	var result *providers.ProjectResult
+	assert.NoError(env.GetWorkflowResult(&result))
+	assert.Len(result.DNSRecords, 2)
+	assert.Equal(expectedRecords[0].Name, result.DNSRecords[0].Name)
+
+	ops := make([]audit.Operation, 0, len(*events))
+	for _, e := range *events {
+		ops = append(ops, e.Operation)
+	}
+	assert.Equal([]audit.Operation{
+		audit.OperationCreateProject,
+		audit.OperationCreateNetwork,
+		audit.OperationCreateLoadBalancer,
+		audit.OperationUpdateLoadBalancer,
+		audit.OperationUpsertDNSRecord,
+		audit.OperationUpsertDNSRecord,
+	}, ops)
+
+	env.AssertExpectations(t)
+}
+
+func Test_TeardownWorkflowDNS(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	events := mockAudit(env)
+
+	req := workflow.TeardownRequest{WorkflowID: "provision-some-project"}
+	project := &providers.ProjectResult{
+		CloudConfig: providers.CloudConfig{
+			Provider: providers.CloudProviderAWS,
+			DNSZone:  "example.com",
+		},
+		ID:    "project-id",
+		Nodes: []*providers.NodeResult{{ID: "node-id", Name: "node"}},
+		DNSRecords: []dns.Record{
+			{Name: "node.project.example.com", Type: dns.RecordTypeA, Address: net.IPv4(10, 0, 0, 1)},
+		},
+	}
+
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, req).Return(project, nil)
+	env.OnActivity(dnsActivities.DeleteDNSRecordsActivity, mock.Anything, "example.com", project.DNSRecords).Return(nil).Once()
+	env.OnActivity(workflow.DeleteNodeActivity, mock.Anything, project.CloudConfig, project.Nodes[0]).Return(nil).Once()
+	env.OnActivity(workflow.DeleteProjectActivity, mock.Anything, project.CloudConfig, mock.Anything).Return(nil).Once()
+	env.OnActivity(quotaActivities.ReleaseQuotaActivity, mock.Anything, mock.Anything).Return(nil).Once()
+	env.OnActivity(inventoryActivities.RecordDeletionsActivity, mock.Anything, mock.Anything).Return(nil)
+
+	env.ExecuteWorkflow(workflow.TeardownWorkflow, req)
+	assert.True(env.IsWorkflowCompleted())
+	assert.NoError(env.GetWorkflowError())
+
+	// Nothing resolves to the node once it's deleted
+	assert.Equal(audit.OperationDeleteDNSRecord, (*events)[0].Operation)
+	assert.Equal("node.project.example.com", (*events)[0].ResourceID)
+	assert.Equal(audit.OperationDeleteNode, (*events)[1].Operation)
+
+	env.AssertExpectations(t)
+}
+
+func Test_DNSActivities(t *testing.T) {
+	records := []dns.Record{{Name: "node.project.example.com", Type: dns.RecordTypeA, Address: net.IPv4(10, 0, 0, 1)}}
+
+	tests := []struct {
+		Name         string
+		NoProvider   bool
+		Err          error
+		ErrType      string
+		NonRetryable bool
+	}{
+		{
+			Name: "success",
+		},
+		{
+			Name:         "no provider",
+			NoProvider:   true,
+			ErrType:      "DNSNotConfigured",
+			NonRetryable: true,
+		},
+		{
+			Name:         "invalid record",
+			Err:          fmt.Errorf("%w: some reason", dns.ErrInvalidRecord),
+			ErrType:      "InvalidDNSRecord",
+			NonRetryable: true,
+		},
+		{
+			Name: "server error",
+			Err:  errors.New("dns update rejected: SERVFAIL"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+
+			p := new(MockedDNSProvider)
+			p.On("UpsertRecords", "example.com", records).Return(test.Err)
+
+			activities := &workflow.DNSActivities{Provider: p}
+			if test.NoProvider {
+				activities.Provider = nil
+			}
+			env.RegisterActivity(activities)
+
+			_, err := env.ExecuteActivity(activities.UpsertDNSRecordsActivity, "example.com", records)
+			if test.Err == nil && test.ErrType == "" {
+				assert.NoError(err)
+				p.AssertExpectations(t)
+				return
+			}
+
+			var appErr *temporal.ApplicationError
+			assert.ErrorAs(err, &appErr)
+			assert.Equal(test.NonRetryable, appErr.NonRetryable())
+			if test.ErrType != "" {
+				assert.Equal(test.ErrType, appErr.Type())
+			}
+		})
+	}
+}
+
+func Test_ReplaceNodeWorkflowDNS(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	events := mockAudit(env)
+
+	project := newReplaceProject()
+	project.DNSZone = "example.com"
+	project.Plan.Project.Name = "my-project"
+	project.Nodes[0].Address = net.IPv4(10, 0, 0, 1)
+	project.Nodes[1].Address = net.IPv4(10, 0, 0, 2)
+	project.LoadBalancers[0].Address = net.IPv4(10, 0, 0, 3)
+	project.DNSRecords = []dns.Record{
+		{Name: "web-0.my-project.example.com", Type: dns.RecordTypeA, Address: project.Nodes[0].Address},
+		{Name: "web-1.my-project.example.com", Type: dns.RecordTypeA, Address: project.Nodes[1].Address},
+		{Name: "web.lb.my-project.example.com", Type: dns.RecordTypeA, Address: project.LoadBalancers[0].Address},
+	}
+	cfg := project.CloudConfig
+	newNode := &providers.NodeResult{ID: "new-node", Name: "web-0", Pool: "web", Address: net.IPv4(10, 0, 0, 4)}
+
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, mock.Anything).Return(project, nil)
+	env.OnActivity(workflow.ProvisionNodeActivity, mock.Anything, cfg, mock.Anything, cfg.Plan.Nodes[0]).Return(newNode, nil).Once()
+	env.OnActivity(workflow.AwaitForNodeRunningActivity, mock.Anything, cfg, newNode).Return(&providers.NodeReadyResult{Ready: true}, nil).Once()
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, mock.Anything).Return(nil)
+	mockSetTargets(env, cfg)
+	env.OnActivity(workflow.AwaitForTargetHealthyActivity, mock.Anything, cfg, mock.Anything, newNode).Return(nil).Once()
+
+	// The node's name is moved to the new node and the old record removed
+	expectedRecords := []dns.Record{
+		{Name: "web-1.my-project.example.com", Type: dns.RecordTypeA, Address: project.Nodes[1].Address},
+		{Name: "web-0.my-project.example.com", Type: dns.RecordTypeA, Address: newNode.Address},
+		{Name: "web.lb.my-project.example.com", Type: dns.RecordTypeA, Address: project.LoadBalancers[0].Address},
+	}
+	env.OnActivity(dnsActivities.UpsertDNSRecordsActivity, mock.Anything, "example.com", expectedRecords).Return(nil).Once()
+	env.OnActivity(dnsActivities.DeleteDNSRecordsActivity, mock.Anything, "example.com", project.DNSRecords[:1]).Return(nil).Once()
+
+	env.OnActivity(workflow.DeleteNodeActivity, mock.Anything, cfg, project.Nodes[0]).Return(nil).Once()
+	env.OnActivity(inventoryActivities.RecordDeletionsActivity, mock.Anything, mock.Anything).Return(nil).Once()
+
+	env.ExecuteWorkflow(workflow.ReplaceNodeWorkflow, workflow.ReplaceNodeRequest{
+		WorkflowID: "provision-some-project",
+		Node:       "web-0",
+	})
+	assert.True(env.IsWorkflowCompleted())
+
+	var result *workflow.ReplaceNodeResult
+	assert.NoError(env.GetWorkflowResult(&result))
+	assert.Equal(expectedRecords, result.DNSRecords)
+
+	// The name is moved before the old node is deleted
+	assert.Equal(audit.OperationDeleteNode, (*events)[len(*events)-1].Operation)
+	assert.Equal(audit.OperationDeleteDNSRecord, (*events)[len(*events)-2].Operation)
+
+	env.AssertExpectations(t)
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// InventoryActivities keep a record of the resources outside of Temporal so
+// they're not lost when the history is deleted. Nothing is recorded if there
+// is no store.
+type InventoryActivities struct {
+	Store inventory.Store
+}
+
+// Used to reference the activity methods from the workflows
+var inventoryActivities *InventoryActivities
+
+func (i *InventoryActivities) RecordResourcesActivity(ctx context.Context, records []*inventory.Record) error {
+	if i.Store == nil {
+		return nil
+	}
+
+	logger := activity.GetLogger(ctx)
+	for _, record := range records {
+		logger.Debug("Recording resource", "kind", record.Kind, "id", record.ID)
+		if err := i.Store.Put(ctx, record); err != nil {
+			return fmt.Errorf("error recording %s %s: %w", record.Kind, record.ID, err)
+		}
+	}
+	return nil
+}
+
+func (i *InventoryActivities) RecordDeletionsActivity(ctx context.Context, deletions []inventory.Deletion) error {
+	if i.Store == nil {
+		return nil
+	}
+
+	logger := activity.GetLogger(ctx)
+	for _, deletion := range deletions {
+		logger.Debug("Recording deletion", "id", deletion.ID)
+		if err := i.Store.MarkDeleted(ctx, deletion); err != nil {
+			return fmt.Errorf("error recording deletion of %s: %w", deletion.ID, err)
+		}
+	}
+	return nil
+}
+
+// The inventory belongs to the workflow workers, not the provider workers.
+// The inventory is a record, so failures are logged rather than stopping the
+// workflow.
+func inventoryContext(ctx workflow.Context) workflow.Context {
+	ctx = workflow.WithTaskQueue(ctx, workflow.GetInfo(ctx).TaskQueueName)
+	return workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    5,
+		},
+	})
+}
+
+func recordResources(ctx workflow.Context, records ...*inventory.Record) {
+	activityCtx := inventoryContext(ctx)
+	if err := workflow.ExecuteActivity(activityCtx, inventoryActivities.RecordResourcesActivity, records).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Warn("Unable to record resources in inventory", "error", err)
+	}
+}
+
+func recordDeletions(ctx workflow.Context, ids ...string) {
+	deletions := make([]inventory.Deletion, 0, len(ids))
+	for _, id := range ids {
+		deletions = append(deletions, inventory.Deletion{
+			ID:         id,
+			WorkflowID: workflow.GetInfo(ctx).WorkflowExecution.ID,
+			DeletedAt:  workflow.Now(ctx),
+		})
+	}
+
+	activityCtx := inventoryContext(ctx)
+	if err := workflow.ExecuteActivity(activityCtx, inventoryActivities.RecordDeletionsActivity, deletions).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Warn("Unable to record deletions in inventory", "error", err)
+	}
+}
+
+// Build a record of a resource. The nodes are created by child workflows, so
+// the owner is the parent provisioning workflow.
+func newRecord(
+	ctx workflow.Context,
+	cfg providers.CloudConfig,
+	kind inventory.Kind,
+	id, projectID string,
+) *inventory.Record {
+	execution := workflow.GetInfo(ctx).WorkflowExecution
+	if parent := workflow.GetInfo(ctx).ParentWorkflowExecution; parent != nil {
+		execution = *parent
+	}
+
+	return &inventory.Record{
+		Kind:        kind,
+		ID:          id,
+		ProjectID:   projectID,
+		Provider:    cfg.Provider,
+		Region:      cfg.Region,
+		Owner:       cfg.Owner,
+		Environment: cfg.Environment,
+		WorkflowID:  execution.ID,
+		RunID:       execution.RunID,
+		CreatedAt:   workflow.Now(ctx),
+	}
+}
+
+func projectRecord(ctx workflow.Context, project *providers.ProjectResult) *inventory.Record {
+	record := newRecord(ctx, project.CloudConfig, inventory.KindProject, project.ID, project.ID)
+	record.Name = project.Name
+	return record
+}
+
+func networkRecord(ctx workflow.Context, project *providers.ProjectResult, network *providers.NetworkResult) *inventory.Record {
+	record := newRecord(ctx, project.CloudConfig, inventory.KindNetwork, network.ID, project.ID)
+	if network.Subnet != nil {
+		record.Details = map[string]string{
+			"subnet":  network.Subnet.String(),
+			"subnets": strconv.Itoa(len(network.Subnets)),
+		}
+	}
+	return record
+}
+
+func securityGroupRecord(ctx workflow.Context, project *providers.ProjectResult, group *providers.SecurityGroupResult) *inventory.Record {
+	record := newRecord(ctx, project.CloudConfig, inventory.KindSecurityGroup, group.ID, project.ID)
+	record.Name = group.Name
+	record.Details = map[string]string{
+		"networkId": group.NetworkID,
+		"rules":     strconv.Itoa(len(group.Rules)),
+	}
+	return record
+}
+
+func loadBalancerRecord(ctx workflow.Context, project *providers.ProjectResult, lb *providers.LoadBalancerResult) *inventory.Record {
+	record := newRecord(ctx, project.CloudConfig, inventory.KindLoadBalancer, lb.ID, project.ID)
+	record.Name = lb.Name
+	record.Details = map[string]string{
+		"address":   lb.Address.String(),
+		"pool":      lb.Pool,
+		"listeners": strconv.Itoa(len(lb.Listeners)),
+	}
+	return record
+}
+
+func nodeRecord(ctx workflow.Context, cfg providers.CloudConfig, projectID string, node *providers.NodeResult) *inventory.Record {
+	record := newRecord(ctx, cfg, inventory.KindNode, node.ID, projectID)
+	record.Name = node.Name
+	record.CreatedAt = node.CreatedAt
+	record.Details = map[string]string{
+		"address":      node.Address.String(),
+		"pool":         node.Pool,
+		"zone":         node.Zone,
+		"instanceType": node.InstanceType,
+	}
+	return record
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Create the planned load balancers once the nodes are ready and register the
+// nodes as their targets. The load balancers are created in parallel and every
+// one is waited for, so the load balancers that were created are known even
+// if one has failed.
+func createLoadBalancers(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	loadBalancers := cfg.Plan.LoadBalancers
+	if len(loadBalancers) == 0 {
+		return nil
+	}
+
+	logger := workflow.GetLogger(ctx)
+	logger.Debug("Create load balancers in cloud provider", "count", len(loadBalancers))
+
+	providerCtx := withProviderTaskQueue(ctx, cfg)
+
+	futures := make([]workflow.Future, 0, len(loadBalancers))
+	for i := range loadBalancers {
+		auditCtx := withAudit(providerCtx, cfg, audit.Event{
+			Operation:    audit.OperationCreateLoadBalancer,
+			ResourceName: loadBalancers[i].Name,
+			ProjectID:    project.ID,
+		})
+		futures = append(futures, workflow.ExecuteActivity(auditCtx, CreateLoadBalancerActivity, cfg, project.Network, &loadBalancers[i]))
+	}
+
+	project.LoadBalancers = make([]*providers.LoadBalancerResult, 0, len(loadBalancers))
+	errs := make([]error, 0)
+	for i, future := range futures {
+		var lb *providers.LoadBalancerResult
+
+		if err := future.Get(ctx, &lb); err != nil {
+			logger.Error("Error creating load balancer", "name", loadBalancers[i].Name, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+
+		project.LoadBalancers = append(project.LoadBalancers, lb)
+		recordResources(ctx, loadBalancerRecord(ctx, project, lb))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error creating load balancers: %w", errors.Join(errs...))
+	}
+
+	return syncLoadBalancerTargets(ctx, cfg, project)
+}
+
+// Point each load balancer at the nodes currently in its pool. Only nodes
+// which are ready are in the project, so traffic is never sent to a node which
+// is still being provisioned. This replaces the targets, so it's safe to call
+// again whenever a pool's nodes change. The sync only succeeds once any new
+// targets are passing the health check, so a node which is being replaced is
+// only removed once its replacement is taking traffic.
+func syncLoadBalancerTargets(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	providerCtx := withProviderTaskQueue(ctx, cfg)
+
+	futures := make([]workflow.Future, 0, len(project.LoadBalancers))
+	added := make([][]*providers.NodeResult, 0, len(project.LoadBalancers))
+	for _, lb := range project.LoadBalancers {
+		targets := lb.PoolNodes(project.Nodes)
+		added = append(added, slices.DeleteFunc(slices.Clone(targets), func(n *providers.NodeResult) bool {
+			return slices.Contains(lb.Targets, n.ID)
+		}))
+
+		auditCtx := withAudit(providerCtx, cfg, audit.Event{
+			Operation:    audit.OperationUpdateLoadBalancer,
+			ResourceID:   lb.ID,
+			ResourceName: lb.Name,
+			ProjectID:    project.ID,
+		})
+		futures = append(futures, workflow.ExecuteActivity(auditCtx, SetLoadBalancerTargetsActivity, cfg, lb, targets))
+	}
+
+	errs := make([]error, 0)
+	healthy := make([]workflow.Future, 0)
+	for i, future := range futures {
+		var updated *providers.LoadBalancerResult
+		if err := future.Get(ctx, &updated); err != nil {
+			errs = append(errs, fmt.Errorf("error setting load balancer targets: %w", err))
+			continue
+		}
+		project.LoadBalancers[i] = updated
+
+		healthCtx := workflow.WithScheduleToCloseTimeout(providerCtx, targetHealthTimeout(updated.HealthCheck))
+		for _, node := range added[i] {
+			healthy = append(healthy, workflow.ExecuteActivity(healthCtx, AwaitForTargetHealthyActivity, cfg, updated, node))
+		}
+	}
+
+	for _, future := range healthy {
+		if err := future.Get(ctx, nil); err != nil {
+			errs = append(errs, fmt.Errorf("error waiting for target to become healthy: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// How long a new target has to pass the health check before it's given up on
+func targetHealthTimeout(hc providers.HealthCheck) time.Duration {
+	return time.Minute*5 + hc.Interval*time.Duration(hc.HealthyThreshold)
+}
+
+// Delete the project's load balancers. These are deleted before the nodes so
+// no traffic is sent to a node which is being deleted.
+func deleteLoadBalancers(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	futures := make([]workflow.Future, 0, len(project.LoadBalancers))
+	for _, lb := range project.LoadBalancers {
+		auditCtx := withAudit(ctx, cfg, audit.Event{
+			Operation:    audit.OperationDeleteLoadBalancer,
+			ResourceID:   lb.ID,
+			ResourceName: lb.Name,
+			ProjectID:    project.ID,
+		})
+		futures = append(futures, workflow.ExecuteActivity(auditCtx, DeleteLoadBalancerActivity, cfg, lb))
+	}
+
+	errs := make([]error, 0)
+	deleted := make([]string, 0, len(futures))
+	for i, future := range futures {
+		lb := project.LoadBalancers[i]
+
+		if err := future.Get(ctx, nil); err != nil {
+			errs = append(errs, fmt.Errorf("error deleting load balancer: %w", err))
+			continue
+		}
+		deleted = append(deleted, lb.ID)
+	}
+	if len(deleted) > 0 {
+		recordDeletions(ctx, deleted...)
+	}
+
+	return errors.Join(errs...)
+}
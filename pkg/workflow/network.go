@@ -0,0 +1,364 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+
+	"github.com/mrsimonemms/temporal/pkg/cidr"
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	CIDRAllocateUpdate = "allocate"
+	CIDRReleaseUpdate  = "release"
+	CIDRQuery          = "allocations"
+)
+
+// There is a single CIDR manager, so every project's range is allocated in
+// turn and two projects can't be given the same range
+const CIDRManagerWorkflowID = "cidr-manager"
+
+// CIDRRequest is the network range a project wants. If Auto is set, only the
+// size of CIDR is used and the range is picked from the supernet.
+type CIDRRequest struct {
+	CIDR     string
+	Auto     bool
+	Supernet string
+}
+
+// CIDRAllocation is a range held by a workflow
+type CIDRAllocation struct {
+	WorkflowID string
+	// The run the range is held for. This is empty for the networks recorded
+	// in the inventory.
+	RunID string `json:",omitempty"`
+	// The project which owns the range, if it's known
+	ProjectID string `json:",omitempty"`
+	CIDR      string
+}
+
+// CIDRAllocateRequest asks the CIDR manager for a range on behalf of a
+// workflow. The networks recorded in the inventory are sent with the request,
+// so the ranges of networks created before the manager are still avoided.
+type CIDRAllocateRequest struct {
+	CIDRRequest
+
+	WorkflowID string
+	RunID      string
+	Recorded   []CIDRAllocation
+}
+
+// CIDRReleaseRequest returns the range held by a run. Without a run ID, the
+// range is released whichever run holds it.
+type CIDRReleaseRequest struct {
+	WorkflowID string
+	RunID      string
+}
+
+// CIDRManagerState is carried over when the manager continues-as-new. A range
+// is held from when it's allocated until the workflow's network is deleted.
+type CIDRManagerState struct {
+	Allocations map[string]CIDRAllocation
+}
+
+// The ranges in use, sorted so the allocation is deterministic
+func (s *CIDRManagerState) used(recorded []CIDRAllocation) []CIDRAllocation {
+	used := slices.Clone(recorded)
+	for _, id := range slices.Sorted(maps.Keys(s.Allocations)) {
+		used = append(used, s.Allocations[id])
+	}
+	return used
+}
+
+// Check the range doesn't overlap any range in use, or pick a range which
+// doesn't
+func (s *CIDRManagerState) allocate(ctx workflow.Context, req CIDRAllocateRequest) (string, error) {
+	// Retried requests get the same range
+	if allocation, ok := s.Allocations[req.WorkflowID]; ok {
+		return allocation.CIDR, nil
+	}
+
+	_, want, err := net.ParseCIDR(req.CIDR)
+	if err != nil {
+		return "", temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid cidr: %s", err), "InvalidCIDR", err)
+	}
+
+	inUse := s.used(req.Recorded)
+	used := make([]*net.IPNet, 0, len(inUse))
+	owners := make([]CIDRAllocation, 0, len(inUse))
+	for _, allocation := range inUse {
+		if _, ipNet, err := net.ParseCIDR(allocation.CIDR); err == nil {
+			used = append(used, ipNet)
+			owners = append(owners, allocation)
+		}
+	}
+
+	allocated := want
+	if req.Auto {
+		_, supernet, err := net.ParseCIDR(req.Supernet)
+		if err != nil {
+			return "", temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid supernet: %s", err), "InvalidCIDR", err)
+		}
+
+		prefix, _ := want.Mask.Size()
+		allocated, err = cidr.FindFree(supernet, prefix, used)
+		if errors.Is(err, cidr.ErrNoFreeRange) {
+			return "", temporal.NewNonRetryableApplicationError(err.Error(), "NoFreeCIDR", err)
+		} else if err != nil {
+			return "", temporal.NewNonRetryableApplicationError(err.Error(), "InvalidCIDR", err)
+		}
+	} else {
+		for i, ipNet := range used {
+			if cidr.Overlaps(want, ipNet) {
+				owner := owners[i].ProjectID
+				if owner == "" {
+					owner = owners[i].WorkflowID
+				}
+				return "", temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf("%s overlaps network %s of project %s", want, ipNet, owner),
+					"CIDROverlap",
+					nil,
+				)
+			}
+		}
+	}
+
+	workflow.GetLogger(ctx).Info("Allocated network", "workflowId", req.WorkflowID, "cidr", allocated)
+	s.Allocations[req.WorkflowID] = CIDRAllocation{
+		WorkflowID: req.WorkflowID,
+		RunID:      req.RunID,
+		CIDR:       allocated.String(),
+	}
+
+	return allocated.String(), nil
+}
+
+// Return the run's range so it can be given to another project
+func (s *CIDRManagerState) release(ctx workflow.Context, req CIDRReleaseRequest) error {
+	if held, ok := s.Allocations[req.WorkflowID]; ok && req.RunID != "" && held.RunID != req.RunID {
+		// A later run must not lose the range it holds
+		workflow.GetLogger(ctx).Warn("Network is held by another run", "workflowId", req.WorkflowID, "runId", held.RunID)
+		return nil
+	}
+
+	delete(s.Allocations, req.WorkflowID)
+	return nil
+}
+
+// CIDRManagerWorkflow is a long-running singleton which hands out and reclaims
+// network ranges through updates
+func CIDRManagerWorkflow(ctx workflow.Context, state CIDRManagerState) error {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Starting CIDR manager workflow")
+
+	if state.Allocations == nil {
+		state.Allocations = map[string]CIDRAllocation{}
+	}
+
+	if err := workflow.SetQueryHandler(ctx, CIDRQuery, func() ([]CIDRAllocation, error) {
+		return state.used(nil), nil
+	}); err != nil {
+		return fmt.Errorf("error setting cidr query handler: %w", err)
+	}
+
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, CIDRAllocateUpdate, state.allocate, workflow.UpdateHandlerOptions{
+		Validator: func(ctx workflow.Context, req CIDRAllocateRequest) error {
+			if req.WorkflowID == "" {
+				return fmt.Errorf("workflow id is required")
+			}
+			// The workflow ID is reused if a project's name is, so an earlier
+			// run's network must be torn down first
+			if held, ok := state.Allocations[req.WorkflowID]; ok && held.RunID != req.RunID {
+				return temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf("network %s is held by run %s of workflow %s until it's torn down", held.CIDR, held.RunID, req.WorkflowID),
+					"CIDRHeld",
+					nil,
+				)
+			}
+			return nil
+		},
+	}); err != nil {
+		return fmt.Errorf("error setting allocate update handler: %w", err)
+	}
+
+	if err := workflow.SetUpdateHandler(ctx, CIDRReleaseUpdate, state.release); err != nil {
+		return fmt.Errorf("error setting release update handler: %w", err)
+	}
+
+	// Keep the history small by continuing-as-new when the server suggests it
+	if err := workflow.Await(ctx, func() bool {
+		return workflow.GetInfo(ctx).GetContinueAsNewSuggested() && workflow.AllHandlersFinished(ctx)
+	}); err != nil {
+		return fmt.Errorf("error waiting in cidr manager: %w", err)
+	}
+
+	return workflow.NewContinueAsNewError(ctx, CIDRManagerWorkflow, state)
+}
+
+// NetworkActivities talk to the CIDR manager so need a Temporal client. These
+// are registered with the worker as a struct.
+type NetworkActivities struct {
+	Client client.Client
+	// The networks in the inventory are avoided as well as the manager's
+	// allocations. This can be nil.
+	Store inventory.Store
+	// Task queue the CIDR manager runs on
+	TaskQueue string
+}
+
+// Used to reference the activity methods from the workflows
+var networkActivities *NetworkActivities
+
+// AllocateCIDRActivity asks the CIDR manager for the range, starting it if
+// it's not running. The manager holds the range until it's released, so two
+// projects allocating at the same time can't be given the same range.
+func (n *NetworkActivities) AllocateCIDRActivity(ctx context.Context, req CIDRRequest) (string, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Debug("Allocating network", "cidr", req.CIDR, "auto", req.Auto, "supernet", req.Supernet)
+
+	info := activity.GetInfo(ctx)
+	update := CIDRAllocateRequest{
+		CIDRRequest: req,
+		WorkflowID:  info.WorkflowExecution.ID,
+		RunID:       info.WorkflowExecution.RunID,
+		Recorded:    make([]CIDRAllocation, 0),
+	}
+
+	if n.Store != nil {
+		networks, err := n.Store.List(ctx, inventory.Filter{Kind: inventory.KindNetwork})
+		if err != nil {
+			return "", fmt.Errorf("error listing networks: %w", err)
+		}
+		for _, record := range networks {
+			update.Recorded = append(update.Recorded, CIDRAllocation{
+				WorkflowID: record.WorkflowID,
+				ProjectID:  record.ProjectID,
+				CIDR:       record.Details["subnet"],
+			})
+		}
+	}
+
+	startOp := n.Client.NewWithStartWorkflowOperation(client.StartWorkflowOptions{
+		ID:                       CIDRManagerWorkflowID,
+		TaskQueue:                n.TaskQueue,
+		WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING,
+	}, CIDRManagerWorkflow, CIDRManagerState{})
+
+	handle, err := n.Client.UpdateWithStartWorkflow(ctx, client.UpdateWithStartWorkflowOptions{
+		StartWorkflowOperation: startOp,
+		UpdateOptions: client.UpdateWorkflowOptions{
+			// Make retries idempotent
+			UpdateID:     fmt.Sprintf("%s-allocate", info.WorkflowExecution.RunID),
+			UpdateName:   CIDRAllocateUpdate,
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+			Args:         []any{update},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error sending cidr request: %w", err)
+	}
+
+	var allocated string
+	if err := handle.Get(ctx, &allocated); err != nil {
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.NonRetryable() {
+			return "", temporal.NewNonRetryableApplicationError(appErr.Error(), appErr.Type(), err)
+		}
+		return "", fmt.Errorf("error getting cidr allocation: %w", err)
+	}
+
+	return allocated, nil
+}
+
+// Return the run's range to the CIDR manager
+func (n *NetworkActivities) ReleaseCIDRActivity(ctx context.Context, req CIDRReleaseRequest) error {
+	logger := activity.GetLogger(ctx)
+	logger.Debug("Releasing network", "workflowId", req.WorkflowID, "runId", req.RunID)
+
+	handle, err := n.Client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   CIDRManagerWorkflowID,
+		UpdateID:     fmt.Sprintf("%s-release", activity.GetInfo(ctx).WorkflowExecution.RunID),
+		UpdateName:   CIDRReleaseUpdate,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []any{req},
+	})
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			// No manager means no range to release
+			logger.Warn("CIDR manager not running")
+			return nil
+		}
+		return fmt.Errorf("error sending cidr release: %w", err)
+	}
+
+	return handle.Get(ctx, nil)
+}
+
+// Pick or check the project's network range. This is done before planning so
+// the subnets are carved from the allocated range.
+func allocateCIDR(ctx workflow.Context, cfg *providers.CloudConfig) error {
+	req := CIDRRequest{
+		CIDR:     cfg.Subnet,
+		Auto:     cfg.AutoCIDR,
+		Supernet: cfg.Supernet,
+	}
+	if req.CIDR == "" {
+		return nil
+	}
+
+	var allocated string
+	if err := workflow.ExecuteActivity(ctx, networkActivities.AllocateCIDRActivity, req).Get(ctx, &allocated); err != nil {
+		return fmt.Errorf("error allocating network: %w", err)
+	}
+
+	cfg.Subnet = allocated
+	cfg.AutoCIDR = false
+	return nil
+}
+
+// Return the range allocated to the run. This runs in a disconnected context
+// so it works if the workflow has been cancelled.
+func releaseCIDR(ctx workflow.Context, cfg providers.CloudConfig, workflowID, runID string) error {
+	if cfg.Subnet == "" {
+		return nil
+	}
+
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+
+	if err := workflow.ExecuteActivity(ctx, networkActivities.ReleaseCIDRActivity, CIDRReleaseRequest{
+		WorkflowID: workflowID,
+		RunID:      runID,
+	}).Get(ctx, nil); err != nil {
+		return fmt.Errorf("error releasing network: %w", err)
+	}
+	return nil
+}
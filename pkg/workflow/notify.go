@@ -0,0 +1,145 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/notify"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// NotificationActivities send the events to the notifier. Nothing is sent if
+// there is no notifier.
+type NotificationActivities struct {
+	Notifier notify.Notifier
+}
+
+// Used to reference the activity methods from the workflows
+var notificationActivities *NotificationActivities
+
+func (n *NotificationActivities) NotifyActivity(ctx context.Context, event *notify.Event) error {
+	if n.Notifier == nil {
+		return nil
+	}
+
+	logger := activity.GetLogger(ctx)
+	logger.Debug("Sending notification", "type", event.Type)
+
+	targets, ok := n.Notifier.(notify.Multi)
+	if !ok {
+		targets = notify.Multi{n.Notifier}
+	}
+
+	// The targets that have already been sent to are recorded in the heartbeat
+	// so a retry only sends to the ones that failed
+	sent := map[int]bool{}
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &sent); err != nil {
+			logger.Warn("Unable to read sent notifications - sending to all targets", "error", err)
+		}
+	}
+
+	errs := make([]error, 0)
+	for i, target := range targets {
+		if sent[i] {
+			continue
+		}
+
+		// Each notifier renders its own message
+		e := *event
+		if err := target.Notify(ctx, &e); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		sent[i] = true
+		activity.RecordHeartbeat(ctx, sent)
+	}
+	return errors.Join(errs...)
+}
+
+// NotificationWorkflow sends the event. This runs separately from the workflow
+// that raised the event so a slow or failing notifier never holds it up.
+//
+// Notifications are best effort - a failure is logged and never fails the
+// workflow.
+func NotificationWorkflow(ctx workflow.Context, event notify.Event) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    3,
+		},
+	})
+
+	if err := workflow.ExecuteActivity(ctx, notificationActivities.NotifyActivity, &event).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Warn("Unable to send notification", "type", event.Type, "error", err)
+	}
+	return nil
+}
+
+// Tell the notifiers about the project. The caller sets the event type and
+// anything specific to it - the rest is taken from the config and workflow.
+//
+// The notification is sent by a child workflow which outlives this one, so
+// this only waits for it to start. It is still sent if the workflow has been
+// cancelled.
+func sendNotification(ctx workflow.Context, cfg providers.CloudConfig, event notify.Event) {
+	info := workflow.GetInfo(ctx)
+
+	event.Time = workflow.Now(ctx)
+	event.Project = cfg.Name
+	event.Provider = cfg.Provider
+	event.Region = cfg.Region
+	event.Owner = cfg.Owner
+	event.Environment = cfg.Environment
+	event.Actor = actor(ctx)
+	event.Nodes = cfg.NodeCount()
+	if cfg.Plan != nil {
+		event.Project = cfg.Plan.Project.Name
+		event.Nodes = len(cfg.Plan.Nodes)
+		event.MonthlyCost = cfg.Plan.MonthlyCost
+	}
+	if event.WorkflowID == "" {
+		event.WorkflowID = info.WorkflowExecution.ID
+		event.RunID = info.WorkflowExecution.RunID
+	}
+
+	// Cancelling the context would cancel the child, so it's never cancelled
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:        fmt.Sprintf("notify-%s-%s", info.WorkflowExecution.RunID, event.Type),
+		TaskQueue:         info.TaskQueueName,
+		ParentClosePolicy: enums.PARENT_CLOSE_POLICY_ABANDON,
+	})
+
+	// The child must have started before the parent completes or it's lost
+	future := workflow.ExecuteChildWorkflow(ctx, NotificationWorkflow, event)
+	if err := future.GetChildWorkflowExecution().Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Warn("Unable to send notification", "type", event.Type, "error", err)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Policy is the worker's rules for provisioning. This is configured on the
+// worker rather than sent with the workflow input, so it can't be turned off
+// by the person it's meant to check.
+type Policy struct {
+	Approval providers.ApprovalPolicy
+	// Fail before creating any resources if the plan costs more than this. Zero disables the check
+	MaxMonthlyCost float64
+}
+
+// PolicyActivities give the workflows the worker's policy. These are
+// registered with the worker as a struct.
+type PolicyActivities struct {
+	Policy Policy
+}
+
+// Used to reference the activity methods from the workflows
+var policyActivities *PolicyActivities
+
+func (p *PolicyActivities) GetPolicyActivity(ctx context.Context) (*Policy, error) {
+	return &p.Policy, nil
+}
+
+// Get the policy from the workflow workers. This is recorded in the history so
+// the policy doesn't change if the workflow is replayed on a worker with
+// different config.
+func getPolicy(ctx workflow.Context) (*Policy, error) {
+	ctx = workflow.WithTaskQueue(ctx, workflow.GetInfo(ctx).TaskQueueName)
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+		},
+	})
+
+	var policy *Policy
+	if err := workflow.ExecuteActivity(ctx, policyActivities.GetPolicyActivity).Get(ctx, &policy); err != nil {
+		return nil, fmt.Errorf("error getting policy: %w", err)
+	}
+	return policy, nil
+}
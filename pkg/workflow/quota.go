@@ -0,0 +1,333 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	QuotaReserveUpdate = "reserve"
+	QuotaReleaseUpdate = "release"
+	QuotaQuery         = "quota"
+	QuotaGrantedSignal = "quota-granted"
+)
+
+// QuotaLimits is the capacity of the cloud account. Zero is unlimited.
+type QuotaLimits struct {
+	Nodes    int
+	Networks int
+}
+
+// QuotaRequest asks the quota manager for capacity on behalf of a workflow
+type QuotaRequest struct {
+	WorkflowID string
+	// The run the capacity is held for. Another run of the workflow can't
+	// reserve capacity until it's released.
+	RunID    string
+	Provider providers.CloudProvider
+	Region   string
+	Nodes    int
+	Networks int
+	Policy   providers.QuotaPolicy
+}
+
+// QuotaReservation is the quota manager's answer to a request
+type QuotaReservation struct {
+	WorkflowID string
+	Nodes      int
+	Networks   int
+	Granted    bool
+	Queued     bool
+}
+
+// QuotaManagerState is carried over when the manager continues-as-new. The
+// limits are set by the worker which starts the manager and are the same for
+// every request.
+type QuotaManagerState struct {
+	Limits       QuotaLimits
+	Reservations map[string]QuotaRequest
+	Queue        []QuotaRequest
+}
+
+// QuotaUsage is returned by the quota query
+type QuotaUsage struct {
+	Limits   QuotaLimits
+	Nodes    int
+	Networks int
+	Queued   int
+}
+
+// There is a single quota manager for each provider and region
+func QuotaManagerWorkflowID(provider providers.CloudProvider, region string) string {
+	return fmt.Sprintf("quota-manager-%s-%s", provider, region)
+}
+
+func (s *QuotaManagerState) usage() QuotaUsage {
+	usage := QuotaUsage{
+		Limits: s.Limits,
+		Queued: len(s.Queue),
+	}
+	for _, r := range s.Reservations {
+		usage.Nodes += r.Nodes
+		usage.Networks += r.Networks
+	}
+	return usage
+}
+
+// Check if the request can be granted with the remaining capacity
+func (s *QuotaManagerState) fits(req QuotaRequest) bool {
+	usage := s.usage()
+
+	if s.Limits.Nodes > 0 && usage.Nodes+req.Nodes > s.Limits.Nodes {
+		return false
+	}
+	if s.Limits.Networks > 0 && usage.Networks+req.Networks > s.Limits.Networks {
+		return false
+	}
+	return true
+}
+
+func (s *QuotaManagerState) queued(workflowID string) int {
+	return slices.IndexFunc(s.Queue, func(r QuotaRequest) bool {
+		return r.WorkflowID == workflowID
+	})
+}
+
+// Get the request holding or waiting for capacity for the workflow
+func (s *QuotaManagerState) held(workflowID string) (QuotaRequest, bool) {
+	if req, ok := s.Reservations[workflowID]; ok {
+		return req, true
+	}
+	if i := s.queued(workflowID); i >= 0 {
+		return s.Queue[i], true
+	}
+	return QuotaRequest{}, false
+}
+
+func (s *QuotaManagerState) reserve(ctx workflow.Context, req QuotaRequest) (*QuotaReservation, error) {
+	reservation := &QuotaReservation{
+		WorkflowID: req.WorkflowID,
+		Nodes:      req.Nodes,
+		Networks:   req.Networks,
+	}
+
+	// Retried requests get the same answer
+	if _, ok := s.Reservations[req.WorkflowID]; ok {
+		reservation.Granted = true
+		return reservation, nil
+	}
+	if s.queued(req.WorkflowID) >= 0 {
+		reservation.Queued = true
+		return reservation, nil
+	}
+
+	// Don't allow requests to jump the queue
+	if len(s.Queue) == 0 && s.fits(req) {
+		s.Reservations[req.WorkflowID] = req
+		reservation.Granted = true
+		return reservation, nil
+	}
+
+	if req.Policy == providers.QuotaPolicyFailFast {
+		usage := s.usage()
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf(
+				"insufficient quota: %d/%d nodes and %d/%d networks in use",
+				usage.Nodes, s.Limits.Nodes, usage.Networks, s.Limits.Networks,
+			),
+			"QuotaExceeded",
+			nil,
+		)
+	}
+
+	workflow.GetLogger(ctx).Info("Queueing quota request", "workflowId", req.WorkflowID)
+	s.Queue = append(s.Queue, req)
+	reservation.Queued = true
+
+	return reservation, nil
+}
+
+// Release the run's capacity and hand it out to any queued requests. Without
+// a run ID, the capacity is released whichever run holds it.
+func (s *QuotaManagerState) release(ctx workflow.Context, req QuotaRequest) error {
+	logger := workflow.GetLogger(ctx)
+
+	if held, ok := s.held(req.WorkflowID); ok && req.RunID != "" && held.RunID != req.RunID {
+		// A later run must not lose the capacity it holds
+		logger.Warn("Quota is held by another run", "workflowId", req.WorkflowID, "runId", held.RunID)
+	} else {
+		delete(s.Reservations, req.WorkflowID)
+		if i := s.queued(req.WorkflowID); i >= 0 {
+			s.Queue = slices.Delete(s.Queue, i, i+1)
+		}
+	}
+
+	granted := make([]QuotaRequest, 0)
+	for len(s.Queue) > 0 && s.fits(s.Queue[0]) {
+		req := s.Queue[0]
+		s.Queue = s.Queue[1:]
+		s.Reservations[req.WorkflowID] = req
+		granted = append(granted, req)
+	}
+
+	for _, req := range granted {
+		logger.Info("Granting queued quota request", "workflowId", req.WorkflowID)
+
+		reservation := QuotaReservation{
+			WorkflowID: req.WorkflowID,
+			Nodes:      req.Nodes,
+			Networks:   req.Networks,
+			Granted:    true,
+		}
+		if err := workflow.SignalExternalWorkflow(ctx, req.WorkflowID, req.RunID, QuotaGrantedSignal, reservation).Get(ctx, nil); err != nil {
+			// The workflow has probably gone away, so don't hold its capacity
+			logger.Warn("Unable to signal quota granted", "workflowId", req.WorkflowID, "error", err)
+			delete(s.Reservations, req.WorkflowID)
+		}
+	}
+
+	return nil
+}
+
+// QuotaManagerWorkflow is a long-running singleton for a provider and region
+// which hands out and reclaims capacity through updates
+func QuotaManagerWorkflow(ctx workflow.Context, state QuotaManagerState) error {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Starting quota manager workflow")
+
+	if state.Reservations == nil {
+		state.Reservations = map[string]QuotaRequest{}
+	}
+
+	if err := workflow.SetQueryHandler(ctx, QuotaQuery, func() (QuotaUsage, error) {
+		return state.usage(), nil
+	}); err != nil {
+		return fmt.Errorf("error setting quota query handler: %w", err)
+	}
+
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, QuotaReserveUpdate, state.reserve, workflow.UpdateHandlerOptions{
+		Validator: func(ctx workflow.Context, req QuotaRequest) error {
+			if req.WorkflowID == "" {
+				return fmt.Errorf("workflow id is required")
+			}
+			// The workflow ID is reused if a project's name is, so an earlier
+			// run's project must be torn down first
+			if held, ok := state.held(req.WorkflowID); ok && held.RunID != req.RunID {
+				return temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf("quota is held by run %s of workflow %s until it's torn down", held.RunID, req.WorkflowID),
+					"QuotaHeld",
+					nil,
+				)
+			}
+			// This can never be satisfied so don't let it sit in the queue
+			if (state.Limits.Nodes > 0 && req.Nodes > state.Limits.Nodes) || (state.Limits.Networks > 0 && req.Networks > state.Limits.Networks) {
+				return temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf(
+						"request for %d nodes and %d networks exceeds total quota of %d nodes and %d networks",
+						req.Nodes, req.Networks, state.Limits.Nodes, state.Limits.Networks,
+					),
+					"QuotaExceeded",
+					nil,
+				)
+			}
+			return nil
+		},
+	}); err != nil {
+		return fmt.Errorf("error setting reserve update handler: %w", err)
+	}
+
+	if err := workflow.SetUpdateHandler(ctx, QuotaReleaseUpdate, state.release); err != nil {
+		return fmt.Errorf("error setting release update handler: %w", err)
+	}
+
+	// Keep the history small by continuing-as-new when the server suggests it
+	if err := workflow.Await(ctx, func() bool {
+		return workflow.GetInfo(ctx).GetContinueAsNewSuggested() && workflow.AllHandlersFinished(ctx)
+	}); err != nil {
+		return fmt.Errorf("error waiting in quota manager: %w", err)
+	}
+
+	return workflow.NewContinueAsNewError(ctx, QuotaManagerWorkflow, state)
+}
+
+// Reserve capacity for the planned resources, waiting if the request is queued
+func reserveQuota(ctx workflow.Context, cfg providers.CloudConfig) (*QuotaReservation, error) {
+	logger := workflow.GetLogger(ctx)
+
+	// Get the channel before the request so the grant cannot be missed
+	granted := workflow.GetSignalChannel(ctx, QuotaGrantedSignal)
+
+	execution := workflow.GetInfo(ctx).WorkflowExecution
+	req := QuotaRequest{
+		WorkflowID: execution.ID,
+		RunID:      execution.RunID,
+		Provider:   cfg.Provider,
+		Region:     cfg.Region,
+		Nodes:      len(cfg.Plan.Nodes),
+		Networks:   1,
+		Policy:     cfg.QuotaPolicy,
+	}
+
+	var reservation *QuotaReservation
+	if err := workflow.ExecuteActivity(ctx, quotaActivities.ReserveQuotaActivity, req).Get(ctx, &reservation); err != nil {
+		return nil, fmt.Errorf("error reserving quota: %w", err)
+	}
+
+	if reservation.Queued {
+		logger.Info("Waiting for quota")
+
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(granted, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &reservation)
+		})
+		selector.AddReceive(ctx.Done(), func(c workflow.ReceiveChannel, more bool) {})
+		selector.Select(ctx)
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Info("Quota reserved", "nodes", reservation.Nodes, "networks", reservation.Networks)
+
+	return reservation, nil
+}
+
+// Return the capacity reserved by the run to the quota manager. This runs in a
+// disconnected context so it works if the workflow has been cancelled.
+func releaseQuota(ctx workflow.Context, cfg providers.CloudConfig, workflowID, runID string) error {
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+
+	req := QuotaRequest{
+		WorkflowID: workflowID,
+		RunID:      runID,
+		Provider:   cfg.Provider,
+		Region:     cfg.Region,
+	}
+
+	if err := workflow.ExecuteActivity(ctx, quotaActivities.ReleaseQuotaActivity, req).Get(ctx, nil); err != nil {
+		return fmt.Errorf("error releasing quota: %w", err)
+	}
+	return nil
+}
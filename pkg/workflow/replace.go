@@ -0,0 +1,227 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/dns"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ReplaceNodeRequest identifies the node of a provisioning run to replace
+type ReplaceNodeRequest struct {
+	WorkflowID string
+	RunID      string
+	// The name of the node, which is kept by the new node
+	Node string
+}
+
+// ReplaceNodeResult is the nodes a replacement has taken over from the
+// provisioning run
+type ReplaceNodeResult struct {
+	// The nodes being replaced which haven't been deleted
+	Old []*providers.NodeResult
+	New *providers.NodeResult
+	// The project's DNS records once the new node's name has been updated
+	DNSRecords []dns.Record
+}
+
+// ReplaceNodeWorkflowID is derived from the provisioning workflow's ID and the
+// node's name, so a node can only be replaced once at a time. Each replacement
+// of the node is another run of this workflow.
+func ReplaceNodeWorkflowID(workflowID, node string) string {
+	return fmt.Sprintf("replace-%s-%s", workflowID, node)
+}
+
+// Get the nodes taken over by the latest replacement of a node which has taken
+// any over. Each replacement takes over the nodes of the one before, but the
+// latest run has nothing until it has read them, so the earlier runs are
+// checked newest first. Returns nil if the node has never been replaced.
+func (r *ResourceActivities) replacedNodes(ctx context.Context, workflowID string) (*ReplaceNodeResult, error) {
+	replaced, err := r.queryReplacement(ctx, workflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			// The node has never been replaced
+			return nil, nil
+		}
+		return nil, err
+	}
+	if replaced != nil {
+		return replaced, nil
+	}
+
+	runs := make([]*workflowpb.WorkflowExecutionInfo, 0)
+	var pageToken []byte
+	for {
+		res, err := r.Client.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Query:         fmt.Sprintf("WorkflowId = %s", quote(workflowID)),
+			NextPageToken: pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing node replacements: %w", err)
+		}
+		runs = append(runs, res.GetExecutions()...)
+
+		pageToken = res.GetNextPageToken()
+		if len(pageToken) == 0 {
+			break
+		}
+	}
+
+	slices.SortFunc(runs, func(a, b *workflowpb.WorkflowExecutionInfo) int {
+		return b.GetStartTime().AsTime().Compare(a.GetStartTime().AsTime())
+	})
+
+	for _, run := range runs {
+		replaced, err := r.queryReplacement(ctx, workflowID, run.GetExecution().GetRunId())
+		if err != nil {
+			return nil, err
+		}
+		if replaced != nil {
+			return replaced, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *ResourceActivities) queryReplacement(ctx context.Context, workflowID, runID string) (*ReplaceNodeResult, error) {
+	val, err := r.Client.QueryWorkflow(ctx, workflowID, runID, ResourcesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error querying replaced nodes: %w", err)
+	}
+
+	var replaced *ReplaceNodeResult
+	if err := val.Get(&replaced); err != nil {
+		return nil, fmt.Errorf("error decoding replaced nodes: %w", err)
+	}
+	return replaced, nil
+}
+
+// ReplaceNodeWorkflow creates a new node in place of one of the project's
+// nodes. The new node is added to the load balancers alongside the old one
+// and, once it's passing their health checks, the old node is taken out of
+// the load balancers, its DNS record is moved to the new node and it's
+// deleted. If this fails, both nodes are kept and running it again replaces
+// them both, as it takes over the nodes of the replacements before it.
+func ReplaceNodeWorkflow(ctx workflow.Context, req ReplaceNodeRequest) (*ReplaceNodeResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Starting node replacement workflow", "workflowId", req.WorkflowID, "node", req.Node)
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Hour,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+		},
+	})
+
+	// This is nil until the nodes have been taken over, so the teardown keeps
+	// the provisioning run's nodes until then
+	var result *ReplaceNodeResult
+	if err := workflow.SetQueryHandler(ctx, ResourcesQuery, func() (*ReplaceNodeResult, error) {
+		return result, nil
+	}); err != nil {
+		return nil, fmt.Errorf("error setting resources query handler: %w", err)
+	}
+
+	var project *providers.ProjectResult
+	if err := workflow.ExecuteActivity(ctx, resourceActivities.GetRecordedResourcesActivity, TeardownRequest{
+		WorkflowID: req.WorkflowID,
+		RunID:      req.RunID,
+	}).Get(ctx, &project); err != nil {
+		logger.Error("Error getting recorded resources", "error", err)
+		return nil, fmt.Errorf("error getting recorded resources: %w", err)
+	}
+
+	cfg := project.CloudConfig
+
+	var plannedNode *providers.PlannedNode
+	if cfg.Plan != nil {
+		if i := slices.IndexFunc(cfg.Plan.Nodes, func(n *providers.PlannedNode) bool {
+			return n.Name == req.Node
+		}); i >= 0 {
+			plannedNode = cfg.Plan.Nodes[i]
+		}
+	}
+	if plannedNode == nil {
+		return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("node %s is not in the plan", req.Node), "NodeNotFound", nil)
+	}
+
+	// Every node with the name is replaced, including any left behind by a
+	// replacement which failed
+	result = &ReplaceNodeResult{Old: make([]*providers.NodeResult, 0)}
+	others := make([]*providers.NodeResult, 0, len(project.Nodes))
+	for _, node := range project.Nodes {
+		if node.Name == req.Node {
+			result.Old = append(result.Old, node)
+		} else {
+			others = append(others, node)
+		}
+	}
+
+	providerCtx := withProviderTaskQueue(ctx, cfg)
+
+	if err := provisionNode(providerCtx, cfg, project, plannedNode, &result.New); err != nil {
+		return nil, err
+	}
+
+	// Send traffic to both until the new node is healthy
+	project.Nodes = append(slices.Clone(others), append(slices.Clone(result.Old), result.New)...)
+	if err := syncLoadBalancerTargets(ctx, cfg, project); err != nil {
+		logger.Error("Error adding node to load balancers", "error", err)
+		return nil, err
+	}
+
+	project.Nodes = append(others, result.New)
+	if err := syncLoadBalancerTargets(ctx, cfg, project); err != nil {
+		logger.Error("Error removing old nodes from load balancers", "error", err)
+		return nil, err
+	}
+
+	// Point the node's name at the new node before the old one is released.
+	// The records are kept until the update is confirmed so the teardown
+	// deletes whichever were created.
+	result.DNSRecords = append(slices.Clone(project.DNSRecords), wantedDNSRecords(cfg, project)...)
+	if err := syncDNSRecords(ctx, cfg, project); err != nil {
+		logger.Error("Error updating dns records", "error", err)
+		return nil, err
+	}
+	result.DNSRecords = project.DNSRecords
+
+	err := deleteNodes(providerCtx, cfg, project.ID, result.Old)
+	result.Old = slices.DeleteFunc(result.Old, func(n *providers.NodeResult) bool {
+		return n.DeletedAt != nil
+	})
+	if err != nil {
+		logger.Error("Error deleting old nodes", "error", err)
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,337 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newReplaceProject() *providers.ProjectResult {
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Region:   "some-region",
+		Plan: &providers.Plan{
+			Nodes: []*providers.PlannedNode{
+				{Name: "web-0", Pool: "web"},
+				{Name: "web-1", Pool: "web"},
+			},
+		},
+	}
+	return &providers.ProjectResult{
+		CloudConfig: cfg,
+		ID:          "project-id",
+		Nodes: []*providers.NodeResult{
+			{ID: "old-node", Name: "web-0", Pool: "web"},
+			{ID: "other-node", Name: "web-1", Pool: "web"},
+		},
+		LoadBalancers: []*providers.LoadBalancerResult{
+			{ID: "lb-id", Name: "web", Pool: "web", Targets: []string{"old-node", "other-node"}},
+		},
+	}
+}
+
+// Set the targets to the nodes given, recording each set of targets
+func mockSetTargets(env *testsuite.TestWorkflowEnvironment, cfg providers.CloudConfig) *[][]string {
+	targets := make([][]string, 0)
+	env.OnActivity(workflow.SetLoadBalancerTargetsActivity, mock.Anything, cfg, mock.Anything, mock.Anything).
+		Return(func(
+			_ context.Context,
+			_ providers.CloudConfig,
+			lb *providers.LoadBalancerResult,
+			nodes []*providers.NodeResult,
+		) (*providers.LoadBalancerResult, error) {
+			updated := *lb
+			updated.Targets = make([]string, 0, len(nodes))
+			for _, node := range nodes {
+				updated.Targets = append(updated.Targets, node.ID)
+			}
+			targets = append(targets, updated.Targets)
+			return &updated, nil
+		})
+	return &targets
+}
+
+func Test_ReplaceNodeWorkflow(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	events := mockAudit(env)
+
+	req := workflow.ReplaceNodeRequest{
+		WorkflowID: "provision-some-project",
+		RunID:      "some-run-id",
+		Node:       "web-0",
+	}
+	project := newReplaceProject()
+	cfg := project.CloudConfig
+	newNode := &providers.NodeResult{ID: "new-node", Name: "web-0", Pool: "web"}
+
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, workflow.TeardownRequest{
+		WorkflowID: req.WorkflowID,
+		RunID:      req.RunID,
+	}).Return(project, nil)
+	env.OnActivity(workflow.ProvisionNodeActivity, mock.Anything, cfg, mock.Anything, cfg.Plan.Nodes[0]).Return(newNode, nil).Once()
+	env.OnActivity(workflow.AwaitForNodeRunningActivity, mock.Anything, cfg, newNode).Return(&providers.NodeReadyResult{Ready: true}, nil).Once()
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, mock.Anything).Return(nil)
+	targets := mockSetTargets(env, cfg)
+
+	// Only the new node has to pass the health check
+	env.OnActivity(workflow.AwaitForTargetHealthyActivity, mock.Anything, cfg, mock.Anything, newNode).Return(nil).Once()
+	env.OnActivity(workflow.DeleteNodeActivity, mock.Anything, cfg, project.Nodes[0]).Return(nil).Once()
+	env.OnActivity(inventoryActivities.RecordDeletionsActivity, mock.Anything, mock.Anything).Return(nil).Once()
+
+	env.ExecuteWorkflow(workflow.ReplaceNodeWorkflow, req)
+	assert.True(env.IsWorkflowCompleted())
+
+	var result *workflow.ReplaceNodeResult
+	assert.NoError(env.GetWorkflowResult(&result))
+	assert.Equal(newNode.ID, result.New.ID)
+	assert.Empty(result.Old)
+
+	// The old node is only removed once the new node is taking traffic
+	assert.Equal([][]string{
+		{"other-node", "old-node", "new-node"},
+		{"other-node", "new-node"},
+	}, *targets)
+
+	ops := make([]audit.Operation, 0, len(*events))
+	for _, e := range *events {
+		ops = append(ops, e.Operation)
+	}
+	assert.Equal([]audit.Operation{
+		audit.OperationCreateNode,
+		audit.OperationUpdateLoadBalancer,
+		audit.OperationUpdateLoadBalancer,
+		audit.OperationDeleteNode,
+	}, ops)
+
+	env.AssertExpectations(t)
+}
+
+func Test_ReplaceNodeWorkflowUnhealthy(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	mockAudit(env)
+
+	req := workflow.ReplaceNodeRequest{
+		WorkflowID: "provision-some-project",
+		RunID:      "some-run-id",
+		Node:       "web-0",
+	}
+	project := newReplaceProject()
+	cfg := project.CloudConfig
+	newNode := &providers.NodeResult{ID: "new-node", Name: "web-0", Pool: "web"}
+
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, mock.Anything).Return(project, nil)
+	env.OnActivity(workflow.ProvisionNodeActivity, mock.Anything, cfg, mock.Anything, cfg.Plan.Nodes[0]).Return(newNode, nil).Once()
+	env.OnActivity(workflow.AwaitForNodeRunningActivity, mock.Anything, cfg, newNode).Return(&providers.NodeReadyResult{Ready: true}, nil).Once()
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, mock.Anything).Return(nil)
+	targets := mockSetTargets(env, cfg)
+	env.OnActivity(workflow.AwaitForTargetHealthyActivity, mock.Anything, cfg, mock.Anything, newNode).
+		Return(temporal.NewNonRetryableApplicationError("unhealthy", "TargetUnhealthy", nil)).
+		Once()
+
+	env.ExecuteWorkflow(workflow.ReplaceNodeWorkflow, req)
+	assert.True(env.IsWorkflowCompleted())
+	assert.Error(env.GetWorkflowError())
+
+	// The old node is still taking traffic and both nodes are kept
+	assert.Equal([][]string{{"other-node", "old-node", "new-node"}}, *targets)
+
+	val, err := env.QueryWorkflow(workflow.ResourcesQuery)
+	assert.NoError(err)
+
+	var result *workflow.ReplaceNodeResult
+	assert.NoError(val.Get(&result))
+	assert.Equal(newNode.ID, result.New.ID)
+	assert.Len(result.Old, 1)
+	assert.Equal("old-node", result.Old[0].ID)
+
+	env.AssertExpectations(t)
+}
+
+func Test_ReplaceNodeWorkflowNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, mock.Anything).Return(newReplaceProject(), nil)
+
+	env.ExecuteWorkflow(workflow.ReplaceNodeWorkflow, workflow.ReplaceNodeRequest{
+		WorkflowID: "provision-some-project",
+		Node:       "unknown",
+	})
+	assert.True(env.IsWorkflowCompleted())
+
+	var appErr *temporal.ApplicationError
+	assert.ErrorAs(env.GetWorkflowError(), &appErr)
+	assert.Equal("NodeNotFound", appErr.Type())
+}
+
+// A query result from the mocked client
+type encodedValue struct {
+	val any
+}
+
+func (e encodedValue) HasValue() bool {
+	return e.val != nil
+}
+
+func (e encodedValue) Get(valuePtr any) error {
+	payload, err := converter.GetDefaultDataConverter().ToPayload(e.val)
+	if err != nil {
+		return err
+	}
+	return converter.GetDefaultDataConverter().FromPayload(payload, valuePtr)
+}
+
+// Replace web-0 with a node which never passes the health check, returning the
+// nodes the replacement is left with
+func failReplacement(t *testing.T, project *providers.ProjectResult, newNode *providers.NodeResult) *workflow.ReplaceNodeResult {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	mockAudit(env)
+
+	cfg := project.CloudConfig
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, mock.Anything).Return(project, nil)
+	env.OnActivity(workflow.ProvisionNodeActivity, mock.Anything, cfg, mock.Anything, cfg.Plan.Nodes[0]).Return(newNode, nil).Once()
+	env.OnActivity(workflow.AwaitForNodeRunningActivity, mock.Anything, cfg, newNode).Return(&providers.NodeReadyResult{Ready: true}, nil).Once()
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, mock.Anything).Return(nil)
+	mockSetTargets(env, cfg)
+	env.OnActivity(workflow.AwaitForTargetHealthyActivity, mock.Anything, cfg, mock.Anything, newNode).
+		Return(temporal.NewNonRetryableApplicationError("unhealthy", "TargetUnhealthy", nil))
+
+	env.ExecuteWorkflow(workflow.ReplaceNodeWorkflow, workflow.ReplaceNodeRequest{
+		WorkflowID: "provision-some-project",
+		Node:       "web-0",
+	})
+	assert.Error(t, env.GetWorkflowError())
+
+	val, err := env.QueryWorkflow(workflow.ResourcesQuery)
+	assert.NoError(t, err)
+
+	var result *workflow.ReplaceNodeResult
+	assert.NoError(t, val.Get(&result))
+	return result
+}
+
+func Test_ReplaceNodeTwice(t *testing.T) {
+	assert := assert.New(t)
+
+	const workflowID = "provision-some-project"
+	project := newReplaceProject()
+	replaceID := workflow.ReplaceNodeWorkflowID(workflowID, "web-0")
+
+	c := &mocks.Client{}
+	c.On("QueryWorkflow", mock.Anything, workflowID, "", workflow.ResourcesQuery).Return(encodedValue{project}, nil)
+	for i := range project.Plan.Nodes {
+		c.On("QueryWorkflow", mock.Anything, workflow.ProvisionNodeWorkflowID(workflowID, i), "", workflow.ResourcesQuery).
+			Return(nil, serviceerror.NewNotFound("workflow not found"))
+	}
+	c.On("QueryWorkflow", mock.Anything, workflow.ReplaceNodeWorkflowID(workflowID, "web-1"), "", workflow.ResourcesQuery).
+		Return(nil, serviceerror.NewNotFound("workflow not found"))
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	actEnv := testSuite.NewTestActivityEnvironment()
+	actEnv.RegisterActivity(&workflow.ResourceActivities{Client: c})
+
+	getResources := func() *providers.ProjectResult {
+		val, err := actEnv.ExecuteActivity(resourceActivities.GetRecordedResourcesActivity, workflow.TeardownRequest{WorkflowID: workflowID})
+		assert.NoError(err)
+
+		var resources *providers.ProjectResult
+		assert.NoError(val.Get(&resources))
+		return resources
+	}
+	nodeIDs := func(p *providers.ProjectResult) []string {
+		ids := make([]string, 0, len(p.Nodes))
+		for _, node := range p.Nodes {
+			ids = append(ids, node.ID)
+		}
+		return ids
+	}
+
+	// The first replacement leaves both nodes behind
+	first := failReplacement(t, project, &providers.NodeResult{ID: "new-node-1", Name: "web-0", Pool: "web"})
+
+	// The second replacement has started but not taken the nodes over yet, so
+	// the first is used
+	start := time.Now()
+	latest := c.On("QueryWorkflow", mock.Anything, replaceID, "", workflow.ResourcesQuery).Return(encodedValue{}, nil)
+	c.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{
+			{Execution: &commonpb.WorkflowExecution{WorkflowId: replaceID, RunId: "run-1"}, StartTime: timestamppb.New(start)},
+			{Execution: &commonpb.WorkflowExecution{WorkflowId: replaceID, RunId: "run-2"}, StartTime: timestamppb.New(start.Add(time.Hour))},
+		},
+	}, nil)
+	c.On("QueryWorkflow", mock.Anything, replaceID, "run-2", workflow.ResourcesQuery).Return(encodedValue{}, nil)
+	c.On("QueryWorkflow", mock.Anything, replaceID, "run-1", workflow.ResourcesQuery).Return(encodedValue{first}, nil)
+
+	resources := getResources()
+	assert.ElementsMatch([]string{"other-node", "old-node", "new-node-1"}, nodeIDs(resources))
+
+	// The second replacement fails too, having taken over every node
+	second := failReplacement(t, resources, &providers.NodeResult{ID: "new-node-2", Name: "web-0", Pool: "web"})
+	latest.Unset()
+	c.On("QueryWorkflow", mock.Anything, replaceID, "", workflow.ResourcesQuery).Return(encodedValue{second}, nil)
+
+	resources = getResources()
+	assert.ElementsMatch([]string{"other-node", "old-node", "new-node-1", "new-node-2"}, nodeIDs(resources))
+
+	// The teardown deletes every node
+	env := testSuite.NewTestWorkflowEnvironment()
+	mockAudit(env)
+
+	deleted := make([]string, 0)
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, mock.Anything).Return(resources, nil)
+	env.OnActivity(workflow.DeleteLoadBalancerActivity, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(workflow.DeleteNodeActivity, mock.Anything, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, _ providers.CloudConfig, node *providers.NodeResult) error {
+			deleted = append(deleted, node.ID)
+			return nil
+		})
+	env.OnActivity(workflow.DeleteProjectActivity, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(inventoryActivities.RecordDeletionsActivity, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(quotaActivities.ReleaseQuotaActivity, mock.Anything, mock.Anything).Return(nil)
+
+	env.ExecuteWorkflow(workflow.TeardownWorkflow, workflow.TeardownRequest{WorkflowID: workflowID})
+	assert.NoError(env.GetWorkflowError())
+	assert.ElementsMatch([]string{"other-node", "old-node", "new-node-1", "new-node-2"}, deleted)
+
+	c.AssertExpectations(t)
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// These must be registered in the Temporal namespace before use
+var (
+	ProviderSearchAttribute    = temporal.NewSearchAttributeKeyKeyword("CloudProvider")
+	RegionSearchAttribute      = temporal.NewSearchAttributeKeyKeyword("CloudRegion")
+	NodeCountSearchAttribute   = temporal.NewSearchAttributeKeyInt64("NodeCount")
+	OwnerSearchAttribute       = temporal.NewSearchAttributeKeyKeyword("Owner")
+	EnvironmentSearchAttribute = temporal.NewSearchAttributeKeyKeyword("Environment")
+	ProjectIDSearchAttribute   = temporal.NewSearchAttributeKeyKeyword("ProjectID")
+	PhaseSearchAttribute       = temporal.NewSearchAttributeKeyKeyword("ProvisioningPhase")
+)
+
+var searchAttributes = []temporal.SearchAttributeKey{
+	ProviderSearchAttribute,
+	RegionSearchAttribute,
+	NodeCountSearchAttribute,
+	OwnerSearchAttribute,
+	EnvironmentSearchAttribute,
+	ProjectIDSearchAttribute,
+	PhaseSearchAttribute,
+}
+
+// CheckSearchAttributes returns an error if any of the search attributes are
+// not registered in the namespace with the right type. The server rejects an
+// unregistered attribute by failing the workflow task, which is retried
+// forever, so the worker checks this when it starts.
+func CheckSearchAttributes(ctx context.Context, svc operatorservice.OperatorServiceClient, namespace string) error {
+	res, err := svc.ListSearchAttributes(ctx, &operatorservice.ListSearchAttributesRequest{
+		Namespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing search attributes: %w", err)
+	}
+
+	missing := make([]string, 0)
+	for _, key := range searchAttributes {
+		if valueType, ok := res.GetCustomAttributes()[key.GetName()]; !ok || valueType != key.GetValueType() {
+			missing = append(missing, fmt.Sprintf("%s (%s)", key.GetName(), key.GetValueType()))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("search attributes not registered in namespace %s: %s", namespace, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Phase is how far through provisioning the workflow is
+type Phase string
+
+const (
+	PhasePlanning         Phase = "planning"
+	PhaseAwaitingApproval Phase = "awaiting-approval"
+	PhaseAwaitingQuota    Phase = "awaiting-quota"
+	PhaseProvisioning     Phase = "provisioning"
+	PhaseCompleted        Phase = "completed"
+	PhaseFailed           Phase = "failed"
+	PhaseCancelled        Phase = "cancelled"
+	PhaseTearingDown      Phase = "tearing-down"
+)
+
+// ProvisionWorkflowID is derived from the project name so the workflow that
+// built a project can be found
+func ProvisionWorkflowID(name string) string {
+	return fmt.Sprintf("provision-%s", name)
+}
+
+// SearchAttributes are set when the provisioning workflow is started
+func SearchAttributes(cfg providers.CloudConfig) temporal.SearchAttributes {
+	nodeCount := cfg.NodeCount()
+	if cfg.Plan != nil {
+		nodeCount = len(cfg.Plan.Nodes)
+	}
+
+	updates := []temporal.SearchAttributeUpdate{
+		ProviderSearchAttribute.ValueSet(string(cfg.Provider)),
+		RegionSearchAttribute.ValueSet(cfg.Region),
+		NodeCountSearchAttribute.ValueSet(int64(nodeCount)),
+		PhaseSearchAttribute.ValueSet(string(PhasePlanning)),
+	}
+	if cfg.Owner != "" {
+		updates = append(updates, OwnerSearchAttribute.ValueSet(cfg.Owner))
+	}
+	if cfg.Environment != "" {
+		updates = append(updates, EnvironmentSearchAttribute.ValueSet(cfg.Environment))
+	}
+
+	return temporal.NewSearchAttributes(updates...)
+}
+
+// Record the phase so the workflows can be filtered by it. This only returns an
+// error for an invalid value, which is logged rather than failing the workflow.
+// An unregistered attribute fails the workflow task instead, which is why the
+// worker checks the attributes when it starts.
+func setPhase(ctx workflow.Context, phase Phase) {
+	if err := workflow.UpsertTypedSearchAttributes(ctx, PhaseSearchAttribute.ValueSet(string(phase))); err != nil {
+		workflow.GetLogger(ctx).Warn("Unable to set phase", "phase", phase, "error", err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Create the planned security groups in the network. These are created in
+// parallel and every one is waited for, so the groups that were created are
+// known even if one has failed.
+func createSecurityGroups(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	groups := cfg.Plan.SecurityGroups
+	if len(groups) == 0 {
+		return nil
+	}
+
+	logger := workflow.GetLogger(ctx)
+	logger.Debug("Create security groups in cloud provider", "count", len(groups))
+
+	providerCtx := withProviderTaskQueue(ctx, cfg)
+
+	futures := make([]workflow.Future, 0, len(groups))
+	for i := range groups {
+		auditCtx := withAudit(providerCtx, cfg, audit.Event{
+			Operation:    audit.OperationCreateSecurityGroup,
+			ResourceName: groups[i].Name,
+			ProjectID:    project.ID,
+		})
+		futures = append(futures, workflow.ExecuteActivity(auditCtx, CreateSecurityGroupActivity, cfg, project.Network, &groups[i]))
+	}
+
+	project.SecurityGroups = make([]*providers.SecurityGroupResult, 0, len(groups))
+	errs := make([]error, 0)
+	for i, future := range futures {
+		var group *providers.SecurityGroupResult
+
+		if err := future.Get(ctx, &group); err != nil {
+			logger.Error("Error creating security group", "name", groups[i].Name, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+
+		project.SecurityGroups = append(project.SecurityGroups, group)
+		recordResources(ctx, securityGroupRecord(ctx, project, group))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error creating security groups: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Delete the project's security groups. The nodes must have been deleted
+// first as a group cannot be deleted while it's attached.
+func deleteSecurityGroups(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	futures := make([]workflow.Future, 0, len(project.SecurityGroups))
+	for _, group := range project.SecurityGroups {
+		auditCtx := withAudit(ctx, cfg, audit.Event{
+			Operation:    audit.OperationDeleteSecurityGroup,
+			ResourceID:   group.ID,
+			ResourceName: group.Name,
+			ProjectID:    project.ID,
+		})
+		futures = append(futures, workflow.ExecuteActivity(auditCtx, DeleteSecurityGroupActivity, cfg, group))
+	}
+
+	errs := make([]error, 0)
+	deleted := make([]string, 0, len(futures))
+	for i, future := range futures {
+		group := project.SecurityGroups[i]
+
+		if err := future.Get(ctx, nil); err != nil {
+			errs = append(errs, fmt.Errorf("error deleting security group: %w", err))
+			continue
+		}
+		deleted = append(deleted, group.ID)
+	}
+	if len(deleted) > 0 {
+		recordDeletions(ctx, deleted...)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,303 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/dns"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ResourcesQuery returns the resources a workflow has created. This works on
+// closed workflows so the resources of a terminated run can be found.
+const ResourcesQuery = "resources"
+
+// TeardownRequest identifies the provisioning run to clean up
+type TeardownRequest struct {
+	WorkflowID string
+	RunID      string
+}
+
+// TeardownWorkflowID is derived from the provisioning workflow's ID
+func TeardownWorkflowID(workflowID string) string {
+	return fmt.Sprintf("teardown-%s", workflowID)
+}
+
+// ProvisionNodeWorkflowID is the ID of the child workflow creating a node
+func ProvisionNodeWorkflowID(workflowID string, index int) string {
+	return fmt.Sprintf("%s_node_%d", workflowID, index)
+}
+
+// ResourceActivities read the resources recorded by other workflows so need
+// a Temporal client
+type ResourceActivities struct {
+	Client client.Client
+}
+
+// Used to reference the activity methods from the workflows
+var resourceActivities *ResourceActivities
+
+// Query the provisioning workflow and its children for the resources they
+// created. Nodes that were being created when the workflow stopped are only
+// recorded by the child workflows.
+func (r *ResourceActivities) GetRecordedResourcesActivity(ctx context.Context, req TeardownRequest) (*providers.ProjectResult, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("GetRecordedResourcesActivity", "workflowId", req.WorkflowID)
+
+	val, err := r.Client.QueryWorkflow(ctx, req.WorkflowID, req.RunID, ResourcesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error querying workflow resources: %w", err)
+	}
+
+	var project *providers.ProjectResult
+	if err := val.Get(&project); err != nil {
+		return nil, fmt.Errorf("error decoding workflow resources: %w", err)
+	}
+
+	if project.Plan == nil {
+		return project, nil
+	}
+
+	for i := range project.Plan.Nodes {
+		val, err := r.Client.QueryWorkflow(ctx, ProvisionNodeWorkflowID(req.WorkflowID, i), "", ResourcesQuery)
+		if err != nil {
+			var notFound *serviceerror.NotFound
+			if errors.As(err, &notFound) {
+				// The child was never started
+				continue
+			}
+			return nil, fmt.Errorf("error querying node resources: %w", err)
+		}
+
+		var node *providers.NodeResult
+		if err := val.Get(&node); err != nil {
+			return nil, fmt.Errorf("error decoding node resources: %w", err)
+		}
+
+		if node != nil && !slices.ContainsFunc(project.Nodes, func(n *providers.NodeResult) bool {
+			return n.ID == node.ID
+		}) {
+			project.Nodes = append(project.Nodes, node)
+		}
+	}
+
+	// A replacement takes over every node with the name, including any old
+	// nodes it didn't delete
+	for _, planned := range project.Plan.Nodes {
+		replaced, err := r.replacedNodes(ctx, ReplaceNodeWorkflowID(req.WorkflowID, planned.Name))
+		if err != nil {
+			return nil, err
+		}
+		if replaced == nil {
+			continue
+		}
+
+		project.Nodes = slices.DeleteFunc(project.Nodes, func(n *providers.NodeResult) bool {
+			return n.Name == planned.Name
+		})
+		project.Nodes = append(project.Nodes, replaced.Old...)
+		if replaced.New != nil {
+			project.Nodes = append(project.Nodes, replaced.New)
+		}
+
+		// Deleting a record which has already gone does nothing, so every
+		// record the replacement may have created is kept
+		for _, r := range replaced.DNSRecords {
+			if !slices.ContainsFunc(project.DNSRecords, func(d dns.Record) bool {
+				return d.Name == r.Name && d.Address.Equal(r.Address)
+			}) {
+				project.DNSRecords = append(project.DNSRecords, r)
+			}
+		}
+	}
+
+	return project, nil
+}
+
+// Delete the resources in the reverse order they were created. This runs in
+// a disconnected context so it works if the workflow has been cancelled.
+func cleanupResources(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Cleaning up resources", "projectId", project.ID, "nodes", len(project.Nodes))
+
+	ctx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+
+	// The records are changed by this worker, not the provider's
+	if err := deleteDNSRecords(ctx, cfg, project); err != nil {
+		return fmt.Errorf("error deleting dns records: %w", err)
+	}
+
+	ctx = withProviderTaskQueue(ctx, cfg)
+
+	// Stop sending traffic to the nodes before they're deleted
+	if err := deleteLoadBalancers(ctx, cfg, project); err != nil {
+		return err
+	}
+
+	// The network and project cannot be deleted with nodes in them
+	if err := deleteNodes(ctx, cfg, project.ID, project.Nodes); err != nil {
+		return err
+	}
+
+	if err := deleteSecurityGroups(ctx, cfg, project); err != nil {
+		return err
+	}
+
+	if project.Network != nil {
+		auditCtx := withAudit(ctx, cfg, audit.Event{
+			Operation:  audit.OperationDeleteNetwork,
+			ResourceID: project.Network.ID,
+			ProjectID:  project.ID,
+		})
+		if err := workflow.ExecuteActivity(auditCtx, DeleteNetworkActivity, cfg, project.Network).Get(ctx, nil); err != nil {
+			return fmt.Errorf("error deleting network: %w", err)
+		}
+		recordDeletions(ctx, project.Network.ID)
+	}
+
+	if project.ID != "" {
+		auditCtx := withAudit(ctx, cfg, audit.Event{
+			Operation:    audit.OperationDeleteProject,
+			ResourceID:   project.ID,
+			ResourceName: project.Name,
+			ProjectID:    project.ID,
+		})
+		if err := workflow.ExecuteActivity(auditCtx, DeleteProjectActivity, cfg, project).Get(ctx, nil); err != nil {
+			return fmt.Errorf("error deleting project: %w", err)
+		}
+		recordDeletions(ctx, project.ID)
+	}
+
+	return nil
+}
+
+// Delete the nodes in parallel. Each node that's deleted is given the time it
+// was deleted.
+func deleteNodes(ctx workflow.Context, cfg providers.CloudConfig, projectID string, nodes []*providers.NodeResult) error {
+	futures := make([]workflow.Future, 0, len(nodes))
+	for _, node := range nodes {
+		auditCtx := withAudit(ctx, cfg, audit.Event{
+			Operation:    audit.OperationDeleteNode,
+			ResourceID:   node.ID,
+			ResourceName: node.Name,
+			ProjectID:    projectID,
+		})
+		futures = append(futures, workflow.ExecuteActivity(auditCtx, DeleteNodeActivity, cfg, node))
+	}
+
+	errs := make([]error, 0)
+	deleted := make([]string, 0, len(futures))
+	for i, future := range futures {
+		node := nodes[i]
+
+		if err := future.Get(ctx, nil); err != nil {
+			errs = append(errs, fmt.Errorf("error deleting node: %w", err))
+			continue
+		}
+		deletedAt := workflow.Now(ctx)
+		node.DeletedAt = &deletedAt
+		deleted = append(deleted, node.ID)
+	}
+	if len(deleted) > 0 {
+		recordDeletions(ctx, deleted...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// TeardownWorkflow deletes the resources recorded by a provisioning workflow
+// which has been terminated, and returns its quota
+func TeardownWorkflow(ctx workflow.Context, req TeardownRequest) (result *providers.ProjectResult, err error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Starting teardown workflow", "workflowId", req.WorkflowID)
+
+	defer func() {
+		if err != nil {
+			setPhase(ctx, PhaseFailed)
+		}
+	}()
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Hour,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+		},
+	})
+
+	var project *providers.ProjectResult
+
+	// The teardown records when each node was deleted, so this reports the
+	// final cost of the project
+	if err := workflow.SetQueryHandler(ctx, CostQuery, func(asOf time.Time) (*providers.CostReport, error) {
+		return providers.NewCostReport(project, asOf), nil
+	}); err != nil {
+		return nil, fmt.Errorf("error setting cost query handler: %w", err)
+	}
+
+	if err := workflow.ExecuteActivity(ctx, resourceActivities.GetRecordedResourcesActivity, req).Get(ctx, &project); err != nil {
+		logger.Error("Error getting recorded resources", "error", err)
+		return nil, fmt.Errorf("error getting recorded resources: %w", err)
+	}
+
+	cfg := project.CloudConfig
+
+	// Make the teardown visible alongside the project
+	if err := workflow.UpsertTypedSearchAttributes(ctx,
+		ProviderSearchAttribute.ValueSet(string(cfg.Provider)),
+		RegionSearchAttribute.ValueSet(cfg.Region),
+		NodeCountSearchAttribute.ValueSet(int64(len(project.Nodes))),
+		OwnerSearchAttribute.ValueSet(cfg.Owner),
+		EnvironmentSearchAttribute.ValueSet(cfg.Environment),
+		ProjectIDSearchAttribute.ValueSet(project.ID),
+		PhaseSearchAttribute.ValueSet(string(PhaseTearingDown)),
+	); err != nil {
+		logger.Warn("Unable to set search attributes", "error", err)
+	}
+
+	if err := cleanupResources(ctx, cfg, project); err != nil {
+		logger.Error("Error cleaning up resources", "error", err)
+		return nil, err
+	}
+
+	if err := releaseQuota(ctx, cfg, req.WorkflowID, req.RunID); err != nil {
+		logger.Error("Error releasing quota", "error", err)
+		return nil, err
+	}
+
+	if err := releaseCIDR(ctx, cfg, req.WorkflowID, req.RunID); err != nil {
+		logger.Error("Error releasing network", "error", err)
+		return nil, err
+	}
+
+	setPhase(ctx, PhaseCompleted)
+
+	return project, nil
+}
@@ -0,0 +1,279 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/mrsimonemms/temporal/pkg/providers"
+	"github.com/mrsimonemms/temporal/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// Used to reference the resource activity methods
+var resourceActivities *workflow.ResourceActivities
+
+func Test_CloudProvisionWorkflowCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	mockAudit(env)
+	mockNotify(env)
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Region:   "some-region",
+		Plan: &providers.Plan{
+			Nodes: []*providers.PlannedNode{{Name: "node-0"}, {Name: "node-1"}},
+		},
+	}
+	project := &providers.ProjectResult{CloudConfig: cfg, ID: "project-id"}
+	network := &providers.NetworkResult{ID: "network-id"}
+	node := &providers.NodeResult{ID: "node-id", Name: "node-0"}
+
+	env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, mock.Anything).Return(&workflow.QuotaReservation{Granted: true}, nil)
+	env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(project, nil)
+	env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, mock.Anything).Return(network, nil)
+
+	// One node is created and the other is still being created when cancelled
+	env.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
+	env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, mock.Anything, mock.Anything, cfg.Plan.Nodes[0]).Return(node, nil)
+	env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, mock.Anything, mock.Anything, cfg.Plan.Nodes[1]).
+		After(time.Hour).Return(nil, temporal.NewCanceledError())
+
+	// The created resources are deleted and the quota released
+	env.OnActivity(workflow.DeleteNodeActivity, mock.Anything, mock.Anything, node).Return(nil).Once()
+	env.OnActivity(workflow.DeleteNetworkActivity, mock.Anything, mock.Anything, network).Return(nil).Once()
+	env.OnActivity(workflow.DeleteProjectActivity, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	env.OnActivity(quotaActivities.ReleaseQuotaActivity, mock.Anything, mock.Anything).Return(nil).Once()
+
+	env.RegisterDelayedCallback(env.CancelWorkflow, time.Minute)
+
+	mockPlan(env, cfg.Plan)
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(env.IsWorkflowCompleted())
+
+	assert.True(temporal.IsCanceledError(env.GetWorkflowError()))
+
+	env.AssertExpectations(t)
+}
+
+func Test_CloudProvisionWorkflowCancelledAwaitingApproval(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{
+		Approval: providers.ApprovalPolicy{MaxNodes: 1},
+	})
+	mockNotify(env)
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Plan: &providers.Plan{
+			Nodes: []*providers.PlannedNode{{Name: "node-0"}, {Name: "node-1"}},
+		},
+	}
+
+	env.RegisterDelayedCallback(env.CancelWorkflow, time.Minute)
+
+	mockPlan(env, cfg.Plan)
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(env.IsWorkflowCompleted())
+
+	assert.True(temporal.IsCanceledError(env.GetWorkflowError()))
+	env.AssertNotCalled(t, "CreateProjectActivity", mock.Anything, mock.Anything)
+}
+
+func Test_TeardownWorkflow(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	mockAudit(env)
+
+	req := workflow.TeardownRequest{
+		WorkflowID: "provision-some-project",
+		RunID:      "some-run-id",
+	}
+	project := &providers.ProjectResult{
+		CloudConfig: providers.CloudConfig{
+			Provider: providers.CloudProviderAWS,
+			Region:   "some-region",
+			Subnet:   "10.0.0.0/24",
+		},
+		ID:      "project-id",
+		Network: &providers.NetworkResult{ID: "network-id"},
+		SecurityGroups: []*providers.SecurityGroupResult{
+			{ID: "sg-id", Name: "cluster"},
+		},
+		Nodes: []*providers.NodeResult{
+			{ID: "node-0", HourlyCost: 1},
+			{ID: "node-1", HourlyCost: 1},
+		},
+		LoadBalancers: []*providers.LoadBalancerResult{
+			{ID: "lb-id", Name: "web"},
+		},
+	}
+
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, req).Return(project, nil)
+	env.OnActivity(workflow.DeleteLoadBalancerActivity, mock.Anything, project.CloudConfig, project.LoadBalancers[0]).Return(nil).Once()
+	env.OnActivity(workflow.DeleteNodeActivity, mock.Anything, project.CloudConfig, mock.Anything).Return(nil).Twice()
+	env.OnActivity(workflow.DeleteSecurityGroupActivity, mock.Anything, project.CloudConfig, project.SecurityGroups[0]).Return(nil).Once()
+	env.OnActivity(workflow.DeleteNetworkActivity, mock.Anything, project.CloudConfig, project.Network).Return(nil).Once()
+	env.OnActivity(workflow.DeleteProjectActivity, mock.Anything, project.CloudConfig, mock.MatchedBy(func(p *providers.ProjectResult) bool {
+		return p.ID == project.ID
+	})).Return(nil).Once()
+	env.OnActivity(quotaActivities.ReleaseQuotaActivity, mock.Anything, workflow.QuotaRequest{
+		WorkflowID: req.WorkflowID,
+		RunID:      req.RunID,
+		Provider:   project.Provider,
+		Region:     project.Region,
+	}).Return(nil).Once()
+	env.OnActivity(networkActivities.ReleaseCIDRActivity, mock.Anything, workflow.CIDRReleaseRequest{
+		WorkflowID: req.WorkflowID,
+		RunID:      req.RunID,
+	}).Return(nil).Once()
+
+	// Every deletion is recorded in the inventory
+	deleted := make([]string, 0)
+	env.OnActivity(inventoryActivities.RecordDeletionsActivity, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, deletions []inventory.Deletion) error {
+			for _, d := range deletions {
+				assert.NotEmpty(d.WorkflowID)
+				deleted = append(deleted, d.ID)
+			}
+			return nil
+		}).Times(5)
+
+	env.ExecuteWorkflow(workflow.TeardownWorkflow, req)
+	assert.True(env.IsWorkflowCompleted())
+
+	var result *providers.ProjectResult
+	assert.NoError(env.GetWorkflowResult(&result))
+	assert.Equal(project.ID, result.ID)
+	assert.ElementsMatch([]string{"lb-id", "node-0", "node-1", "sg-id", "network-id", "project-id"}, deleted)
+
+	// The nodes stop accruing cost once they're deleted
+	for _, node := range result.Nodes {
+		assert.NotNil(node.DeletedAt)
+	}
+
+	val, err := env.QueryWorkflow(workflow.CostQuery, time.Now().Add(time.Hour*24*365))
+	assert.NoError(err)
+
+	var cost *providers.CostReport
+	assert.NoError(val.Get(&cost))
+	assert.Equal(project.ID, cost.ProjectID)
+	assert.Zero(cost.HourlyCost)
+
+	env.AssertExpectations(t)
+}
+
+func Test_TeardownWorkflowWhilstPlanning(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	mockNotify(env)
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Region:   "some-region",
+		Subnet:   "10.0.0.0/24",
+		AutoCIDR: true,
+		Supernet: "10.0.0.0/8",
+	}
+
+	env.OnActivity(networkActivities.AllocateCIDRActivity, mock.Anything, mock.Anything).Return("10.1.0.0/24", nil).Once()
+	env.OnActivity(workflow.PlanProjectActivity, mock.Anything, mock.Anything).After(time.Hour).Return(&providers.Plan{}, nil)
+	env.OnActivity(networkActivities.ReleaseCIDRActivity, mock.Anything, mock.Anything).Return(nil)
+
+	// The run is stopped whilst it's planning, after the range was allocated
+	var project *providers.ProjectResult
+	env.RegisterDelayedCallback(func() {
+		val, err := env.QueryWorkflow(workflow.ResourcesQuery)
+		assert.NoError(err)
+		assert.NoError(val.Get(&project))
+
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(env.IsWorkflowCompleted())
+	assert.Equal("10.1.0.0/24", project.Subnet)
+
+	// The teardown releases the allocated range
+	req := workflow.TeardownRequest{
+		WorkflowID: "provision-some-project",
+		RunID:      "some-run-id",
+	}
+	env = testSuite.NewTestWorkflowEnvironment()
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, req).Return(project, nil)
+	env.OnActivity(quotaActivities.ReleaseQuotaActivity, mock.Anything, mock.Anything).Return(nil).Once()
+	env.OnActivity(networkActivities.ReleaseCIDRActivity, mock.Anything, workflow.CIDRReleaseRequest{
+		WorkflowID: req.WorkflowID,
+		RunID:      req.RunID,
+	}).Return(nil).Once()
+
+	env.ExecuteWorkflow(workflow.TeardownWorkflow, req)
+	assert.NoError(env.GetWorkflowError())
+	env.AssertExpectations(t)
+}
+
+func Test_TeardownWorkflowNodeFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	events := mockAudit(env)
+
+	project := &providers.ProjectResult{
+		CloudConfig: providers.CloudConfig{Provider: providers.CloudProviderAWS},
+		ID:          "project-id",
+		Network:     &providers.NetworkResult{ID: "network-id"},
+		Nodes:       []*providers.NodeResult{{ID: "node-0"}},
+	}
+
+	env.OnActivity(resourceActivities.GetRecordedResourcesActivity, mock.Anything, mock.Anything).Return(project, nil)
+	env.OnActivity(workflow.DeleteNodeActivity, mock.Anything, mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("some error", "some-type", nil))
+
+	env.ExecuteWorkflow(workflow.TeardownWorkflow, workflow.TeardownRequest{WorkflowID: "some-id"})
+	assert.True(env.IsWorkflowCompleted())
+	assert.ErrorContains(env.GetWorkflowError(), "error deleting node")
+
+	// The network can't be deleted whilst it has nodes in it
+	env.AssertNotCalled(t, "DeleteNetworkActivity", mock.Anything, mock.Anything, mock.Anything)
+	env.AssertNotCalled(t, "DeleteProjectActivity", mock.Anything, mock.Anything, mock.Anything)
+
+	// The deletion is audited by the activity
+	assert.Len(*events, 1)
+	assert.Equal(audit.OperationDeleteNode, (*events)[0].Operation)
+	assert.Equal("node-0", (*events)[0].ResourceID)
+	assert.Equal("project-id", (*events)[0].ProjectID)
+}
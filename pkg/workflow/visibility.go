@@ -0,0 +1,79 @@
+/*
+ * Copyright 2025 Simon Emms <simon@simonemms.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListWorkflowTypes are the workflows shown when listing projects
+var ListWorkflowTypes = []string{
+	"CloudProvisionWorkflow",
+	"TeardownWorkflow",
+}
+
+// ListFilter narrows down the projects returned from the visibility API. Empty
+// fields are ignored.
+type ListFilter struct {
+	Provider    string
+	Region      string
+	Owner       string
+	Environment string
+	Phase       string
+	// Workflow execution status, eg Running or Failed
+	Status string
+}
+
+// Quote a value for a visibility query. Backslashes are escaped first so they
+// can't escape the closing quote
+func quote(val string) string {
+	val = strings.ReplaceAll(val, "\\", "\\\\")
+	return "'" + strings.ReplaceAll(val, "'", "\\'") + "'"
+}
+
+// Query builds the visibility query
+func (f ListFilter) Query() string {
+	types := make([]string, 0, len(ListWorkflowTypes))
+	for _, t := range ListWorkflowTypes {
+		types = append(types, quote(t))
+	}
+
+	clauses := []string{
+		fmt.Sprintf("WorkflowType IN (%s)", strings.Join(types, ", ")),
+	}
+
+	for _, filter := range []struct {
+		Key   string
+		Value string
+	}{
+		{Key: ProviderSearchAttribute.GetName(), Value: f.Provider},
+		{Key: RegionSearchAttribute.GetName(), Value: f.Region},
+		{Key: OwnerSearchAttribute.GetName(), Value: f.Owner},
+		{Key: EnvironmentSearchAttribute.GetName(), Value: f.Environment},
+		{Key: PhaseSearchAttribute.GetName(), Value: f.Phase},
+		{Key: "ExecutionStatus", Value: f.Status},
+	} {
+		if filter.Value != "" {
+			clauses = append(clauses, fmt.Sprintf("%s = %s", filter.Key, quote(filter.Value)))
+		}
+	}
+
+	// No ORDER BY - SQL visibility, including the dev server, and Temporal Cloud
+	// reject it and the default order is already newest first
+	return strings.Join(clauses, " AND ")
+}
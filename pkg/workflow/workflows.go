@@ -17,18 +17,81 @@
 package workflow
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/notify"
 	"github.com/mrsimonemms/temporal/pkg/providers"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
-func CloudProvisionWorkflow(ctx workflow.Context, cfg providers.CloudConfig) (*providers.ProjectResult, error) {
+const (
+	CostQuery   = "cost"
+	StatusQuery = "status"
+)
+
+// ProvisionStatus is the progress of the provisioning workflow
+type ProvisionStatus struct {
+	Phase        Phase
+	ProjectID    string
+	NodesPlanned int
+	NodesCreated int
+}
+
+// The phase is read from the search attributes so there's a single record of it
+func currentPhase(ctx workflow.Context) Phase {
+	phase, _ := workflow.GetTypedSearchAttributes(ctx).GetKeyword(PhaseSearchAttribute)
+	return Phase(phase)
+}
+
+// ProviderTaskQueue is where the activities that talk to a cloud provider are
+// sent, so only the workers dedicated to that cloud need its credentials
+func ProviderTaskQueue(base string, provider providers.CloudProvider, region string) string {
+	return fmt.Sprintf("%s-%s-%s", base, provider, region)
+}
+
+// Route the activities to the provider's task queue. This is derived from the
+// workflow's task queue.
+func withProviderTaskQueue(ctx workflow.Context, cfg providers.CloudConfig) workflow.Context {
+	return workflow.WithTaskQueue(ctx, ProviderTaskQueue(workflow.GetInfo(ctx).TaskQueueName, cfg.Provider, cfg.Region))
+}
+
+// Ensure the plan is within the worker's budget - this must be done before any
+// resources are created. The plan's cost is calculated by the worker.
+func checkBudget(plan *providers.Plan, policy *Policy) error {
+	if policy.MaxMonthlyCost <= 0 || plan.MonthlyCost <= policy.MaxMonthlyCost {
+		return nil
+	}
+
+	return temporal.NewNonRetryableApplicationError(
+		fmt.Sprintf("plan costs $%.2f/month which exceeds budget of $%.2f/month", plan.MonthlyCost, policy.MaxMonthlyCost),
+		"BudgetExceeded",
+		nil,
+	)
+}
+
+func CloudProvisionWorkflow(ctx workflow.Context, cfg providers.CloudConfig) (result *providers.ProjectResult, err error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Starting cloud provisioning workflow")
 
+	project := &providers.ProjectResult{}
+
+	defer func() {
+		if temporal.IsCanceledError(err) {
+			setPhase(ctx, PhaseCancelled)
+		} else if err != nil {
+			setPhase(ctx, PhaseFailed)
+			sendNotification(ctx, cfg, notify.Event{
+				Type:      notify.EventFailed,
+				ProjectID: project.ID,
+				Error:     err.Error(),
+			})
+		}
+	}()
+
 	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
 		StartToCloseTimeout: time.Hour,
 		RetryPolicy: &temporal.RetryPolicy{
@@ -36,54 +99,310 @@ func CloudProvisionWorkflow(ctx workflow.Context, cfg providers.CloudConfig) (*p
 			BackoffCoefficient: 2.0,
 			MaximumInterval:    time.Minute,
 		},
+		// Know whether an in-flight resource was created before cleaning up
+		WaitForCancellation: true,
 	})
 
+	if err := setProvisionQueryHandlers(ctx, project); err != nil {
+		return nil, err
+	}
+
+	// The quota and network range are held for this run
+	execution := workflow.GetInfo(ctx).WorkflowExecution
+
+	// The network's range is held until the network is deleted, so it's only
+	// kept on failure if the resources couldn't be cleaned up
+	resourcesLeft := false
+	defer func() {
+		if err != nil && !resourcesLeft {
+			if err := releaseCIDR(ctx, cfg, execution.ID, execution.RunID); err != nil {
+				logger.Error("Error releasing network", "error", err)
+			}
+		}
+	}()
+
+	if err := planProject(ctx, &cfg, project); err != nil {
+		return nil, err
+	}
+
+	project.CloudConfig = cfg
+	sendNotification(ctx, cfg, notify.Event{Type: notify.EventStarted})
+
+	policy, err := getPolicy(ctx)
+	if err != nil {
+		logger.Error("Unable to get policy", "error", err)
+		return nil, err
+	}
+
+	if err := checkBudget(cfg.Plan, policy); err != nil {
+		logger.Error("Plan exceeds budget", "error", err)
+		return nil, err
+	}
+
+	// Expensive requests must be signed off before anything is created
+	approval, err := awaitApproval(ctx, cfg, policy.Approval)
+	if err != nil {
+		logger.Error("Provisioning not approved", "error", err)
+		return nil, err
+	}
+
+	// Ensure there's enough capacity in the account before fanning out
+	setPhase(ctx, PhaseAwaitingQuota)
+	if _, err := reserveQuota(ctx, cfg); err != nil {
+		logger.Error("Unable to reserve quota", "error", err)
+		if temporal.IsCanceledError(err) {
+			// Remove the request from the queue
+			if err := releaseQuota(ctx, cfg, execution.ID, execution.RunID); err != nil {
+				logger.Error("Error releasing quota", "error", err)
+			}
+		}
+		return nil, err
+	}
+
+	setPhase(ctx, PhaseProvisioning)
+	if err := provisionResources(ctx, cfg, project); err != nil {
+		// The quota is only released once the resources have been deleted, so
+		// anything left behind still counts against it until it's torn down
+		setPhase(ctx, PhaseTearingDown)
+		if err := cleanupResources(ctx, cfg, project); err != nil {
+			logger.Error("Error cleaning up resources - quota is held until they're torn down", "error", err)
+			resourcesLeft = true
+		} else if err := releaseQuota(ctx, cfg, execution.ID, execution.RunID); err != nil {
+			logger.Error("Error releasing quota", "error", err)
+		}
+		return nil, err
+	}
+	project.Approval = approval
+
+	setPhase(ctx, PhaseCompleted)
+
+	sendNotification(ctx, cfg, notify.Event{
+		Type:      notify.EventSucceeded,
+		ProjectID: project.ID,
+	})
+
+	return project, nil
+}
+
+// Allocate the network's range and generate the plan from it. The plan is
+// always generated by the worker so the resources and cost can't be changed
+// by editing a saved plan - a saved plan must match the worker's plan and
+// only its names are kept.
+func planProject(ctx workflow.Context, cfg *providers.CloudConfig, project *providers.ProjectResult) error {
+	logger := workflow.GetLogger(ctx)
+
+	saved := cfg.Plan
+	cfg.Plan = nil
+	if saved != nil {
+		// Check the saved plan's range rather than picking a new one
+		cfg.Subnet = saved.Network.Subnet
+		cfg.AutoCIDR = false
+	}
+
+	if err := allocateCIDR(ctx, cfg); err != nil {
+		logger.Error("Unable to allocate network", "error", err)
+		return err
+	}
+
+	// The teardown reads the range from the project, so it's released if the
+	// run is terminated before the plan is generated
+	project.CloudConfig = *cfg
+
+	logger.Debug("Generating plan")
+	var plan *providers.Plan
+	if err := workflow.ExecuteActivity(withProviderTaskQueue(ctx, *cfg), PlanProjectActivity, *cfg).Get(ctx, &plan); err != nil {
+		logger.Error("Error generating plan", "error", err)
+		return fmt.Errorf("error generating plan: %w", err)
+	}
+
+	if saved != nil {
+		var err error
+		plan, err = providers.ApplySavedPlan(plan, saved)
+		if err != nil {
+			logger.Error("Saved plan does not match config", "error", err)
+			return temporal.NewNonRetryableApplicationError(err.Error(), "PlanMismatch", err)
+		}
+	}
+
+	cfg.Plan = plan
+	return nil
+}
+
+// Register the queries which report on the project whilst it's being built
+// and after it has finished
+func setProvisionQueryHandlers(ctx workflow.Context, project *providers.ProjectResult) error {
+	if err := workflow.SetQueryHandler(ctx, ResourcesQuery, func() (*providers.ProjectResult, error) {
+		return project, nil
+	}); err != nil {
+		return fmt.Errorf("error setting resources query handler: %w", err)
+	}
+
+	if err := workflow.SetQueryHandler(ctx, StatusQuery, func() (*ProvisionStatus, error) {
+		status := &ProvisionStatus{
+			Phase:        currentPhase(ctx),
+			ProjectID:    project.ID,
+			NodesCreated: len(project.Nodes),
+		}
+		if project.Plan != nil {
+			status.NodesPlanned = len(project.Plan.Nodes)
+		}
+		return status, nil
+	}); err != nil {
+		return fmt.Errorf("error setting status query handler: %w", err)
+	}
+
+	// Report the spend of the project - the time is provided by the caller so
+	// the accrued cost is up to date after the workflow has completed
+	if err := workflow.SetQueryHandler(ctx, CostQuery, func(asOf time.Time) (*providers.CostReport, error) {
+		return providers.NewCostReport(project, asOf), nil
+	}); err != nil {
+		return fmt.Errorf("error setting cost query handler: %w", err)
+	}
+
+	return nil
+}
+
+// Create the project, network and nodes in the cloud provider
+func provisionResources(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	logger := workflow.GetLogger(ctx)
+
+	providerCtx := withProviderTaskQueue(ctx, cfg)
+
 	logger.Debug("Create project in cloud provider")
-	var project *providers.ProjectResult
-	if err := workflow.ExecuteActivity(ctx, CreateProjectActivity, cfg).Get(ctx, &project); err != nil {
+	auditCtx := withAudit(providerCtx, cfg, audit.Event{
+		Operation:    audit.OperationCreateProject,
+		ResourceName: cfg.Plan.Project.Name,
+	})
+	if err := workflow.ExecuteActivity(auditCtx, CreateProjectActivity, cfg).Get(ctx, project); err != nil {
 		logger.Error("Error executing cloud provisioning activity", "error", err)
-		return nil, fmt.Errorf("error executing cloud provision activity: %w", err)
+		return fmt.Errorf("error executing cloud provision activity: %w", err)
 	}
 
+	if err := workflow.UpsertTypedSearchAttributes(ctx, ProjectIDSearchAttribute.ValueSet(project.ID)); err != nil {
+		logger.Warn("Unable to set project ID search attribute", "error", err)
+	}
+	recordResources(ctx, projectRecord(ctx, project))
+
 	logger.Debug("Create network in cloud provider")
 	var network *providers.NetworkResult
-	if err := workflow.ExecuteActivity(ctx, SetupNetworkActivity, cfg, project).Get(ctx, &network); err != nil {
+	auditCtx = withAudit(providerCtx, cfg, audit.Event{
+		Operation:    audit.OperationCreateNetwork,
+		ResourceName: "network",
+		ProjectID:    project.ID,
+	})
+	if err := workflow.ExecuteActivity(auditCtx, SetupNetworkActivity, cfg, project).Get(ctx, &network); err != nil {
 		logger.Error("Error setting up network activity", "error", err)
-		return nil, fmt.Errorf("error setting up network activity: %w", err)
+		return fmt.Errorf("error setting up network activity: %w", err)
 	}
 	project.Network = network
+	recordResources(ctx, networkRecord(ctx, project, network))
+
+	// The nodes are attached to the security groups when they're created
+	if err := createSecurityGroups(ctx, cfg, project); err != nil {
+		return err
+	}
+
+	if err := provisionNodes(ctx, cfg, project); err != nil {
+		return err
+	}
+
+	// The load balancers only send traffic to nodes which are ready
+	if err := createLoadBalancers(ctx, cfg, project); err != nil {
+		return err
+	}
+
+	return syncDNSRecords(ctx, cfg, project)
+}
+
+// Create the nodes in child workflows so they're built in parallel
+func provisionNodes(ctx workflow.Context, cfg providers.CloudConfig, project *providers.ProjectResult) error {
+	logger := workflow.GetLogger(ctx)
 
 	// Run as a child process to fan-out to support multiple node creation
 	logger.Debug("Create nodes in cloud provider")
 	project.Nodes = make([]*providers.NodeResult, 0)
 
-	provisionNodeFutures := map[workflow.Context]workflow.ChildWorkflowFuture{}
+	provisionNodeFutures := make([]workflow.ChildWorkflowFuture, 0, len(cfg.Plan.Nodes))
 
 	// Invoke the child workflows in parallel
-	for i := range cfg.VMCount {
+	for i, node := range cfg.Plan.Nodes {
+		// Stop fanning out if the workflow has been cancelled
+		if ctx.Err() != nil {
+			break
+		}
+
 		// Set ID so can track the jobs in dashboard easier
 		childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
 			WorkflowTaskTimeout: time.Hour,
-			WorkflowID:          fmt.Sprintf("%s_node_%d", workflow.GetInfo(ctx).WorkflowExecution.ID, i),
+			WorkflowID:          ProvisionNodeWorkflowID(workflow.GetInfo(ctx).WorkflowExecution.ID, i),
+			Memo: map[string]any{
+				TriggeredByMemo: actor(ctx),
+			},
 		})
 
 		// Execute the child workflow and store results as a Future
-		provisionNodeFutures[childCtx] = workflow.ExecuteChildWorkflow(childCtx, ProvisionNodeWorkflow, cfg, project)
+		provisionNodeFutures = append(provisionNodeFutures, workflow.ExecuteChildWorkflow(childCtx, ProvisionNodeWorkflow, cfg, project, node))
 	}
 
-	// Now the child workflows are running, wait for the results
-	for ctx, workflow := range provisionNodeFutures {
+	// Now the child workflows are running, wait for all the results so every
+	// node that was created is known, even if one has failed
+	errs := make([]error, 0)
+	for _, future := range provisionNodeFutures {
 		var node *providers.NodeResult
 
-		if err := workflow.Get(ctx, &node); err != nil {
+		if err := future.Get(ctx, &node); err != nil {
 			logger.Error("Error provisioning nodes", "error", err)
-			return nil, fmt.Errorf("error provisioning nodes: %w", err)
+			errs = append(errs, err)
+			continue
 		}
 
 		project.Nodes = append(project.Nodes, node)
 	}
 
-	return project, nil
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error provisioning nodes: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// PlanWorkflow previews the resources that CloudProvisionWorkflow would create
+func PlanWorkflow(ctx workflow.Context, cfg providers.CloudConfig) (*providers.Plan, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Starting plan workflow")
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+		},
+	})
+
+	if err := allocateCIDR(ctx, &cfg); err != nil {
+		logger.Error("Unable to allocate network", "error", err)
+		return nil, err
+	}
+
+	// The range is only previewed, so it's not held for the project
+	defer func() {
+		execution := workflow.GetInfo(ctx).WorkflowExecution
+		if err := releaseCIDR(ctx, cfg, execution.ID, execution.RunID); err != nil {
+			logger.Error("Error releasing network", "error", err)
+		}
+	}()
+
+	var plan *providers.Plan
+	if err := workflow.ExecuteActivity(withProviderTaskQueue(ctx, cfg), PlanProjectActivity, cfg).Get(ctx, &plan); err != nil {
+		logger.Error("Error generating plan", "error", err)
+		return nil, fmt.Errorf("error generating plan: %w", err)
+	}
+
+	return plan, nil
 }
 
 // Run as a child worker
@@ -91,6 +410,7 @@ func ProvisionNodeWorkflow(
 	ctx workflow.Context,
 	cfg providers.CloudConfig,
 	project *providers.ProjectResult,
+	plannedNode *providers.PlannedNode,
 ) (*providers.NodeResult, error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Starting node provisioning workflow")
@@ -102,19 +422,60 @@ func ProvisionNodeWorkflow(
 			BackoffCoefficient: 2.0,
 			MaximumInterval:    time.Minute,
 		},
+		WaitForCancellation: true,
 	})
 
+	ctx = withProviderTaskQueue(ctx, cfg)
+
+	// The parent only knows about the node once this workflow has finished
 	var node *providers.NodeResult
-	if err := workflow.ExecuteActivity(ctx, ProvisionNodeActivity, cfg, project).Get(ctx, &node); err != nil {
+	if err := workflow.SetQueryHandler(ctx, ResourcesQuery, func() (*providers.NodeResult, error) {
+		return node, nil
+	}); err != nil {
+		return nil, fmt.Errorf("error setting resources query handler: %w", err)
+	}
+
+	if err := provisionNode(ctx, cfg, project, plannedNode, &node); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// Create the node and wait for it to become ready. The node is set as soon as
+// it's created so it can be queried, and it's deleted if it never becomes
+// ready.
+func provisionNode(
+	ctx workflow.Context,
+	cfg providers.CloudConfig,
+	project *providers.ProjectResult,
+	plannedNode *providers.PlannedNode,
+	node **providers.NodeResult,
+) error {
+	logger := workflow.GetLogger(ctx)
+
+	auditCtx := withAudit(ctx, cfg, audit.Event{
+		Operation:    audit.OperationCreateNode,
+		ResourceName: plannedNode.Name,
+		ProjectID:    project.ID,
+	})
+	if err := workflow.ExecuteActivity(auditCtx, ProvisionNodeActivity, cfg, project, plannedNode).Get(ctx, node); err != nil {
 		logger.Error("Error executing node provisioning activity", "error", err)
-		return nil, fmt.Errorf("error executing node provision activity: %w", err)
+		return fmt.Errorf("error executing node provision activity: %w", err)
 	}
+	recordResources(ctx, nodeRecord(ctx, cfg, project.ID, *node))
 
 	var isReady *providers.NodeReadyResult
-	if err := workflow.ExecuteActivity(ctx, AwaitForNodeRunningActivity, cfg, node).Get(ctx, &isReady); err != nil {
+	if err := workflow.ExecuteActivity(ctx, AwaitForNodeRunningActivity, cfg, *node).Get(ctx, &isReady); err != nil {
 		logger.Error("Error whilst waiting for node to become ready", "error", err)
-		return nil, fmt.Errorf("error waiting for node to become ready: %w", err)
+
+		// The caller won't receive the node so it must be deleted here
+		if err := cleanupResources(ctx, cfg, &providers.ProjectResult{Nodes: []*providers.NodeResult{*node}}); err != nil {
+			logger.Error("Error deleting node", "error", err)
+		}
+
+		return fmt.Errorf("error waiting for node to become ready: %w", err)
 	}
 
-	return node, nil
+	return nil
 }
@@ -17,19 +17,116 @@
 package workflow_test
 
 import (
+	"context"
+	"fmt"
 	"net"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/mrsimonemms/temporal/pkg/audit"
+	"github.com/mrsimonemms/temporal/pkg/inventory"
+	"github.com/mrsimonemms/temporal/pkg/notify"
 	"github.com/mrsimonemms/temporal/pkg/providers"
 	"github.com/mrsimonemms/temporal/pkg/workflow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"google.golang.org/grpc"
 )
 
+// Used to reference the quota activity methods
+var quotaActivities *workflow.QuotaActivities
+
+// Used to reference the inventory activity methods
+var inventoryActivities *workflow.InventoryActivities
+
+// Used to reference the network activity methods
+var networkActivities *workflow.NetworkActivities
+
+// Used to reference the notification activity methods
+var notificationActivities *workflow.NotificationActivities
+
+// Give the workflow the worker's policy
+func registerPolicy(env *testsuite.TestWorkflowEnvironment, policy workflow.Policy) {
+	env.RegisterActivity(&workflow.PolicyActivities{Policy: policy})
+}
+
+// Have the worker generate the same plan as the one that was saved
+func mockPlan(env *testsuite.TestWorkflowEnvironment, plan *providers.Plan) {
+	env.OnActivity(workflow.PlanProjectActivity, mock.Anything, mock.Anything).Return(plan, nil)
+}
+
+// Accept the notifications, returning the events that have been sent
+func mockNotify(env *testsuite.TestWorkflowEnvironment) *[]*notify.Event {
+	events := make([]*notify.Event, 0)
+	env.RegisterWorkflow(workflow.NotificationWorkflow)
+	env.OnActivity(notificationActivities.NotifyActivity, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, event *notify.Event) error {
+			events = append(events, event)
+			return nil
+		}).
+		Maybe()
+	return &events
+}
+
+// The types of the notifications that have been sent
+func notificationTypes(events *[]*notify.Event) []notify.EventType {
+	types := make([]notify.EventType, 0, len(*events))
+	for _, e := range *events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// Capture the changes the workflow asks the activities to audit. The mocked
+// activities don't record anything, so this is what they would record.
+func mockAudit(env *testsuite.TestWorkflowEnvironment) *[]audit.Event {
+	var mu sync.Mutex
+	events := make([]audit.Event, 0)
+
+	env.SetWorkerOptions(worker.Options{
+		Interceptors: []interceptor.WorkerInterceptor{&workflow.AuditInterceptor{}},
+	})
+	env.SetOnActivityStartedListener(func(_ *activity.Info, ctx context.Context, _ converter.EncodedValues) {
+		payload, ok := interceptor.Header(ctx)[workflow.AuditHeader]
+		if !ok {
+			return
+		}
+
+		var changes []audit.Event
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &changes); err != nil {
+			panic(err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, changes...)
+	})
+	return &events
+}
+
+// Match a call to record a single resource in the inventory
+func inventoryRecord(kind inventory.Kind, id string) any {
+	return mock.MatchedBy(func(records []*inventory.Record) bool {
+		return len(records) == 1 && records[0].Kind == kind && records[0].ID == id
+	})
+}
+
 func Test_CloudProvisionWorkflow(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	events := mockAudit(env)
+	notifications := mockNotify(env)
 
 	expectedNodes := []*providers.NodeResult{
 		{
@@ -49,8 +146,17 @@ func Test_CloudProvisionWorkflow(t *testing.T) {
 		Provider: providers.CloudProviderAWS,
 		VMCount:  len(expectedNodes),
 	}
+	expectedPlan := &providers.Plan{
+		Project: providers.PlannedProject{Name: "some-project"},
+	}
+	for _, node := range expectedNodes {
+		expectedPlan.Nodes = append(expectedPlan.Nodes, &providers.PlannedNode{Name: node.Name})
+	}
+	plannedCfg := cfg
+	plannedCfg.Plan = expectedPlan
+
 	expectedProject := &providers.ProjectResult{
-		CloudConfig: cfg,
+		CloudConfig: plannedCfg,
 		ID:          "some-id",
 	}
 	expectedNetwork := &providers.NetworkResult{
@@ -65,32 +171,380 @@ func Test_CloudProvisionWorkflow(t *testing.T) {
 	}
 
 	// Mock the activity responses
-	env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(expectedProject, nil)
-	env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, expectedProject).Return(expectedNetwork, nil)
+	env.OnActivity(workflow.PlanProjectActivity, mock.Anything, cfg).Return(expectedPlan, nil)
+	env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, mock.Anything).Return(&workflow.QuotaReservation{Granted: true}, nil)
+	env.OnActivity(workflow.CreateProjectActivity, mock.Anything, plannedCfg).Return(expectedProject, nil)
+	env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, plannedCfg, expectedProject).Return(expectedNetwork, nil)
 
 	// Mock the child workflow
 	env.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
 	for _, node := range expectedNodes {
-		env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, mock.Anything, mock.Anything).Return(node, nil).Once()
+		env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(node, nil).Once()
+	}
+
+	// The phase and project ID are recorded for visibility
+	for _, phase := range []workflow.Phase{workflow.PhaseAwaitingQuota, workflow.PhaseProvisioning, workflow.PhaseCompleted} {
+		env.OnUpsertTypedSearchAttributes(temporal.NewSearchAttributes(workflow.PhaseSearchAttribute.ValueSet(string(phase)))).Return(nil).Once()
 	}
+	env.OnUpsertTypedSearchAttributes(temporal.NewSearchAttributes(workflow.ProjectIDSearchAttribute.ValueSet(expectedProject.ID))).
+		Return(nil).Once()
 
+	// The resources are recorded in the inventory
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, inventoryRecord(inventory.KindProject, expectedProject.ID)).
+		Return(nil).Once()
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, inventoryRecord(inventory.KindNetwork, expectedNetwork.ID)).
+		Return(nil).Once()
+
+	env.SetMemoOnStart(map[string]any{workflow.TriggeredByMemo: "alice@laptop"})
 	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
 	assert.True(t, env.IsWorkflowCompleted())
 
 	var result *providers.ProjectResult
 	assert.NoError(t, env.GetWorkflowResult(&result))
 
+	// The project and network are audited by this workflow - the nodes are
+	// audited by the child workflows. The activities fill in the resource IDs
+	// and the result of each attempt.
+	assert.Len(t, *events, 2)
+	for i, op := range []audit.Operation{audit.OperationCreateProject, audit.OperationCreateNetwork} {
+		event := (*events)[i]
+		assert.Equal(t, op, event.Operation)
+		assert.Equal(t, "alice@laptop", event.Actor)
+		assert.Equal(t, "default-test-workflow-id", event.WorkflowID)
+		assert.Empty(t, event.ResourceID)
+	}
+	assert.Equal(t, "some-project", (*events)[0].ResourceName)
+	assert.Equal(t, expectedProject.ID, (*events)[1].ProjectID)
+
+	assert.Equal(t, []notify.EventType{notify.EventStarted, notify.EventSucceeded}, notificationTypes(notifications))
+	succeeded := (*notifications)[1]
+	assert.Equal(t, "some-project", succeeded.Project)
+	assert.Equal(t, expectedProject.ID, succeeded.ProjectID)
+	assert.Equal(t, "alice@laptop", succeeded.Actor)
+	assert.Equal(t, len(expectedNodes), succeeded.Nodes)
+
 	assert.Equal(t, expectedProject.CloudConfig, result.CloudConfig)
 	assert.Equal(t, expectedProject.ID, result.ID)
 	assert.Equal(t, expectedNetwork, result.Network)
 	assert.ElementsMatch(t, expectedNodes, result.Nodes)
 
+	val, err := env.QueryWorkflow(workflow.CostQuery, time.Now())
+	assert.NoError(t, err)
+
+	var cost *providers.CostReport
+	assert.NoError(t, val.Get(&cost))
+	assert.Equal(t, expectedProject.ID, cost.ProjectID)
+	assert.Len(t, cost.Nodes, len(expectedNodes))
+
+	val, err = env.QueryWorkflow(workflow.StatusQuery)
+	assert.NoError(t, err)
+
+	var status *workflow.ProvisionStatus
+	assert.NoError(t, val.Get(&status))
+	assert.Equal(t, &workflow.ProvisionStatus{
+		Phase:        workflow.PhaseCompleted,
+		ProjectID:    expectedProject.ID,
+		NodesPlanned: len(expectedNodes),
+		NodesCreated: len(expectedNodes),
+	}, status)
+
+	env.AssertExpectations(t)
+}
+
+func Test_CloudProvisionWorkflowBudget(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{MaxMonthlyCost: 100})
+	notifications := mockNotify(env)
+
+	// The cost in the saved plan is ignored - the worker's plan is used
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Plan: &providers.Plan{
+			MonthlyCost: 1,
+		},
+	}
+
+	mockPlan(env, &providers.Plan{MonthlyCost: 100.01})
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(t, env.IsWorkflowCompleted())
+
+	var appErr *temporal.ApplicationError
+	assert.ErrorAs(t, env.GetWorkflowError(), &appErr)
+	assert.Equal(t, "BudgetExceeded", appErr.Type())
+
+	assert.Equal(t, []notify.EventType{notify.EventStarted, notify.EventFailed}, notificationTypes(notifications))
+	assert.Contains(t, (*notifications)[1].Error, "exceeds budget")
+
+	env.AssertNotCalled(t, "CreateProjectActivity", mock.Anything, mock.Anything)
+}
+
+func Test_CloudProvisionWorkflowSavedPlanMismatch(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	mockNotify(env)
+
+	// The saved plan has been edited to use a different instance type
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Plan: &providers.Plan{
+			Nodes: []*providers.PlannedNode{{Name: "node", InstanceType: "p4d.24xlarge"}},
+		},
+	}
+	mockPlan(env, &providers.Plan{
+		Nodes: []*providers.PlannedNode{{Name: "other-node", InstanceType: "t3.medium"}},
+	})
+
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(t, env.IsWorkflowCompleted())
+
+	var appErr *temporal.ApplicationError
+	assert.ErrorAs(t, env.GetWorkflowError(), &appErr)
+	assert.Equal(t, "PlanMismatch", appErr.Type())
+
+	env.AssertNotCalled(t, "CreateProjectActivity", mock.Anything, mock.Anything)
+}
+
+func Test_CloudProvisionWorkflowCIDR(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Allocated string
+		Err       error
+	}{
+		{
+			Name:      "allocated",
+			Allocated: "10.1.0.0/24",
+		},
+		{
+			Name: "overlaps",
+			Err:  temporal.NewNonRetryableApplicationError("overlaps", "CIDROverlap", nil),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			registerPolicy(env, workflow.Policy{})
+			mockNotify(env)
+
+			cfg := providers.CloudConfig{
+				Provider: providers.CloudProviderAWS,
+				Subnet:   "10.0.0.0/24",
+				AutoCIDR: true,
+				Supernet: "10.0.0.0/8",
+			}
+
+			env.OnActivity(networkActivities.AllocateCIDRActivity, mock.Anything, workflow.CIDRRequest{
+				CIDR:     cfg.Subnet,
+				Auto:     true,
+				Supernet: cfg.Supernet,
+			}).Return(test.Allocated, test.Err).Once()
+
+			// The range isn't held once the workflow has failed
+			env.OnActivity(networkActivities.ReleaseCIDRActivity, mock.Anything, workflow.CIDRReleaseRequest{
+				WorkflowID: "default-test-workflow-id",
+				RunID:      "default-test-run-id",
+			}).Return(nil).Once()
+
+			// The plan is carved from the allocated range
+			allocatedCfg := cfg
+			allocatedCfg.Subnet = test.Allocated
+			allocatedCfg.AutoCIDR = false
+			env.OnActivity(workflow.PlanProjectActivity, mock.Anything, allocatedCfg).
+				Return(nil, temporal.NewNonRetryableApplicationError("stop", "Stop", nil)).
+				Maybe()
+
+			env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+			assert.True(env.IsWorkflowCompleted())
+
+			err := env.GetWorkflowError()
+			if test.Err != nil {
+				assert.ErrorContains(err, "error allocating network")
+				env.AssertNotCalled(t, "PlanProjectActivity", mock.Anything, mock.Anything)
+				return
+			}
+			assert.ErrorContains(err, "error generating plan")
+			env.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_CloudProvisionWorkflowSecurityGroups(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	events := mockAudit(env)
+	mockNotify(env)
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Plan: &providers.Plan{
+			SecurityGroups: []providers.SecurityGroup{
+				{Name: "bastion"},
+				{Name: "cluster"},
+			},
+			Nodes: []*providers.PlannedNode{
+				{Name: "node", SecurityGroups: []string{"cluster"}},
+			},
+		},
+	}
+	expectedProject := &providers.ProjectResult{
+		CloudConfig: cfg,
+		ID:          "some-id",
+	}
+	expectedNetwork := &providers.NetworkResult{
+		ID: "some-network-id",
+	}
+
+	env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, mock.Anything).Return(&workflow.QuotaReservation{Granted: true}, nil)
+	env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(expectedProject, nil)
+	env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, expectedProject).Return(expectedNetwork, nil)
+	for _, group := range cfg.Plan.SecurityGroups {
+		env.OnActivity(workflow.CreateSecurityGroupActivity, mock.Anything, cfg, expectedNetwork, &group).
+			Return(&providers.SecurityGroupResult{ID: "sg-" + group.Name, Name: group.Name, NetworkID: expectedNetwork.ID}, nil).
+			Once()
+	}
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, mock.Anything).Return(nil)
+
+	// The groups exist before the nodes are created
+	env.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
+	env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, cfg, mock.MatchedBy(func(project *providers.ProjectResult) bool {
+		return len(project.SecurityGroups) == len(cfg.Plan.SecurityGroups)
+	}), cfg.Plan.Nodes[0]).
+		Return(&providers.NodeResult{ID: "node-id", SecurityGroups: []string{"sg-cluster"}}, nil).
+		Once()
+
+	mockPlan(env, cfg.Plan)
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(env.IsWorkflowCompleted())
+
+	var result *providers.ProjectResult
+	assert.NoError(env.GetWorkflowResult(&result))
+	assert.Len(result.SecurityGroups, 2)
+	assert.ElementsMatch([]string{"sg-bastion", "sg-cluster"}, []string{result.SecurityGroups[0].ID, result.SecurityGroups[1].ID})
+
+	ops := make([]audit.Operation, 0, len(*events))
+	for _, e := range *events {
+		ops = append(ops, e.Operation)
+	}
+	assert.Equal([]audit.Operation{
+		audit.OperationCreateProject,
+		audit.OperationCreateNetwork,
+		audit.OperationCreateSecurityGroup,
+		audit.OperationCreateSecurityGroup,
+	}, ops)
+
+	env.AssertExpectations(t)
+}
+
+func Test_CloudProvisionWorkflowLoadBalancers(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	events := mockAudit(env)
+	mockNotify(env)
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Plan: &providers.Plan{
+			Nodes: []*providers.PlannedNode{
+				{Name: "web-0", Pool: "web"},
+				{Name: "db-0", Pool: "db"},
+				{Name: "web-1", Pool: "web"},
+			},
+			LoadBalancers: []providers.LoadBalancer{
+				{
+					Name:      "web",
+					Pool:      "web",
+					Listeners: []providers.Listener{{Protocol: providers.LoadBalancerProtocolHTTP, Port: 80}},
+				},
+			},
+		},
+	}
+	expectedProject := &providers.ProjectResult{
+		CloudConfig: cfg,
+		ID:          "some-id",
+	}
+	expectedNetwork := &providers.NetworkResult{
+		ID: "some-network-id",
+	}
+	lb := &providers.LoadBalancerResult{ID: "lb-id", Name: "web", Pool: "web", Targets: []string{}}
+
+	env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, mock.Anything).Return(&workflow.QuotaReservation{Granted: true}, nil)
+	env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(expectedProject, nil)
+	env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, expectedProject).Return(expectedNetwork, nil)
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, mock.Anything).Return(nil)
+
+	env.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
+	for _, node := range cfg.Plan.Nodes {
+		env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, cfg, mock.Anything, node).
+			Return(&providers.NodeResult{ID: node.Name, Name: node.Name, Pool: node.Pool}, nil).
+			Once()
+	}
+
+	// Only the ready nodes in the load balancer's pool are registered
+	env.OnActivity(workflow.CreateLoadBalancerActivity, mock.Anything, cfg, expectedNetwork, &cfg.Plan.LoadBalancers[0]).
+		Return(lb, nil).
+		Once()
+	env.OnActivity(workflow.SetLoadBalancerTargetsActivity, mock.Anything, cfg, lb, mock.Anything).
+		Return(func(
+			_ context.Context,
+			_ providers.CloudConfig,
+			lb *providers.LoadBalancerResult,
+			nodes []*providers.NodeResult,
+		) (*providers.LoadBalancerResult, error) {
+			updated := *lb
+			for _, node := range nodes {
+				updated.Targets = append(updated.Targets, node.ID)
+			}
+			return &updated, nil
+		}).
+		Once()
+
+	// The new targets must pass the health check
+	for _, name := range []string{"web-0", "web-1"} {
+		env.OnActivity(workflow.AwaitForTargetHealthyActivity, mock.Anything, cfg, mock.Anything, mock.MatchedBy(func(node *providers.NodeResult) bool {
+			return node.ID == name
+		})).
+			Return(nil).
+			Once()
+	}
+
+	mockPlan(env, cfg.Plan)
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(env.IsWorkflowCompleted())
+
+	var result *providers.ProjectResult
+	assert.NoError(env.GetWorkflowResult(&result))
+	assert.Len(result.LoadBalancers, 1)
+	assert.ElementsMatch([]string{"web-0", "web-1"}, result.LoadBalancers[0].Targets)
+
+	ops := make([]audit.Operation, 0, len(*events))
+	for _, e := range *events {
+		ops = append(ops, e.Operation)
+	}
+	assert.Equal([]audit.Operation{
+		audit.OperationCreateProject,
+		audit.OperationCreateNetwork,
+		audit.OperationCreateLoadBalancer,
+		audit.OperationUpdateLoadBalancer,
+	}, ops)
+
 	env.AssertExpectations(t)
 }
 
 func Test_ProvisionNodeWorkflow(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
+	mockAudit(env)
 
 	expectedNode := &providers.NodeResult{
 		ID:      "some-id",
@@ -110,11 +564,17 @@ func Test_ProvisionNodeWorkflow(t *testing.T) {
 		Nodes:       []*providers.NodeResult{},
 	}
 
+	plannedNode := &providers.PlannedNode{
+		Name: expectedNode.Name,
+	}
+
 	// Mock the activity responses
-	env.OnActivity(workflow.ProvisionNodeActivity, mock.Anything, cfg, project).Return(expectedNode, nil)
+	env.OnActivity(workflow.ProvisionNodeActivity, mock.Anything, cfg, project, plannedNode).Return(expectedNode, nil)
 	env.OnActivity(workflow.AwaitForNodeRunningActivity, mock.Anything, cfg, expectedNode).Return(expectedNodeReady, nil)
+	env.OnActivity(inventoryActivities.RecordResourcesActivity, mock.Anything, inventoryRecord(inventory.KindNode, expectedNode.ID)).
+		Return(nil).Once()
 
-	env.ExecuteWorkflow(workflow.ProvisionNodeWorkflow, cfg, project)
+	env.ExecuteWorkflow(workflow.ProvisionNodeWorkflow, cfg, project, plannedNode)
 	assert.True(t, env.IsWorkflowCompleted())
 
 	var result *providers.NodeResult
@@ -123,3 +583,680 @@ func Test_ProvisionNodeWorkflow(t *testing.T) {
 
 	env.AssertExpectations(t)
 }
+
+func Test_CloudProvisionWorkflowApproval(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Update   string
+		Approved bool
+		ErrType  string
+	}{
+		{
+			Name:     "approved",
+			Update:   workflow.ApproveUpdate,
+			Approved: true,
+		},
+		{
+			Name:    "rejected",
+			Update:  workflow.RejectUpdate,
+			ErrType: "ApprovalRejected",
+		},
+		{
+			Name:    "timed out",
+			ErrType: "ApprovalTimeout",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			registerPolicy(env, workflow.Policy{
+				Approval: providers.ApprovalPolicy{
+					InstanceTypes: []string{"p4d.24xlarge"},
+					Timeout:       time.Hour,
+				},
+			})
+			mockAudit(env)
+			notifications := mockNotify(env)
+
+			cfg := providers.CloudConfig{
+				Provider:     providers.CloudProviderAWS,
+				InstanceType: "p4d.24xlarge",
+				Plan: &providers.Plan{
+					Nodes: []*providers.PlannedNode{
+						{Name: "node", InstanceType: "p4d.24xlarge"},
+					},
+				},
+			}
+			expectedProject := &providers.ProjectResult{
+				CloudConfig: cfg,
+				ID:          "some-id",
+			}
+			expectedNetwork := &providers.NetworkResult{
+				ID: "some-network-id",
+			}
+
+			env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, mock.Anything).
+				Return(&workflow.QuotaReservation{Granted: true}, nil).Maybe()
+			env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(expectedProject, nil).Maybe()
+			env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, mock.Anything).Return(expectedNetwork, nil).Maybe()
+			env.RegisterWorkflow(workflow.ProvisionNodeWorkflow)
+			env.OnWorkflow("ProvisionNodeWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(&providers.NodeResult{Name: "node"}, nil).Maybe()
+
+			if test.Update != "" {
+				env.RegisterDelayedCallback(func() {
+					env.UpdateWorkflow(test.Update, "decision", &testsuite.TestUpdateCallback{
+						OnAccept:   func() {},
+						OnReject:   func(err error) { assert.Fail("update should not be rejected", err) },
+						OnComplete: func(any, error) {},
+					}, workflow.ApprovalInput{
+						Approver: "alice",
+						Comment:  "some comment",
+					})
+				}, time.Minute)
+			}
+
+			mockPlan(env, cfg.Plan)
+			env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+			assert.True(env.IsWorkflowCompleted())
+
+			assert.Contains(notificationTypes(notifications), notify.EventApprovalRequired)
+			for _, n := range *notifications {
+				if n.Type == notify.EventApprovalRequired {
+					assert.Equal([]string{"instance type p4d.24xlarge requires approval"}, n.Reasons)
+				}
+			}
+
+			if test.ErrType != "" {
+				err := env.GetWorkflowError()
+
+				var appErr *temporal.ApplicationError
+				assert.ErrorAs(err, &appErr)
+				assert.Equal(test.ErrType, appErr.Type())
+
+				env.AssertNotCalled(t, "CreateProjectActivity", mock.Anything, mock.Anything)
+				return
+			}
+
+			var result *providers.ProjectResult
+			assert.NoError(env.GetWorkflowResult(&result))
+			assert.Equal(test.Approved, result.Approval.Approved)
+			assert.Equal("alice", result.Approval.Approver)
+			assert.Equal("some comment", result.Approval.Comment)
+		})
+	}
+}
+
+func Test_PlanWorkflow(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		VMCount:  1,
+	}
+	expectedPlan := &providers.Plan{
+		Project: providers.PlannedProject{Name: "some-project"},
+		Network: providers.PlannedNetwork{Region: "some-region", Subnet: "10.0.0.0/24"},
+		Nodes: []*providers.PlannedNode{
+			{Name: "some-node", Zone: "some-zone", InstanceType: "some-type"},
+		},
+	}
+
+	env.OnActivity(workflow.PlanProjectActivity, mock.Anything, cfg).Return(expectedPlan, nil)
+
+	env.ExecuteWorkflow(workflow.PlanWorkflow, cfg)
+	assert.True(t, env.IsWorkflowCompleted())
+
+	var result *providers.Plan
+	assert.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, expectedPlan, result)
+
+	env.AssertExpectations(t)
+}
+
+func Test_CloudProvisionWorkflowQuota(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Queued      bool
+		ProjectErr  error
+		ExpectError bool
+	}{
+		{
+			Name: "granted",
+		},
+		{
+			Name:   "queued",
+			Queued: true,
+		},
+		{
+			Name:        "released on failure",
+			ProjectErr:  temporal.NewNonRetryableApplicationError("some error", "some-type", nil),
+			ExpectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			registerPolicy(env, workflow.Policy{})
+			mockAudit(env)
+			mockNotify(env)
+
+			cfg := providers.CloudConfig{
+				Provider: providers.CloudProviderAWS,
+				Region:   "some-region",
+				Plan: &providers.Plan{
+					Nodes: []*providers.PlannedNode{},
+				},
+			}
+
+			env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, workflow.QuotaRequest{
+				WorkflowID: "default-test-workflow-id",
+				RunID:      "default-test-run-id",
+				Provider:   cfg.Provider,
+				Region:     cfg.Region,
+				Nodes:      0,
+				Networks:   1,
+			}).Return(&workflow.QuotaReservation{Granted: !test.Queued, Queued: test.Queued}, nil).Once()
+
+			if test.Queued {
+				env.RegisterDelayedCallback(func() {
+					env.SignalWorkflow(workflow.QuotaGrantedSignal, workflow.QuotaReservation{Granted: true})
+				}, time.Minute)
+			}
+
+			if test.ProjectErr != nil {
+				env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(nil, test.ProjectErr)
+				env.OnActivity(quotaActivities.ReleaseQuotaActivity, mock.Anything, mock.Anything).Return(nil).Once()
+			} else {
+				env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(&providers.ProjectResult{ID: "some-id"}, nil)
+				env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, mock.Anything).Return(&providers.NetworkResult{}, nil)
+			}
+
+			mockPlan(env, cfg.Plan)
+			env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+			assert.True(env.IsWorkflowCompleted())
+
+			if test.ExpectError {
+				assert.Error(env.GetWorkflowError())
+			} else {
+				assert.NoError(env.GetWorkflowError())
+			}
+
+			env.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_CloudProvisionWorkflowQuotaExceeded(t *testing.T) {
+	assert := assert.New(t)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerPolicy(env, workflow.Policy{})
+	mockAudit(env)
+	mockNotify(env)
+
+	cfg := providers.CloudConfig{
+		Provider: providers.CloudProviderAWS,
+		Region:   "some-region",
+		Plan: &providers.Plan{
+			Nodes: []*providers.PlannedNode{{Name: "node-0"}},
+		},
+	}
+
+	// The quota manager rejects a request which can never fit
+	handle := &mocks.WorkflowUpdateHandle{}
+	handle.On("Get", mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("request exceeds total quota", "QuotaExceeded", nil))
+	c := &mocks.Client{}
+	c.On("NewWithStartWorkflowOperation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	c.On("UpdateWithStartWorkflow", mock.Anything, mock.Anything).Return(handle, nil).Once()
+	env.RegisterActivity(&workflow.QuotaActivities{Client: c})
+
+	mockPlan(env, cfg.Plan)
+	env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+	assert.True(env.IsWorkflowCompleted())
+
+	// The request isn't retried
+	assert.ErrorContains(env.GetWorkflowError(), "type: QuotaExceeded, retryable: false")
+	c.AssertExpectations(t)
+}
+
+func Test_CloudProvisionWorkflowFailureCleanup(t *testing.T) {
+	tests := []struct {
+		Name      string
+		DeleteErr error
+		Released  bool
+	}{
+		{
+			Name:     "cleaned up",
+			Released: true,
+		},
+		{
+			Name:      "cleanup failed",
+			DeleteErr: temporal.NewNonRetryableApplicationError("some error", "some-type", nil),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			registerPolicy(env, workflow.Policy{})
+			mockAudit(env)
+			mockNotify(env)
+
+			cfg := providers.CloudConfig{
+				Provider: providers.CloudProviderAWS,
+				Region:   "some-region",
+				Plan: &providers.Plan{
+					Nodes: []*providers.PlannedNode{},
+				},
+			}
+
+			env.OnActivity(quotaActivities.ReserveQuotaActivity, mock.Anything, mock.Anything).Return(&workflow.QuotaReservation{Granted: true}, nil)
+			env.OnActivity(workflow.CreateProjectActivity, mock.Anything, cfg).Return(&providers.ProjectResult{ID: "some-id"}, nil)
+			env.OnActivity(workflow.SetupNetworkActivity, mock.Anything, cfg, mock.Anything).
+				Return(nil, temporal.NewNonRetryableApplicationError("some error", "some-type", nil))
+
+			// The project was created so must be deleted before the quota is released
+			env.OnActivity(workflow.DeleteProjectActivity, mock.Anything, mock.Anything, mock.Anything).Return(test.DeleteErr).Once()
+			if test.Released {
+				env.OnActivity(quotaActivities.ReleaseQuotaActivity, mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			mockPlan(env, cfg.Plan)
+			env.ExecuteWorkflow(workflow.CloudProvisionWorkflow, cfg)
+			assert.True(env.IsWorkflowCompleted())
+			assert.ErrorContains(env.GetWorkflowError(), "error setting up network activity")
+
+			if !test.Released {
+				env.AssertNotCalled(t, "ReleaseQuotaActivity", mock.Anything, mock.Anything)
+			}
+			env.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_QuotaManagerWorkflow(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	limits := workflow.QuotaLimits{Nodes: 5, Networks: 2}
+
+	reserve := func(id string, nodes int, policy providers.QuotaPolicy, check func(*workflow.QuotaReservation, error)) {
+		env.UpdateWorkflow(workflow.QuotaReserveUpdate, id, &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { assert.Fail(t, "update should not be rejected", err) },
+			OnComplete: func(res any, err error) {
+				reservation, _ := res.(*workflow.QuotaReservation)
+				check(reservation, err)
+			},
+		}, workflow.QuotaRequest{
+			WorkflowID: id,
+			RunID:      "run-" + id,
+			Nodes:      nodes,
+			Networks:   1,
+			Policy:     policy,
+		})
+	}
+
+	// The queued workflow is told when capacity is released
+	env.OnSignalExternalWorkflow(mock.Anything, "wf3", "run-wf3", workflow.QuotaGrantedSignal, mock.Anything).Return(nil).Once()
+
+	env.RegisterDelayedCallback(func() {
+		reserve("wf1", 3, providers.QuotaPolicyQueue, func(r *workflow.QuotaReservation, err error) {
+			assert.NoError(t, err)
+			assert.True(t, r.Granted)
+		})
+	}, time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		reserve("wf2", 3, providers.QuotaPolicyFailFast, func(r *workflow.QuotaReservation, err error) {
+			var appErr *temporal.ApplicationError
+			assert.ErrorAs(t, err, &appErr)
+			assert.Equal(t, "QuotaExceeded", appErr.Type())
+		})
+	}, time.Second*2)
+
+	// Another run of the workflow can't reserve until the capacity is released
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.QuotaReserveUpdate, "wf1-rerun", &testsuite.TestUpdateCallback{
+			OnAccept: func() { assert.Fail(t, "update should be rejected") },
+			OnReject: func(err error) {
+				var appErr *temporal.ApplicationError
+				assert.ErrorAs(t, err, &appErr)
+				assert.Equal(t, "QuotaHeld", appErr.Type())
+			},
+			OnComplete: func(any, error) {},
+		}, workflow.QuotaRequest{WorkflowID: "wf1", RunID: "another-run", Nodes: 1, Networks: 1})
+	}, time.Second+time.Millisecond)
+
+	// A request which can never fit is rejected rather than queued
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.QuotaReserveUpdate, "wf4", &testsuite.TestUpdateCallback{
+			OnAccept: func() { assert.Fail(t, "update should be rejected") },
+			OnReject: func(err error) {
+				var appErr *temporal.ApplicationError
+				assert.ErrorAs(t, err, &appErr)
+				assert.Equal(t, "QuotaExceeded", appErr.Type())
+				assert.True(t, appErr.NonRetryable())
+			},
+			OnComplete: func(any, error) {},
+		}, workflow.QuotaRequest{WorkflowID: "wf4", Nodes: 6, Networks: 1})
+	}, time.Second*2)
+
+	env.RegisterDelayedCallback(func() {
+		reserve("wf3", 3, providers.QuotaPolicyQueue, func(r *workflow.QuotaReservation, err error) {
+			assert.NoError(t, err)
+			assert.True(t, r.Queued)
+		})
+	}, time.Second*3)
+
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.QuotaReleaseUpdate, "release-wf1", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { assert.Fail(t, "update should not be rejected", err) },
+			OnComplete: func(_ any, err error) { assert.NoError(t, err) },
+		}, workflow.QuotaRequest{WorkflowID: "wf1", RunID: "run-wf1"})
+	}, time.Second*4)
+
+	// Releasing for another run leaves the capacity with the run holding it
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.QuotaReleaseUpdate, "release-wf3", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { assert.Fail(t, "update should not be rejected", err) },
+			OnComplete: func(_ any, err error) { assert.NoError(t, err) },
+		}, workflow.QuotaRequest{WorkflowID: "wf3", RunID: "another-run"})
+	}, time.Second*4+time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		val, err := env.QueryWorkflow(workflow.QuotaQuery)
+		assert.NoError(t, err)
+
+		var usage workflow.QuotaUsage
+		assert.NoError(t, val.Get(&usage))
+		assert.Equal(t, 3, usage.Nodes)
+		assert.Equal(t, 1, usage.Networks)
+		assert.Equal(t, 0, usage.Queued)
+
+		env.CancelWorkflow()
+	}, time.Second*5)
+
+	env.ExecuteWorkflow(workflow.QuotaManagerWorkflow, workflow.QuotaManagerState{Limits: limits})
+	assert.True(t, env.IsWorkflowCompleted())
+
+	env.AssertExpectations(t)
+}
+
+func Test_CIDRManagerWorkflow(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// The networks recorded in the inventory are avoided too
+	recorded := []workflow.CIDRAllocation{
+		{WorkflowID: "old-0", ProjectID: "project-0", CIDR: "10.0.0.0/16"},
+		{WorkflowID: "old-1", ProjectID: "project-1", CIDR: "10.1.0.0/16"},
+	}
+
+	tests := []struct {
+		WorkflowID string
+		Request    workflow.CIDRRequest
+		Expected   string
+		ErrType    string
+	}{
+		{
+			WorkflowID: "wf1",
+			Request:    workflow.CIDRRequest{CIDR: "10.2.0.0/24"},
+			Expected:   "10.2.0.0/24",
+		},
+		{
+			// Allocated to wf1, although its network hasn't been recorded
+			WorkflowID: "wf2",
+			Request:    workflow.CIDRRequest{CIDR: "10.2.0.0/24"},
+			ErrType:    "CIDROverlap",
+		},
+		{
+			WorkflowID: "wf3",
+			Request:    workflow.CIDRRequest{CIDR: "10.0.0.0/16", Auto: true, Supernet: "10.0.0.0/8"},
+			Expected:   "10.3.0.0/16",
+		},
+		{
+			// Retried requests get the same range
+			WorkflowID: "wf1",
+			Request:    workflow.CIDRRequest{CIDR: "10.2.0.0/24"},
+			Expected:   "10.2.0.0/24",
+		},
+		{
+			WorkflowID: "wf4",
+			Request:    workflow.CIDRRequest{CIDR: "10.0.0.0/16", Auto: true, Supernet: "10.0.0.0/15"},
+			ErrType:    "NoFreeCIDR",
+		},
+		{
+			WorkflowID: "wf5",
+			Request:    workflow.CIDRRequest{CIDR: "10.0.0.0"},
+			ErrType:    "InvalidCIDR",
+		},
+	}
+
+	allocate := func(updateID, id string, req workflow.CIDRRequest, expected, errType string) {
+		env.UpdateWorkflow(workflow.CIDRAllocateUpdate, updateID, &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { assert.Fail(t, "update should not be rejected", err) },
+			OnComplete: func(res any, err error) {
+				if errType != "" {
+					var appErr *temporal.ApplicationError
+					assert.ErrorAs(t, err, &appErr)
+					assert.Equal(t, errType, appErr.Type())
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, expected, res)
+			},
+		}, workflow.CIDRAllocateRequest{
+			CIDRRequest: req,
+			WorkflowID:  id,
+			RunID:       "run-" + id,
+			Recorded:    recorded,
+		})
+	}
+
+	for i, test := range tests {
+		env.RegisterDelayedCallback(func() {
+			allocate(fmt.Sprintf("allocate-%d", i), test.WorkflowID, test.Request, test.Expected, test.ErrType)
+		}, time.Second*time.Duration(i+1))
+	}
+
+	// Once wf1 has released its range, it can be given to wf2
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.CIDRReleaseUpdate, "release-wf1", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { assert.Fail(t, "update should not be rejected", err) },
+			OnComplete: func(_ any, err error) { assert.NoError(t, err) },
+		}, workflow.CIDRReleaseRequest{WorkflowID: "wf1", RunID: "run-wf1"})
+	}, time.Second*10)
+
+	// Another run of wf3 can't have a range until wf3's is released, and
+	// releasing for another run leaves wf3 with its range
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.CIDRAllocateUpdate, "allocate-rerun", &testsuite.TestUpdateCallback{
+			OnAccept: func() { assert.Fail(t, "update should be rejected") },
+			OnReject: func(err error) {
+				var appErr *temporal.ApplicationError
+				assert.ErrorAs(t, err, &appErr)
+				assert.Equal(t, "CIDRHeld", appErr.Type())
+			},
+			OnComplete: func(any, error) {},
+		}, workflow.CIDRAllocateRequest{
+			CIDRRequest: workflow.CIDRRequest{CIDR: "10.4.0.0/16"},
+			WorkflowID:  "wf3",
+			RunID:       "another-run",
+		})
+
+		env.UpdateWorkflow(workflow.CIDRReleaseUpdate, "release-rerun", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { assert.Fail(t, "update should not be rejected", err) },
+			OnComplete: func(_ any, err error) { assert.NoError(t, err) },
+		}, workflow.CIDRReleaseRequest{WorkflowID: "wf3", RunID: "another-run"})
+	}, time.Second*10+time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		allocate("allocate-released", "wf2", workflow.CIDRRequest{CIDR: "10.2.0.0/24"}, "10.2.0.0/24", "")
+	}, time.Second*11)
+
+	env.RegisterDelayedCallback(func() {
+		val, err := env.QueryWorkflow(workflow.CIDRQuery)
+		assert.NoError(t, err)
+
+		var allocations []workflow.CIDRAllocation
+		assert.NoError(t, val.Get(&allocations))
+		assert.Equal(t, []workflow.CIDRAllocation{
+			{WorkflowID: "wf2", RunID: "run-wf2", CIDR: "10.2.0.0/24"},
+			{WorkflowID: "wf3", RunID: "run-wf3", CIDR: "10.3.0.0/16"},
+		}, allocations)
+
+		env.CancelWorkflow()
+	}, time.Second*12)
+
+	env.ExecuteWorkflow(workflow.CIDRManagerWorkflow, workflow.CIDRManagerState{})
+	assert.True(t, env.IsWorkflowCompleted())
+}
+
+func Test_ProviderTaskQueue(t *testing.T) {
+	assert.Equal(
+		t,
+		"cloud-provisioning-aws-eu-west-2",
+		workflow.ProviderTaskQueue("cloud-provisioning", providers.CloudProviderAWS, "eu-west-2"),
+	)
+}
+
+func Test_SearchAttributes(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := providers.CloudConfig{
+		Provider:    providers.CloudProviderAWS,
+		Region:      "eu-west-2",
+		VMCount:     3,
+		Owner:       "alice",
+		Environment: "dev",
+	}
+
+	attrs := workflow.SearchAttributes(cfg)
+
+	provider, _ := attrs.GetKeyword(workflow.ProviderSearchAttribute)
+	assert.Equal("aws", provider)
+	region, _ := attrs.GetKeyword(workflow.RegionSearchAttribute)
+	assert.Equal("eu-west-2", region)
+	nodes, _ := attrs.GetInt64(workflow.NodeCountSearchAttribute)
+	assert.Equal(int64(3), nodes)
+	owner, _ := attrs.GetKeyword(workflow.OwnerSearchAttribute)
+	assert.Equal("alice", owner)
+	env, _ := attrs.GetKeyword(workflow.EnvironmentSearchAttribute)
+	assert.Equal("dev", env)
+	phase, _ := attrs.GetKeyword(workflow.PhaseSearchAttribute)
+	assert.Equal(string(workflow.PhasePlanning), phase)
+
+	// The plan's nodes take precedence over the count
+	cfg.Plan = &providers.Plan{Nodes: []*providers.PlannedNode{{Name: "node"}}}
+	nodes, _ = workflow.SearchAttributes(cfg).GetInt64(workflow.NodeCountSearchAttribute)
+	assert.Equal(int64(1), nodes)
+
+	assert.Equal("provision-some-project", workflow.ProvisionWorkflowID("some-project"))
+}
+
+type mockOperatorService struct {
+	operatorservice.OperatorServiceClient
+	mock.Mock
+}
+
+func (m *mockOperatorService) ListSearchAttributes(
+	ctx context.Context,
+	req *operatorservice.ListSearchAttributesRequest,
+	opts ...grpc.CallOption,
+) (*operatorservice.ListSearchAttributesResponse, error) {
+	args := m.Called(req.GetNamespace())
+	return args.Get(0).(*operatorservice.ListSearchAttributesResponse), args.Error(1)
+}
+
+func Test_CheckSearchAttributes(t *testing.T) {
+	assert := assert.New(t)
+
+	registered := map[string]enums.IndexedValueType{
+		"CloudProvider":     enums.INDEXED_VALUE_TYPE_KEYWORD,
+		"CloudRegion":       enums.INDEXED_VALUE_TYPE_KEYWORD,
+		"NodeCount":         enums.INDEXED_VALUE_TYPE_INT,
+		"Owner":             enums.INDEXED_VALUE_TYPE_KEYWORD,
+		"Environment":       enums.INDEXED_VALUE_TYPE_KEYWORD,
+		"ProjectID":         enums.INDEXED_VALUE_TYPE_KEYWORD,
+		"ProvisioningPhase": enums.INDEXED_VALUE_TYPE_KEYWORD,
+	}
+
+	svc := &mockOperatorService{}
+	svc.On("ListSearchAttributes", "default").
+		Return(&operatorservice.ListSearchAttributesResponse{CustomAttributes: registered}, nil).Once()
+	assert.NoError(workflow.CheckSearchAttributes(context.Background(), svc, "default"))
+
+	// Missing or registered with the wrong type
+	delete(registered, "ProvisioningPhase")
+	registered["NodeCount"] = enums.INDEXED_VALUE_TYPE_KEYWORD
+	svc.On("ListSearchAttributes", "default").
+		Return(&operatorservice.ListSearchAttributesResponse{CustomAttributes: registered}, nil).Once()
+	err := workflow.CheckSearchAttributes(context.Background(), svc, "default")
+	assert.EqualError(err, "search attributes not registered in namespace default: NodeCount (Int), ProvisioningPhase (Keyword)")
+
+	svc.AssertExpectations(t)
+}
+
+func Test_ListFilterQuery(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Filter   workflow.ListFilter
+		Expected string
+	}{
+		{
+			Name:     "no filters",
+			Expected: "WorkflowType IN ('CloudProvisionWorkflow', 'TeardownWorkflow')",
+		},
+		{
+			Name: "filters",
+			Filter: workflow.ListFilter{
+				Provider: "aws",
+				Owner:    "alice",
+				Status:   "Running",
+			},
+			Expected: "WorkflowType IN ('CloudProvisionWorkflow', 'TeardownWorkflow') AND CloudProvider = 'aws' AND Owner = 'alice' AND " +
+				"ExecutionStatus = 'Running'",
+		},
+		{
+			Name: "escaped",
+			Filter: workflow.ListFilter{
+				Owner: "o'brien",
+			},
+			Expected: "WorkflowType IN ('CloudProvisionWorkflow', 'TeardownWorkflow') AND Owner = 'o\\'brien'",
+		},
+		{
+			Name: "escaped backslash",
+			Filter: workflow.ListFilter{
+				Owner: "alice\\' OR 1=1",
+			},
+			Expected: "WorkflowType IN ('CloudProvisionWorkflow', 'TeardownWorkflow') AND Owner = 'alice\\\\\\' OR 1=1'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, test.Filter.Query())
+		})
+	}
+}